@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
 	"strconv"
 	"strings"
@@ -167,7 +168,7 @@ func (c *APIClient) doRequestRaw(
 	return nil, errors.Errorf("failed to do request after %d retries", authRetryLimit)
 }
 
-func decodeQueryResponse(rawResp *rawHTTPResponse) (*QueryResponse, error) {
+func decodeQueryResponse(types *TypeRegistry, rawResp *rawHTTPResponse) (*QueryResponse, error) {
 	if rawResp == nil {
 		return nil, errors.New("empty query response")
 	}
@@ -176,7 +177,7 @@ func decodeQueryResponse(rawResp *rawHTTPResponse) (*QueryResponse, error) {
 		if err := json.Unmarshal(rawResp.body, &resp); err != nil {
 			return nil, errors.Wrap(err, "failed to unmarshal response body")
 		}
-		if err := materializeJSONQueryRows(&resp); err != nil {
+		if err := materializeJSONQueryRows(&resp, types); err != nil {
 			return nil, err
 		}
 		return &resp, nil
@@ -210,7 +211,7 @@ func decodeQueryResponse(rawResp *rawHTTPResponse) (*QueryResponse, error) {
 		resp.Schema = &fields
 	}
 
-	typedRows, err := arrowReaderToRows(reader, resp.Schema, resp.Settings)
+	typedRows, err := arrowReaderToRows(reader, resp.Schema, resp.Settings, types)
 	if err != nil {
 		return nil, err
 	}
@@ -218,11 +219,11 @@ func decodeQueryResponse(rawResp *rawHTTPResponse) (*QueryResponse, error) {
 	return &resp, nil
 }
 
-func arrowReaderToRows(reader *ipc.Reader, fields *[]DataField, settings *Settings) ([][]driver.Value, error) {
+func arrowReaderToRows(reader *ipc.Reader, fields *[]DataField, settings *Settings, types *TypeRegistry) ([][]driver.Value, error) {
 	typedRows := make([][]driver.Value, 0)
 	for reader.Next() {
 		record := reader.Record()
-		batchTypedRows, err := arrowRecordToRows(record, fields, settings)
+		batchTypedRows, err := arrowRecordToRows(record, fields, settings, types)
 		if err != nil {
 			return nil, err
 		}
@@ -234,7 +235,7 @@ func arrowReaderToRows(reader *ipc.Reader, fields *[]DataField, settings *Settin
 	return typedRows, nil
 }
 
-func arrowRecordToRows(record arrow.Record, fields *[]DataField, settings *Settings) ([][]driver.Value, error) {
+func arrowRecordToRows(record arrow.Record, fields *[]DataField, settings *Settings, types *TypeRegistry) ([][]driver.Value, error) {
 	if fields == nil {
 		derivedFields, err := dataFieldsFromArrowSchema(record.Schema())
 		if err != nil {
@@ -253,7 +254,7 @@ func arrowRecordToRows(record arrow.Record, fields *[]DataField, settings *Setti
 		}
 	}
 
-	opts, err := queryResponseColumnTypeOptions(settings)
+	opts, err := queryResponseColumnTypeOptions(settings, types)
 	if err != nil {
 		return nil, err
 	}
@@ -267,6 +268,22 @@ func arrowRecordToRows(record arrow.Record, fields *[]DataField, settings *Setti
 		descs[i] = desc.Normalize()
 	}
 
+	readers := make([]arrowValueReader, len(descs))
+	nestedTypes := make([]*nestedType, len(descs))
+	for i, desc := range descs {
+		readers[i], err = registeredArrowReader(desc, opts)
+		if err != nil {
+			return nil, err
+		}
+		if readers[i] != nil || !isNestedTypeName(desc.Name) {
+			continue
+		}
+		nestedTypes[i], err = newNestedType(desc, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	typedRows := make([][]driver.Value, 0, int(record.NumRows()))
 	columns := record.Columns()
 	for rowIdx := 0; rowIdx < int(record.NumRows()); rowIdx++ {
@@ -281,6 +298,24 @@ func arrowRecordToRows(record arrow.Record, fields *[]DataField, settings *Setti
 				continue
 			}
 
+			if read := readers[colIdx]; read != nil {
+				v, err := read(column, rowIdx)
+				if err != nil {
+					return nil, err
+				}
+				typedRow[colIdx] = v
+				continue
+			}
+
+			if nested := nestedTypes[colIdx]; nested != nil {
+				v, err := nested.fromArrow(column, rowIdx)
+				if err != nil {
+					return nil, err
+				}
+				typedRow[colIdx] = v.Interface()
+				continue
+			}
+
 			typedValue, err := materializeArrowDriverValue(descs[colIdx], column, rowIdx, opts)
 			if err != nil {
 				return nil, err
@@ -292,6 +327,34 @@ func arrowRecordToRows(record arrow.Record, fields *[]DataField, settings *Setti
 	return typedRows, nil
 }
 
+type arrowValueReader func(column arrow.Array, rowIdx int) (driver.Value, error)
+
+// registeredArrowReader returns the reader of a type registered in a
+// TypeRegistry, or nil. Types with only a ColumnTypeFactory are read as text
+// and decoded by the ColumnType, like JSON results.
+func registeredArrowReader(desc *TypeDesc, opts *ColumnTypeOptions) (arrowValueReader, error) {
+	if fn := opts.types.arrowValue(desc.Name); fn != nil {
+		return func(column arrow.Array, rowIdx int) (driver.Value, error) {
+			return fn(desc, column, rowIdx, opts)
+		}, nil
+	}
+	factory := opts.types.columnType(desc.Name)
+	if factory == nil {
+		return nil, nil
+	}
+	colType, err := factory(desc, opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(column arrow.Array, rowIdx int) (driver.Value, error) {
+		text, err := formatArrowColumnValue(desc, column, rowIdx, opts.timezone)
+		if err != nil {
+			return nil, err
+		}
+		return colType.Parse(text)
+	}, nil
+}
+
 func formatArrowColumnValue(desc *TypeDesc, column arrow.Array, rowIdx int, location *time.Location) (string, error) {
 	if desc != nil && desc.Name == "Timestamp_Tz" {
 		if array, ok := column.(*arrowarray.Decimal128); ok {
@@ -317,6 +380,18 @@ func materializeArrowDriverValue(desc *TypeDesc, column arrow.Array, rowIdx int,
 		return nil, nil
 	case "Binary":
 		return materializeArrowBinaryDriverValue(column, rowIdx)
+	case "Decimal":
+		return materializeArrowDecimalDriverValue(column, rowIdx)
+	case "Int128", "Int256", "UInt128", "UInt256":
+		return materializeArrowBigIntDriverValue(desc.Name, column, rowIdx)
+	case "Variant", "VariantObject", "VariantArray":
+		return materializeArrowVariantDriverValue(column, rowIdx)
+	case "Interval":
+		return materializeArrowIntervalDriverValue(column, rowIdx)
+	case "Bitmap":
+		return materializeArrowBitmapDriverValue(column, rowIdx)
+	case "Vector":
+		return materializeArrowVectorDriverValue(desc, column, rowIdx)
 	case "Date":
 		return materializeArrowDateDriverValue(column, rowIdx)
 	case "Timestamp":
@@ -346,6 +421,46 @@ func materializeArrowBinaryDriverValue(column arrow.Array, rowIdx int) (driver.V
 	}
 }
 
+func materializeArrowVariantDriverValue(column arrow.Array, rowIdx int) (driver.Value, error) {
+	marshaled, ok := column.(marshaledArrowArray)
+	if !ok {
+		return nil, fmt.Errorf("arrow column does not support row materialization: %T", column)
+	}
+
+	switch value := marshaled.GetOneForMarshal(rowIdx).(type) {
+	case []byte:
+		return variantText(value)
+	case string:
+		return variantText([]byte(value))
+	default:
+		return nil, fmt.Errorf("unsupported arrow variant value type %T", value)
+	}
+}
+
+func materializeArrowDecimalDriverValue(column arrow.Array, rowIdx int) (driver.Value, error) {
+	dt, ok := column.DataType().(arrow.DecimalType)
+	if !ok {
+		// some servers send decimals as strings
+		return formatArrowColumnValue(nil, column, rowIdx, nil)
+	}
+
+	var unscaled *big.Int
+	switch array := column.(type) {
+	case *arrowarray.Decimal32:
+		unscaled = big.NewInt(int64(array.Value(rowIdx)))
+	case *arrowarray.Decimal64:
+		unscaled = big.NewInt(int64(array.Value(rowIdx)))
+	case *arrowarray.Decimal128:
+		unscaled = array.Value(rowIdx).BigInt()
+	case *arrowarray.Decimal256:
+		unscaled = array.Value(rowIdx).BigInt()
+	default:
+		return nil, fmt.Errorf("unsupported arrow decimal column %T", column)
+	}
+	// as text, like the JSON format, with exactly scale digits after the point
+	return NewDecimal(unscaled, dt.GetScale()).String(), nil
+}
+
 func materializeArrowGeoDriverValue(kind string, column arrow.Array, rowIdx int, format geoOutputFormat) (driver.Value, error) {
 	marshaled, ok := column.(marshaledArrowArray)
 	if !ok {
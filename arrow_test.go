@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql/driver"
 	"encoding/json"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -15,6 +16,7 @@ import (
 	"github.com/apache/arrow-go/v18/arrow"
 	arrowarray "github.com/apache/arrow-go/v18/arrow/array"
 	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/arrow/decimal256"
 	"github.com/apache/arrow-go/v18/arrow/ipc"
 	"github.com/apache/arrow-go/v18/arrow/memory"
 	"github.com/stretchr/testify/assert"
@@ -72,7 +74,7 @@ func TestDecodeArrowResponseMaterializesRows(t *testing.T) {
 		builder.Field(3).(*arrowarray.TimestampBuilder).AppendTime(ts)
 	})
 
-	decoded, err := decodeQueryResponse(&rawHTTPResponse{
+	decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
 		headers: http.Header{contentType: []string{arrowStreamContentType}},
 		body:    payload,
 	})
@@ -110,7 +112,7 @@ func TestDecodeArrowResponseDerivesSchemaFromArrowSchema(t *testing.T) {
 		builder.Field(1).(*arrowarray.StringBuilder).AppendValues([]string{"derived"}, nil)
 	})
 
-	decoded, err := decodeQueryResponse(&rawHTTPResponse{
+	decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
 		headers: http.Header{contentType: []string{arrowStreamContentType}},
 		body:    payload,
 	})
@@ -139,7 +141,7 @@ func TestDecodeArrowResponseMaterializesNullTypeAsNil(t *testing.T) {
 		builder.Field(0).(*arrowarray.NullBuilder).AppendNull()
 	})
 
-	decoded, err := decodeQueryResponse(&rawHTTPResponse{
+	decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
 		headers: http.Header{contentType: []string{arrowStreamContentType}},
 		body:    payload,
 	})
@@ -175,7 +177,7 @@ func TestDecodeArrowResponseMaterializesTimestampTZ(t *testing.T) {
 		builder.Field(0).(*arrowarray.Decimal128Builder).Append(value)
 	})
 
-	decoded, err := decodeQueryResponse(&rawHTTPResponse{
+	decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
 		headers: http.Header{contentType: []string{arrowStreamContentType}},
 		body:    payload,
 	})
@@ -249,7 +251,7 @@ func TestDecodeArrowResponseMaterializesGeo(t *testing.T) {
 				builder.Field(0).(*arrowarray.BinaryBuilder).Append(tc.input)
 			})
 
-			decoded, err := decodeQueryResponse(&rawHTTPResponse{
+			decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
 				headers: http.Header{contentType: []string{arrowStreamContentType}},
 				body:    payload,
 			})
@@ -275,7 +277,7 @@ func TestDecodeArrowResponseMaterializesBinary(t *testing.T) {
 		builder.Field(0).(*arrowarray.BinaryBuilder).Append(input)
 	})
 
-	decoded, err := decodeQueryResponse(&rawHTTPResponse{
+	decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
 		headers: http.Header{contentType: []string{arrowStreamContentType}},
 		body:    payload,
 	})
@@ -285,6 +287,99 @@ func TestDecodeArrowResponseMaterializesBinary(t *testing.T) {
 	assert.Equal(t, input, decoded.typedRows[0][0])
 }
 
+func TestDecodeArrowResponseMaterializesDecimal(t *testing.T) {
+	resp := QueryResponse{
+		ID: "query-decimal",
+		Schema: &[]DataField{
+			{Name: "d128", Type: "Decimal(38, 4)"},
+			{Name: "d256", Type: "Decimal(76, 2)"},
+		},
+	}
+
+	big256, ok := new(big.Int).SetString("-123456789012345678901234567890123456789012345678901234567890123456789", 10)
+	require.True(t, ok)
+	payload := buildArrowPayload(t, resp, []arrow.Field{
+		{Name: "d128", Type: &arrow.Decimal128Type{Precision: 38, Scale: 4}},
+		{Name: "d256", Type: &arrow.Decimal256Type{Precision: 76, Scale: 2}},
+	}, func(builder *arrowarray.RecordBuilder) {
+		builder.Field(0).(*arrowarray.Decimal128Builder).Append(decimal128.FromI64(123456789))
+		builder.Field(1).(*arrowarray.Decimal256Builder).Append(decimal256.FromBigInt(big256))
+	})
+
+	decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
+		headers: http.Header{contentType: []string{arrowStreamContentType}},
+		body:    payload,
+	})
+	require.NoError(t, err)
+	require.Len(t, decoded.typedRows, 1)
+
+	assert.Equal(t, "12345.6789", decoded.typedRows[0][0])
+	assert.Equal(t, "-1234567890123456789012345678901234567890123456789012345678901234567.89", decoded.typedRows[0][1])
+
+	colType, err := NewColumnType("Decimal(38, 4)", nil)
+	require.NoError(t, err)
+	fromJSON, err := colType.Parse("12345.6789")
+	require.NoError(t, err)
+	assert.Equal(t, decoded.typedRows[0][0], fromJSON)
+}
+
+func TestDecodeArrowResponseMaterializesNestedTypes(t *testing.T) {
+	resp := QueryResponse{
+		ID:       "query-nested",
+		Settings: &Settings{TimeZone: time.UTC.String()},
+		Schema: &[]DataField{
+			{Name: "a", Type: "Array(Int32 NULL)"},
+			{Name: "m", Type: "Map(String, Array(Int64))"},
+			{Name: "t", Type: "Tuple(Int32, String NULL)"},
+		},
+	}
+
+	payload := buildArrowPayload(t, resp, []arrow.Field{
+		{Name: "a", Type: arrow.ListOf(arrow.PrimitiveTypes.Int32)},
+		{Name: "m", Type: arrow.MapOf(arrow.BinaryTypes.String, arrow.ListOf(arrow.PrimitiveTypes.Int64))},
+		{Name: "t", Type: arrow.StructOf(
+			arrow.Field{Name: "1", Type: arrow.PrimitiveTypes.Int32},
+			arrow.Field{Name: "2", Type: arrow.BinaryTypes.String, Nullable: true},
+		)},
+	}, func(builder *arrowarray.RecordBuilder) {
+		list := builder.Field(0).(*arrowarray.ListBuilder)
+		list.Append(true)
+		list.ValueBuilder().(*arrowarray.Int32Builder).AppendValues([]int32{1, 0}, []bool{true, false})
+
+		m := builder.Field(1).(*arrowarray.MapBuilder)
+		m.Append(true)
+		m.KeyBuilder().(*arrowarray.StringBuilder).Append("k")
+		items := m.ItemBuilder().(*arrowarray.ListBuilder)
+		items.Append(true)
+		items.ValueBuilder().(*arrowarray.Int64Builder).AppendValues([]int64{7, 8}, nil)
+
+		tuple := builder.Field(2).(*arrowarray.StructBuilder)
+		tuple.Append(true)
+		tuple.FieldBuilder(0).(*arrowarray.Int32Builder).Append(3)
+		tuple.FieldBuilder(1).(*arrowarray.StringBuilder).AppendNull()
+	})
+
+	decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
+		headers: http.Header{contentType: []string{arrowStreamContentType}},
+		body:    payload,
+	})
+	require.NoError(t, err)
+	require.Len(t, decoded.typedRows, 1)
+	one := int32(1)
+	assert.Equal(t, []*int32{&one, nil}, decoded.typedRows[0][0])
+	assert.Equal(t, map[string][]int64{"k": {7, 8}}, decoded.typedRows[0][1])
+	assert.Equal(t, []any{int32(3), nil}, decoded.typedRows[0][2])
+
+	// the JSON transport produces the same values
+	for i, text := range []string{"[1,NULL]", "{'k':[7,8]}", "(3,NULL)"} {
+		colType, err := NewColumnType((*resp.Schema)[i].Type, nil)
+		require.NoError(t, err)
+		v, err := colType.Parse(text)
+		require.NoError(t, err)
+		assert.Equal(t, decoded.typedRows[0][i], v)
+	}
+}
+
 func TestQuerySyncUsesHTTPArrowAcrossPages(t *testing.T) {
 	type requestSnapshot struct {
 		SQL       string
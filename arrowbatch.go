@@ -0,0 +1,549 @@
+package godatabend
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/arrow/decimal256"
+)
+
+// arrowBatchSchema returns the Arrow schema rows of the columns are staged with.
+func arrowBatchSchema(columns []tableColumn) (*arrow.Schema, error) {
+	fields := make([]arrow.Field, len(columns))
+	for i, col := range columns {
+		typ, err := arrowBatchType(col.desc)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", col.name, err)
+		}
+		fields[i] = arrow.Field{Name: col.name, Type: typ, Nullable: col.desc.Nullable}
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
+
+// arrowBatchType returns the Arrow type values of a column of type desc are
+// staged as. Types without an Arrow equivalent, such as Variant or Bitmap, are
+// staged as strings in their text form and converted by Databend on load.
+func arrowBatchType(desc *TypeDesc) (arrow.DataType, error) {
+	switch desc.Name {
+	case "Boolean":
+		return arrow.FixedWidthTypes.Boolean, nil
+	case "Int8":
+		return arrow.PrimitiveTypes.Int8, nil
+	case "Int16":
+		return arrow.PrimitiveTypes.Int16, nil
+	case "Int32":
+		return arrow.PrimitiveTypes.Int32, nil
+	case "Int64":
+		return arrow.PrimitiveTypes.Int64, nil
+	case "UInt8":
+		return arrow.PrimitiveTypes.Uint8, nil
+	case "UInt16":
+		return arrow.PrimitiveTypes.Uint16, nil
+	case "UInt32":
+		return arrow.PrimitiveTypes.Uint32, nil
+	case "UInt64":
+		return arrow.PrimitiveTypes.Uint64, nil
+	case "Float32":
+		return arrow.PrimitiveTypes.Float32, nil
+	case "Float64":
+		return arrow.PrimitiveTypes.Float64, nil
+	case "Binary":
+		return arrow.BinaryTypes.Binary, nil
+	case "Date":
+		return arrow.FixedWidthTypes.Date32, nil
+	case "Timestamp":
+		return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}, nil
+	case "Decimal":
+		precision, scale, err := decimalPrecisionScale(desc)
+		if err != nil {
+			return nil, err
+		}
+		if precision > 38 {
+			return &arrow.Decimal256Type{Precision: int32(precision), Scale: int32(scale)}, nil
+		}
+		return &arrow.Decimal128Type{Precision: int32(precision), Scale: int32(scale)}, nil
+	case "Vector":
+		return arrow.ListOfNonNullable(arrow.PrimitiveTypes.Float32), nil
+	case "Array":
+		if len(desc.Args) != 1 {
+			return nil, fmt.Errorf("element type not specified for Array")
+		}
+		elem, err := arrowBatchType(desc.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		return arrow.ListOfField(arrow.Field{Name: "item", Type: elem, Nullable: desc.Args[0].Nullable}), nil
+	case "Map":
+		if len(desc.Args) != 2 {
+			return nil, fmt.Errorf("incorrect number of arguments for Map")
+		}
+		key, err := arrowBatchType(desc.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		value, err := arrowBatchType(desc.Args[1])
+		if err != nil {
+			return nil, err
+		}
+		return arrow.MapOf(key, value), nil
+	case "Tuple":
+		if len(desc.Args) == 0 {
+			return nil, fmt.Errorf("element types not specified for Tuple")
+		}
+		fields := make([]arrow.Field, len(desc.Args))
+		for i, arg := range desc.Args {
+			typ, err := arrowBatchType(arg)
+			if err != nil {
+				return nil, err
+			}
+			// Databend names the fields of unnamed tuples by position
+			name := arg.field
+			if name == "" {
+				name = strconv.Itoa(i + 1)
+			}
+			fields[i] = arrow.Field{Name: name, Type: typ, Nullable: arg.Nullable}
+		}
+		return arrow.StructOf(fields...), nil
+	default:
+		return arrow.BinaryTypes.String, nil
+	}
+}
+
+// batchMapEntry is a key and value of a Map value converted by convertBatchValue.
+type batchMapEntry struct {
+	key, value any
+}
+
+// convertBatchValue converts v to the Go value appendArrowValue appends for a
+// column of type desc:
+//
+//   - Boolean becomes bool
+//   - signed and unsigned integers become int64 and uint64
+//   - Float32 and Float64 become float64
+//   - Binary becomes []byte
+//   - Date and Timestamp become time.Time
+//   - Decimal becomes decimal128.Num or decimal256.Num
+//   - Array and Vector become []any, Tuple becomes []any and Map becomes []batchMapEntry
+//   - other types become their text form
+//
+// Strings are parsed the way Databend would parse them, so that batches built
+// from text values keep working.
+func convertBatchValue(desc *TypeDesc, v any, opts *ColumnTypeOptions) (any, error) {
+	v = derefBatchValue(v)
+	if valuer, ok := v.(driver.Valuer); ok {
+		// Array, Map and Tuple values are converted element by element
+		// instead of through their text form, and intervals are written
+		// in the text form rather than as the microseconds Value returns
+		_, nested := v.(nestedValue)
+		_, interval := v.(IntervalValue)
+		if !nested && !interval {
+			dv, err := callValuer(valuer)
+			if err != nil {
+				return nil, err
+			}
+			v = dv
+		}
+	}
+	if v == nil {
+		if !desc.Nullable {
+			return nil, fmt.Errorf("NULL is not allowed in %s", desc)
+		}
+		return nil, nil
+	}
+	return convertBatchValueOf(desc, v, opts)
+}
+
+// derefBatchValue follows pointers, returning nil for nil pointers. Pointers
+// to driver.Valuer implementations and *big.Int are kept as they are, and the
+// value of a sql.Null[T] is taken out of it.
+func derefBatchValue(v any) any {
+	if inner, ok := unwrapNull(v); ok {
+		return derefBatchValue(inner)
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		switch rv.Interface().(type) {
+		case driver.Valuer, *big.Int:
+			return rv.Interface()
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
+
+func convertBatchValueOf(desc *TypeDesc, v any, opts *ColumnTypeOptions) (any, error) {
+	if s, ok := v.(string); ok && isNestedTypeName(desc.Name) {
+		typ, err := newNestedType(desc, opts)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := typ.parseText(s)
+		if err != nil {
+			return nil, err
+		}
+		return convertBatchValue(desc, parsed, opts)
+	}
+	switch desc.Name {
+	case "Boolean":
+		return batchBool(v)
+	case "Int8", "Int16", "Int32", "Int64":
+		return batchInt(v, typeBits(desc.Name))
+	case "UInt8", "UInt16", "UInt32", "UInt64":
+		return batchUint(v, typeBits(desc.Name))
+	case "Float32", "Float64":
+		return batchFloat(v)
+	case "Binary":
+		switch v := v.(type) {
+		case []byte:
+			return v, nil
+		case string:
+			// strings are hex text, as Databend reads them from CSV
+			b, err := hex.DecodeString(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid hex value for %s: %q", desc, v)
+			}
+			return b, nil
+		}
+	case "Date":
+		t, err := batchTime(v, opts)
+		if err != nil {
+			return nil, err
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+	case "Timestamp":
+		return batchTime(v, opts)
+	case "Decimal":
+		return batchDecimal(desc, v)
+	case "Array", "Vector":
+		elem := &TypeDesc{Name: "Float32"}
+		if desc.Name == "Array" {
+			elem = desc.Args[0]
+		} else if s, ok := v.(string); ok {
+			dim, err := vectorDimension(desc)
+			if err != nil {
+				return nil, err
+			}
+			if v, err = parseVector(s, dim); err != nil {
+				return nil, err
+			}
+		}
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			break
+		}
+		out := make([]any, rv.Len())
+		for i := range out {
+			x, err := convertBatchValue(elem, rv.Index(i).Interface(), opts)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = x
+		}
+		return out, nil
+	case "Map":
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Map {
+			break
+		}
+		out := make([]batchMapEntry, 0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, err := convertBatchValue(desc.Args[0], iter.Key().Interface(), opts)
+			if err != nil {
+				return nil, err
+			}
+			value, err := convertBatchValue(desc.Args[1], iter.Value().Interface(), opts)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, batchMapEntry{key: key, value: value})
+		}
+		return out, nil
+	case "Tuple":
+		elems := tupleBatchElements(v)
+		if elems == nil {
+			break
+		}
+		if len(elems) != len(desc.Args) {
+			return nil, fmt.Errorf("expected %d tuple elements, got %d", len(desc.Args), len(elems))
+		}
+		out := make([]any, len(elems))
+		for i, elem := range elems {
+			x, err := convertBatchValue(desc.Args[i], elem, opts)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = x
+		}
+		return out, nil
+	default:
+		if b, ok := v.([]byte); ok {
+			// bytes are the text itself in text columns such as String or Variant
+			return string(b), nil
+		}
+		return batchValueText(v)
+	}
+	return nil, fmt.Errorf("cannot convert %T to %s", v, desc)
+}
+
+// tupleBatchElements returns the elements of a slice, or the fields of a struct
+// such as Tuple2, or nil if v is neither.
+func tupleBatchElements(v any) []any {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		elems := make([]any, rv.Len())
+		for i := range elems {
+			elems[i] = rv.Index(i).Interface()
+		}
+		return elems
+	case reflect.Struct:
+		var elems []any
+		for i := range rv.NumField() {
+			if rv.Type().Field(i).IsExported() {
+				elems = append(elems, rv.Field(i).Interface())
+			}
+		}
+		return elems
+	}
+	return nil
+}
+
+func typeBits(name string) int {
+	bits, _ := strconv.Atoi(strings.TrimLeft(name, "UInt"))
+	return bits
+}
+
+func batchBool(v any) (bool, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() != 0, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint() != 0, nil
+	case reflect.String:
+		return strconv.ParseBool(strings.TrimSpace(rv.String()))
+	}
+	return false, fmt.Errorf("cannot convert %T to Boolean", v)
+}
+
+func batchInt(v any, bits int) (int64, error) {
+	var x int64
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x = rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("value %d out of range for Int%d", rv.Uint(), bits)
+		}
+		x = int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("value %v cannot be stored in Int%d", f, bits)
+		}
+		x = int64(f)
+	case reflect.Bool:
+		if rv.Bool() {
+			x = 1
+		}
+	case reflect.String:
+		n, err := strconv.ParseInt(strings.TrimSpace(rv.String()), 10, bits)
+		if err != nil {
+			return 0, err
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("cannot convert %T to Int%d", v, bits)
+	}
+	if bits < 64 && (x < -(1<<(bits-1)) || x >= 1<<(bits-1)) {
+		return 0, fmt.Errorf("value %d out of range for Int%d", x, bits)
+	}
+	return x, nil
+}
+
+func batchUint(v any, bits int) (uint64, error) {
+	var x uint64
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rv.Int() < 0 {
+			return 0, fmt.Errorf("value %d out of range for UInt%d", rv.Int(), bits)
+		}
+		x = uint64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x = rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+			return 0, fmt.Errorf("value %v cannot be stored in UInt%d", f, bits)
+		}
+		x = uint64(f)
+	case reflect.Bool:
+		if rv.Bool() {
+			x = 1
+		}
+	case reflect.String:
+		return strconv.ParseUint(strings.TrimSpace(rv.String()), 10, bits)
+	default:
+		return 0, fmt.Errorf("cannot convert %T to UInt%d", v, bits)
+	}
+	if bits < 64 && x >= 1<<bits {
+		return 0, fmt.Errorf("value %d out of range for UInt%d", x, bits)
+	}
+	return x, nil
+}
+
+func batchFloat(v any) (float64, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		return strconv.ParseFloat(strings.TrimSpace(rv.String()), 64)
+	}
+	return 0, fmt.Errorf("cannot convert %T to a float", v)
+}
+
+// batchTimeLayouts are the layouts strings are parsed with for Date and
+// Timestamp columns, in the session timezone unless they have an offset.
+var batchTimeLayouts = []string{
+	timeFormat,
+	time.RFC3339Nano,
+	dateTime64Format,
+	"2006-01-02T15:04:05.999999999",
+	dateFormat,
+}
+
+func batchTime(v any, opts *ColumnTypeOptions) (time.Time, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+	case date:
+		return time.Time(v), nil
+	case string:
+		s := strings.TrimSpace(v)
+		for _, layout := range batchTimeLayouts {
+			if t, err := time.ParseInLocation(layout, s, opts.timezone); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("cannot parse %q as a timestamp", v)
+	}
+	return time.Time{}, fmt.Errorf("cannot convert %T to a timestamp", v)
+}
+
+func batchDecimal(desc *TypeDesc, v any) (any, error) {
+	precision, scale, err := decimalPrecisionScale(desc)
+	if err != nil {
+		return nil, err
+	}
+	var text string
+	rv := reflect.ValueOf(v)
+	switch x := v.(type) {
+	case *big.Int:
+		text = x.String()
+	case string:
+		text = strings.TrimSpace(x)
+	default:
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			text = strconv.FormatInt(rv.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			text = strconv.FormatUint(rv.Uint(), 10)
+		case reflect.Float32, reflect.Float64:
+			text = strconv.FormatFloat(rv.Float(), 'f', -1, 64)
+		default:
+			return nil, fmt.Errorf("cannot convert %T to %s", v, desc)
+		}
+	}
+	if precision > 38 {
+		return decimal256.FromString(text, int32(precision), int32(scale))
+	}
+	return decimal128.FromString(text, int32(precision), int32(scale))
+}
+
+// appendArrowValue appends a value converted by convertBatchValue to b.
+func appendArrowValue(b array.Builder, v any) {
+	if v == nil {
+		b.AppendNull()
+		return
+	}
+	switch b := b.(type) {
+	case *array.BooleanBuilder:
+		b.Append(v.(bool))
+	case *array.Int8Builder:
+		b.Append(int8(v.(int64)))
+	case *array.Int16Builder:
+		b.Append(int16(v.(int64)))
+	case *array.Int32Builder:
+		b.Append(int32(v.(int64)))
+	case *array.Int64Builder:
+		b.Append(v.(int64))
+	case *array.Uint8Builder:
+		b.Append(uint8(v.(uint64)))
+	case *array.Uint16Builder:
+		b.Append(uint16(v.(uint64)))
+	case *array.Uint32Builder:
+		b.Append(uint32(v.(uint64)))
+	case *array.Uint64Builder:
+		b.Append(v.(uint64))
+	case *array.Float32Builder:
+		b.Append(float32(v.(float64)))
+	case *array.Float64Builder:
+		b.Append(v.(float64))
+	case *array.StringBuilder:
+		b.Append(v.(string))
+	case *array.BinaryBuilder:
+		b.Append(v.([]byte))
+	case *array.Date32Builder:
+		b.Append(arrow.Date32FromTime(v.(time.Time)))
+	case *array.TimestampBuilder:
+		b.Append(arrow.Timestamp(v.(time.Time).UnixMicro()))
+	case *array.Decimal128Builder:
+		b.Append(v.(decimal128.Num))
+	case *array.Decimal256Builder:
+		b.Append(v.(decimal256.Num))
+	case *array.ListBuilder:
+		b.Append(true)
+		for _, elem := range v.([]any) {
+			appendArrowValue(b.ValueBuilder(), elem)
+		}
+	case *array.MapBuilder:
+		b.Append(true)
+		for _, entry := range v.([]batchMapEntry) {
+			appendArrowValue(b.KeyBuilder(), entry.key)
+			appendArrowValue(b.ItemBuilder(), entry.value)
+		}
+	case *array.StructBuilder:
+		b.Append(true)
+		for i, elem := range v.([]any) {
+			appendArrowValue(b.FieldBuilder(i), elem)
+		}
+	default:
+		panic(fmt.Sprintf("unexpected arrow builder %T", b))
+	}
+}
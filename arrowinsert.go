@@ -0,0 +1,266 @@
+package godatabend
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+// InsertArrow loads the records of reader into table, which is written as in
+// SQL, e.g. `db.t`. The fields of the records are matched to the table columns
+// by name; columns without a field get their default values.
+func InsertArrow(ctx context.Context, conn *sql.Conn, table string, reader array.RecordReader) (result driver.Result, err error) {
+	err = conn.Raw(func(rawConn interface{}) error {
+		result, err = rawConn.(*DatabendConn).InsertArrow(ctx, table, reader)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// InsertArrow loads the records of reader into table. See the package-level InsertArrow.
+func (dc *DatabendConn) InsertArrow(ctx context.Context, table string, reader array.RecordReader) (driver.Result, error) {
+	names := make([]string, reader.Schema().NumFields())
+	for i, field := range reader.Schema().Fields() {
+		names[i] = field.Name
+	}
+	database, name, err := splitTableName(table)
+	if err != nil {
+		return nil, err
+	}
+	query, err := InsertBuilder{Database: database, Table: name, Columns: names}.Build()
+	if err != nil {
+		return nil, err
+	}
+	b, err := dc.prepareBatch(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	var rows int64
+	for reader.Next() {
+		record := reader.Record()
+		if err := b.AppendRecord(record); err != nil {
+			_ = b.buf.Reset()
+			return nil, err
+		}
+		rows += record.NumRows()
+	}
+	if err := reader.Err(); err != nil && err != io.EOF {
+		_ = b.buf.Reset()
+		return nil, err
+	}
+	if rows == 0 {
+		_ = b.buf.Reset()
+		return newDatabendResult(0, 0), nil
+	}
+	result, err := b.BatchInsertWithResult()
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// arrowRecordEncoder writes Arrow records to Parquet as they are, leaving
+// conversions to the column types to Databend.
+type arrowRecordEncoder struct {
+	columns []tableColumn
+	schema  *arrow.Schema
+	writer  *pqarrow.FileWriter
+	w       io.Writer
+}
+
+func newArrowRecordEncoder(w io.Writer, columns []tableColumn) *arrowRecordEncoder {
+	return &arrowRecordEncoder{columns: columns, w: w}
+}
+
+func (e *arrowRecordEncoder) appendRow([]driver.Value) error {
+	return fmt.Errorf("cannot append rows to a batch of Arrow records")
+}
+
+func (e *arrowRecordEncoder) appendRecord(record arrow.Record) error {
+	if e.schema == nil {
+		if err := checkArrowSchema(record.Schema(), e.columns); err != nil {
+			return err
+		}
+		props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Zstd))
+		writer, err := pqarrow.NewFileWriter(record.Schema(), e.w, props, pqarrow.DefaultWriterProps())
+		if err != nil {
+			return fmt.Errorf("create parquet writer failed: %w", err)
+		}
+		e.schema, e.writer = record.Schema(), writer
+	} else if !record.Schema().Equal(e.schema) {
+		return fmt.Errorf("record schema %s differs from the first record of the batch", record.Schema())
+	}
+	for i, col := range e.columns {
+		if !col.desc.Nullable && record.Column(i).NullN() > 0 {
+			return fmt.Errorf("column %s: NULL is not allowed in %s", col.name, col.desc)
+		}
+	}
+	return e.writer.Write(record)
+}
+
+func (e *arrowRecordEncoder) flush() error {
+	if e.writer == nil {
+		return nil
+	}
+	return e.writer.Close()
+}
+
+func (e *arrowRecordEncoder) fileFormat() FileFormatOptions {
+	return ParquetFormat{}
+}
+
+func (e *arrowRecordEncoder) extension() string {
+	return "parquet"
+}
+
+// checkArrowSchema reports an error unless the fields of schema match the
+// columns by name and position, with types that can be loaded into them.
+func checkArrowSchema(schema *arrow.Schema, columns []tableColumn) error {
+	if schema.NumFields() != len(columns) {
+		return fmt.Errorf("record has %d fields, expected %d columns", schema.NumFields(), len(columns))
+	}
+	for i, field := range schema.Fields() {
+		col := columns[i]
+		if !strings.EqualFold(field.Name, col.name) {
+			return fmt.Errorf("record field %d is named %s, expected column %s", i, field.Name, col.name)
+		}
+		desc, err := databendTypeOfArrow(field.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if !arrowLoadable(desc, col.desc) {
+			return fmt.Errorf("field %s of type %s cannot be loaded into column %s of type %s", field.Name, field.Type, col.name, col.desc)
+		}
+	}
+	return nil
+}
+
+// databendTypeOfArrow returns the Databend type Arrow values of type dt are
+// loaded as.
+func databendTypeOfArrow(dt arrow.DataType) (*TypeDesc, error) {
+	switch dt := dt.(type) {
+	case *arrow.BooleanType:
+		return &TypeDesc{Name: "Boolean"}, nil
+	case *arrow.Int8Type:
+		return &TypeDesc{Name: "Int8"}, nil
+	case *arrow.Int16Type:
+		return &TypeDesc{Name: "Int16"}, nil
+	case *arrow.Int32Type:
+		return &TypeDesc{Name: "Int32"}, nil
+	case *arrow.Int64Type:
+		return &TypeDesc{Name: "Int64"}, nil
+	case *arrow.Uint8Type:
+		return &TypeDesc{Name: "UInt8"}, nil
+	case *arrow.Uint16Type:
+		return &TypeDesc{Name: "UInt16"}, nil
+	case *arrow.Uint32Type:
+		return &TypeDesc{Name: "UInt32"}, nil
+	case *arrow.Uint64Type:
+		return &TypeDesc{Name: "UInt64"}, nil
+	case *arrow.Float16Type, *arrow.Float32Type:
+		return &TypeDesc{Name: "Float32"}, nil
+	case *arrow.Float64Type:
+		return &TypeDesc{Name: "Float64"}, nil
+	case *arrow.StringType, *arrow.LargeStringType, *arrow.StringViewType:
+		return &TypeDesc{Name: "String"}, nil
+	case *arrow.BinaryType, *arrow.LargeBinaryType, *arrow.BinaryViewType, *arrow.FixedSizeBinaryType:
+		return &TypeDesc{Name: "Binary"}, nil
+	case *arrow.Date32Type, *arrow.Date64Type:
+		return &TypeDesc{Name: "Date"}, nil
+	case *arrow.TimestampType:
+		return &TypeDesc{Name: "Timestamp"}, nil
+	case arrow.DecimalType:
+		return &TypeDesc{Name: "Decimal", Args: []*TypeDesc{
+			{Name: fmt.Sprint(dt.GetPrecision())},
+			{Name: fmt.Sprint(dt.GetScale())},
+		}}, nil
+	case *arrow.MapType:
+		key, err := databendTypeOfArrow(dt.KeyType())
+		if err != nil {
+			return nil, err
+		}
+		value, err := databendTypeOfArrow(dt.ItemField().Type)
+		if err != nil {
+			return nil, err
+		}
+		value.Nullable = dt.ItemField().Nullable
+		return &TypeDesc{Name: "Map", Args: []*TypeDesc{key, value}}, nil
+	case arrow.ListLikeType:
+		elem, err := databendTypeOfArrow(dt.Elem())
+		if err != nil {
+			return nil, err
+		}
+		elem.Nullable = dt.ElemField().Nullable
+		return &TypeDesc{Name: "Array", Args: []*TypeDesc{elem}}, nil
+	case *arrow.StructType:
+		desc := &TypeDesc{Name: "Tuple"}
+		for _, field := range dt.Fields() {
+			elem, err := databendTypeOfArrow(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			elem.Nullable = field.Nullable
+			desc.Args = append(desc.Args, elem)
+		}
+		return desc, nil
+	}
+	return nil, fmt.Errorf("unsupported arrow type %s", dt)
+}
+
+// arrowLoadable reports whether values of type src can be loaded into a column
+// of type dst, with Databend casting them if needed.
+func arrowLoadable(src, dst *TypeDesc) bool {
+	if src.Name == "String" {
+		// parsed from text, as in CSV
+		return true
+	}
+	switch dst.Name {
+	case "String", "Variant":
+		return !isNestedTypeName(src.Name)
+	case "Boolean", "Binary", "Date":
+		return src.Name == dst.Name
+	case "Timestamp":
+		return src.Name == "Timestamp" || src.Name == "Date"
+	case "Vector":
+		return src.Name == "Array" && isNumericTypeName(src.Args[0].Name)
+	case "Array":
+		return src.Name == "Array" && arrowLoadable(src.Args[0], dst.Args[0])
+	case "Map":
+		return src.Name == "Map" && arrowLoadable(src.Args[0], dst.Args[0]) && arrowLoadable(src.Args[1], dst.Args[1])
+	case "Tuple":
+		if src.Name != "Tuple" || len(src.Args) != len(dst.Args) {
+			return false
+		}
+		for i := range src.Args {
+			if !arrowLoadable(src.Args[i], dst.Args[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if isNumericTypeName(dst.Name) {
+		return isNumericTypeName(src.Name)
+	}
+	return src.Name == dst.Name
+}
+
+func isNumericTypeName(name string) bool {
+	switch name {
+	case "Int8", "Int16", "Int32", "Int64", "UInt8", "UInt16", "UInt32", "UInt64",
+		"Int128", "Int256", "UInt128", "UInt256", "Float32", "Float64", "Decimal":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package godatabend
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabendTypeOfArrow(t *testing.T) {
+	testCases := []struct {
+		typ      arrow.DataType
+		expected string
+	}{
+		{arrow.FixedWidthTypes.Boolean, "Boolean"},
+		{arrow.PrimitiveTypes.Uint16, "UInt16"},
+		{arrow.BinaryTypes.LargeString, "String"},
+		{&arrow.FixedSizeBinaryType{ByteWidth: 16}, "Binary"},
+		{arrow.FixedWidthTypes.Timestamp_ms, "Timestamp"},
+		{&arrow.Decimal128Type{Precision: 10, Scale: 2}, "Decimal(10, 2)"},
+		{arrow.ListOf(arrow.PrimitiveTypes.Int32), "Array(Int32 NULL)"},
+		{arrow.FixedSizeListOfNonNullable(3, arrow.PrimitiveTypes.Float32), "Array(Float32)"},
+		{arrow.MapOf(arrow.BinaryTypes.String, arrow.PrimitiveTypes.Int64), "Map(String, Int64 NULL)"},
+		{arrow.StructOf(arrow.Field{Name: "a", Type: arrow.PrimitiveTypes.Int8}), "Tuple(Int8)"},
+	}
+	for _, tc := range testCases {
+		desc, err := databendTypeOfArrow(tc.typ)
+		require.NoError(t, err, tc.typ.String())
+		assert.Equal(t, tc.expected, desc.String(), tc.typ.String())
+	}
+
+	_, err := databendTypeOfArrow(arrow.FixedWidthTypes.MonthInterval)
+	assert.Error(t, err)
+}
+
+func TestCheckArrowSchema(t *testing.T) {
+	columns := []tableColumn{
+		{name: "id", desc: &TypeDesc{Name: "Int64"}},
+		{name: "tags", desc: mustParseSQLTypeDesc(t, "ARRAY(STRING)")},
+		{name: "v", desc: &TypeDesc{Name: "Variant"}},
+	}
+	field := func(name string, typ arrow.DataType) arrow.Field {
+		return arrow.Field{Name: name, Type: typ, Nullable: true}
+	}
+
+	schema := arrow.NewSchema([]arrow.Field{
+		field("ID", arrow.PrimitiveTypes.Int32),
+		field("tags", arrow.ListOf(arrow.BinaryTypes.String)),
+		field("v", arrow.BinaryTypes.String),
+	}, nil)
+	assert.NoError(t, checkArrowSchema(schema, columns))
+
+	schema = arrow.NewSchema([]arrow.Field{field("id", arrow.PrimitiveTypes.Int32)}, nil)
+	assert.EqualError(t, checkArrowSchema(schema, columns), "record has 1 fields, expected 3 columns")
+
+	schema = arrow.NewSchema([]arrow.Field{
+		field("id", arrow.PrimitiveTypes.Int32),
+		field("v", arrow.BinaryTypes.String),
+		field("tags", arrow.ListOf(arrow.BinaryTypes.String)),
+	}, nil)
+	assert.EqualError(t, checkArrowSchema(schema, columns), "record field 1 is named v, expected column tags")
+
+	schema = arrow.NewSchema([]arrow.Field{
+		field("id", arrow.FixedWidthTypes.Boolean),
+		field("tags", arrow.ListOf(arrow.BinaryTypes.String)),
+		field("v", arrow.BinaryTypes.String),
+	}, nil)
+	assert.EqualError(t, checkArrowSchema(schema, columns), "field id of type bool cannot be loaded into column id of type Int64")
+}
+
+func buildInt64Record(t *testing.T, name string, values []int64, valid []bool) arrow.Record {
+	schema := arrow.NewSchema([]arrow.Field{{Name: name, Type: arrow.PrimitiveTypes.Int64, Nullable: true}}, nil)
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+	b.Field(0).(*array.Int64Builder).AppendValues(values, valid)
+	record := b.NewRecord()
+	t.Cleanup(record.Release)
+	return record
+}
+
+func TestHTTPBatchAppendRecord(t *testing.T) {
+	b, err := newHTTPBatch(context.Background(), nil, "INSERT INTO t VALUES", batchOptions{})
+	require.NoError(t, err)
+	defer b.buf.Reset()
+	b.columns = []tableColumn{{name: "id", desc: &TypeDesc{Name: "Int64"}}}
+
+	require.NoError(t, b.AppendRecord(buildInt64Record(t, "id", []int64{1, 2}, nil)))
+	require.NoError(t, b.AppendRecord(buildInt64Record(t, "id", []int64{3}, nil)))
+	assert.EqualError(t, b.AppendRecord(buildInt64Record(t, "id", []int64{0}, []bool{false})), "column id: NULL is not allowed in Int64")
+	assert.Error(t, b.AppendRecord(buildInt64Record(t, "other", []int64{4}, nil)))
+	assert.Error(t, b.AppendToFile([]driver.Value{int64(5)}))
+
+	require.NoError(t, b.enc.flush())
+	assert.Equal(t, "parquet", b.enc.extension())
+	table := readParquetBatch(t, b.buf)
+	assert.Equal(t, int64(3), table.NumRows())
+}
+
+func TestHTTPBatchAppendRecordAfterRows(t *testing.T) {
+	b, err := newHTTPBatch(context.Background(), nil, "INSERT INTO t VALUES", batchOptions{})
+	require.NoError(t, err)
+	defer b.buf.Reset()
+	b.columns = []tableColumn{{name: "id", desc: &TypeDesc{Name: "Int64"}}}
+
+	require.NoError(t, b.AppendToFile([]driver.Value{int64(1)}))
+	assert.EqualError(t, b.AppendRecord(buildInt64Record(t, "id", []int64{2}, nil)), "cannot append records to a batch of rows")
+}
@@ -1,24 +1,22 @@
 package godatabend
 
 import (
-	"bufio"
 	"context"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/csv"
 	"fmt"
-	"os"
-	"path/filepath"
-	"regexp"
+	"io"
+	"net/netip"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 )
 
-// \x60 represents a backtick
-var httpInsertRe = regexp.MustCompile(`(?i)^\s*(?:INSERT|REPLACE) INTO\s+\x60?([\w.^\(]+)\x60?(?:\s*\([^\)]*\))?(?:\s+ON\s*\([^\)]*\))?(?:\s*\([^\)]*\))?\s+VALUES`)
-
 type BatchStmt struct {
 	query string
 }
@@ -50,109 +48,351 @@ type Batch interface {
 	BatchInsert() error
 }
 
-func (dc *DatabendConn) prepareBatch(ctx context.Context, query string) (Batch, error) {
-	matches := httpInsertRe.FindStringSubmatch(query)
-	if len(matches) < 2 {
+// ResultBatch is implemented by the batches of PrepareBatch, e.g.
+// batch.(godatabend.ResultBatch).BatchInsertWithResult().
+type ResultBatch interface {
+	Batch
+	// BatchInsertWithResult is BatchInsert, also returning the rows loaded and,
+	// with Config.BatchOnError, the rows rejected.
+	BatchInsertWithResult() (*BatchResult, error)
+}
+
+// RecordBatch is implemented by the batches of PrepareBatch.
+type RecordBatch interface {
+	Batch
+	// AppendRecord appends the rows of an Arrow record, whose fields must match
+	// the columns of the statement by name and order. A batch holds either
+	// rows appended by AppendToFile or records, not both.
+	AppendRecord(record arrow.Record) error
+}
+
+var (
+	_ ResultBatch = (*httpBatch)(nil)
+	_ RecordBatch = (*httpBatch)(nil)
+)
+
+// PrepareBatch starts a batch insert with an `INSERT INTO t [(cols)] VALUES`
+// or `REPLACE INTO t ... VALUES` statement, e.g. from conn.Raw.
+func (dc *DatabendConn) PrepareBatch(ctx context.Context, query string) (Batch, error) {
+	return dc.prepareBatch(ctx, query)
+}
+
+func (dc *DatabendConn) prepareBatch(ctx context.Context, query string) (*httpBatch, error) {
+	if _, ok := parseInsertTable(query); !ok {
 		return nil, errors.New("PrepareBatch only support INSERT/REPLACE")
 	}
-	csvFileName := fmt.Sprintf("%s/%s.csv", os.TempDir(), uuid.NewString())
+	b, err := newHTTPBatch(ctx, dc, query, dc.cfg.batchOptions())
+	if err != nil {
+		return nil, err
+	}
+	if onError := b.opts.copyOnError(); onError != "" {
+		if err := checkCopyTarget(query); err != nil {
+			return nil, err
+		}
+		if _, err := (CopyOptions{OnError: onError}).Options(); err != nil {
+			return nil, err
+		}
+	}
+	// values are checked against the table schema, fetched once per batch
+	columns, err := b.tableColumns()
+	if err != nil {
+		var typeErr *columnTypeError
+		if !errors.As(err, &typeErr) {
+			return nil, err
+		}
+		// a type we cannot parse leaves the rows to the untyped CSV encoder of
+		// newHTTPBatch, and their values to be checked by Databend
+		return b, nil
+	}
+	opts := dc.columnTypeOptions(nil, nil)
+	newEncoder := func(w io.Writer) (batchEncoder, error) {
+		return &csvBatchEncoder{writer: csv.NewWriter(w), columns: columns, opts: opts}, nil
+	}
+	if dc.cfg != nil && dc.cfg.BatchFormat == BatchFormatParquet {
+		newEncoder = func(w io.Writer) (batchEncoder, error) {
+			return newParquetBatchEncoder(w, columns, opts)
+		}
+	}
+	if err := b.setEncoder(newEncoder); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
 
-	csvFile, err := os.OpenFile(csvFileName, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0666)
+// httpBatch encodes appended rows into chunks, each a batchBuffer that is
+// uploaded to the user stage once it reaches a threshold of batchOptions, and
+// loads them in BatchInsert, or as they are uploaded with loadPerChunk.
+type httpBatch struct {
+	query string
+	ctx   context.Context
+	conn  *DatabendConn
+	stage batchStage
+	opts  batchOptions
+	// rows appended to the batch
+	rows int64
+	// columns written by the statement, fetched when first needed
+	columns []tableColumn
+
+	// the chunk being appended to
+	buf        *batchBuffer
+	enc        batchEncoder
+	newEncoder func(w io.Writer) (batchEncoder, error)
+	chunkRows  int64
+	chunkStart time.Time
+
+	// stageDir is the stage directory chunks are uploaded to
+	stageDir string
+	chunks   []*batchChunk
+	uploads  chan struct{}
+	wg       sync.WaitGroup
+}
+
+func newHTTPBatch(ctx context.Context, conn *DatabendConn, query string, opts batchOptions) (*httpBatch, error) {
+	b := &httpBatch{
+		query:    query,
+		ctx:      ctx,
+		conn:     conn,
+		opts:     opts,
+		stageDir: fmt.Sprintf("batch/%d-%s", time.Now().Unix(), uuid.NewString()),
+		uploads:  make(chan struct{}, opts.uploadConcurrency()),
+		newEncoder: func(w io.Writer) (batchEncoder, error) {
+			return &csvBatchEncoder{writer: csv.NewWriter(w)}, nil
+		},
+	}
+	if conn != nil {
+		b.stage = conn.rest
+	}
+	b.buf = newBatchBuffer(opts.spillThreshold)
+	enc, err := b.newEncoder(b.buf)
 	if err != nil {
 		return nil, err
 	}
-	defer csvFile.Close()
-	writer := csv.NewWriter(csvFile)
-	writer.Flush()
+	b.enc = enc
+	b.chunkStart = time.Now()
+	return b, nil
+}
 
-	return &httpBatch{
-		query:     query,
-		ctx:       ctx,
-		conn:      dc,
-		batchFile: csvFileName,
-	}, nil
+// setEncoder replaces the encoder of the current chunk, which must be empty,
+// and of the chunks after it.
+func (b *httpBatch) setEncoder(newEncoder func(w io.Writer) (batchEncoder, error)) error {
+	// drop what the previous encoder may have written, such as a Parquet header
+	if err := b.buf.Reset(); err != nil {
+		return err
+	}
+	enc, err := newEncoder(b.buf)
+	if err != nil {
+		return err
+	}
+	b.enc, b.newEncoder = enc, newEncoder
+	return nil
 }
 
-type httpBatch struct {
-	query     string
-	ctx       context.Context
-	conn      *DatabendConn
-	batchFile string
+func (b *httpBatch) tableColumns() ([]tableColumn, error) {
+	if b.columns != nil {
+		return b.columns, nil
+	}
+	columns, err := b.conn.describeInsertTable(b.ctx, b.query)
+	if err != nil {
+		return nil, err
+	}
+	if b.columns, err = insertColumns(b.query, columns); err != nil {
+		return nil, err
+	}
+	return b.columns, nil
 }
 
 func (b *httpBatch) BatchInsert() error {
-	defer func() {
-		err := os.RemoveAll(b.batchFile)
-		if err != nil {
-			b.conn.log("delete batch insert file failed: ", err)
-		}
-	}()
+	_, err := b.BatchInsertWithResult()
+	return err
+}
 
-	stage, err := b.UploadToStage(context.Background())
+func (b *httpBatch) BatchInsertWithResult() (*BatchResult, error) {
+	defer b.discard()
+	if err := b.sealChunk(); err != nil {
+		return nil, err
+	}
+	b.wg.Wait()
+
+	result, err := b.load()
 	if err != nil {
-		return errors.Wrap(err, "upload to stage failed")
+		return result, err
+	}
+	if b.opts.deadLetterFile != "" && len(result.Rejected) > 0 {
+		if err := b.writeDeadLetters(result.Rejected); err != nil {
+			return result, err
+		}
 	}
+	return result, b.opts.checkMaxErrors(result)
+}
 
-	_, err = b.conn.rest.InsertWithStage(b.ctx, b.query, stage, nil, nil)
+// load loads the uploaded chunks, unless they were loaded with loadPerChunk.
+func (b *httpBatch) load() (*BatchResult, error) {
+	failed := b.failedChunks()
+	if b.opts.loadPerChunk {
+		result := b.chunkResult()
+		if len(failed) > 0 {
+			return result, &BatchChunkError{LoadedRows: result.LoadedRows, Chunks: failed}
+		}
+		return result, nil
+	}
+	if len(failed) > 0 {
+		b.removeStageDir()
+		return &BatchResult{}, &BatchChunkError{Chunks: failed}
+	}
+	stage := b.chunks[0].stage
+	if len(b.chunks) > 1 {
+		stage = &StageLocation{Name: "~", Path: b.stageDir + "/"}
+	}
+	if b.opts.copyOnError() != "" {
+		loads, err := b.copyFiles(b.ctx, stage, b.chunks[0].formatOptions)
+		if err != nil {
+			return &BatchResult{}, err
+		}
+		result := &BatchResult{}
+		b.addLoads(result, loads)
+		return result, nil
+	}
+	_, err := b.stage.InsertWithStage(b.ctx, b.query, stage, b.chunks[0].formatOptions, nil)
 	if err != nil {
-		return errors.Wrap(err, "insert with stage failed")
+		return &BatchResult{}, errors.Wrap(err, "insert with stage failed")
+	}
+	return &BatchResult{LoadedRows: b.rows}, nil
+}
+
+// discard waits for the uploads in flight and removes the buffers of the batch.
+func (b *httpBatch) discard() {
+	b.wg.Wait()
+	if err := b.buf.Reset(); err != nil {
+		b.conn.log("delete batch insert file failed: ", err)
+	}
+	for _, c := range b.chunks {
+		if err := c.buf.Reset(); err != nil {
+			b.conn.log("delete batch insert file failed: ", err)
+		}
 	}
-	return nil
 }
 
 func (b *httpBatch) AppendToFile(row []driver.Value) error {
-	csvFile, err := os.OpenFile(b.batchFile, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0666)
-	if err != nil {
+	if err := b.enc.appendRow(row); err != nil {
+		var valueErr *BatchValueError
+		if errors.As(err, &valueErr) {
+			valueErr.Row = b.rows
+		}
+		return err
+	}
+	b.rows++
+	b.chunkRows++
+	return b.maybeCutChunk()
+}
+
+func (b *httpBatch) AppendRecord(record arrow.Record) error {
+	enc, ok := b.enc.(*arrowRecordEncoder)
+	if !ok {
+		if b.rows > 0 {
+			return errors.New("cannot append records to a batch of rows")
+		}
+		columns, err := b.tableColumns()
+		if err != nil {
+			return err
+		}
+		if err := b.setEncoder(func(w io.Writer) (batchEncoder, error) {
+			return newArrowRecordEncoder(w, columns), nil
+		}); err != nil {
+			return err
+		}
+		enc = b.enc.(*arrowRecordEncoder)
+	}
+	if err := enc.appendRecord(record); err != nil {
 		return err
 	}
-	defer csvFile.Close()
+	b.rows += record.NumRows()
+	b.chunkRows += record.NumRows()
+	return b.maybeCutChunk()
+}
 
-	lineData := make([]string, 0, len(row))
-	for _, v := range row {
-		var s string
-		switch v := v.(type) {
+// batchValueText formats a value for the CSV file staged by a batch, and for
+// the string columns of other formats.
+func batchValueText(v driver.Value) (string, error) {
+	if inner, ok := unwrapNull(v); ok {
+		return batchValueText(inner)
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return binaryText(v), nil
+	case time.Time:
+		return v.Format(timeFormat), nil
+	case date:
+		return time.Time(v).Format(dateFormat), nil
+	case Variant:
+		return string(v), nil
+	case uuid.UUID:
+		return v.String(), nil
+	case netip.Addr:
+		return v.String(), nil
+	case IntervalValue:
+		return v.String(), nil
+	case time.Duration:
+		return NewIntervalFromDuration(v).String(), nil
+	case bigUint64:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case decimal:
+		return fmt.Sprint(v.v), nil
+	case nestedValue:
+		bytes, err := textEncode.Encode(v)
+		if err != nil {
+			return "", err
+		}
+		return string(bytes), nil
+	case driver.Valuer:
+		// values such as Bitmap, Interval or geometries are written in
+		// their text form rather than as SQL literals
+		dv, err := callValuer(v)
+		if err != nil {
+			return "", err
+		}
+		switch dv := dv.(type) {
 		case string:
-			s = v
-		case time.Time:
-			s = v.Format(timeFormat)
-		case date:
-			s = time.Time(v).Format(dateFormat)
+			return dv, nil
+		case []byte:
+			return binaryText(dv), nil
 		default:
-			bytes, err := textEncode.Encode(v)
+			bytes, err := textEncode.Encode(dv)
 			if err != nil {
-				return err
+				return "", err
 			}
-			s = string(bytes)
+			return string(bytes), nil
 		}
-		lineData = append(lineData, s)
-	}
-	writer := csv.NewWriter(csvFile)
-	err = writer.Write(lineData)
-	if err != nil {
-		return err
+	default:
+		bytes, err := textEncode.Encode(v)
+		if err != nil {
+			return "", err
+		}
+		return string(bytes), nil
 	}
-	writer.Flush()
-
-	return nil
 }
 
-func (b *httpBatch) UploadToStage(ctx context.Context) (*StageLocation, error) {
-	ctx = checkQueryID(ctx)
-	fi, err := os.Stat(b.batchFile)
-	if err != nil {
-		return nil, errors.Wrap(err, "get batch file size failed")
+// parseInsertTable returns the target table of an `INSERT INTO t [(cols)] VALUES`
+// or `REPLACE INTO t [(cols)] ON (keys) VALUES` statement, as written in the query.
+func parseInsertTable(query string) (string, bool) {
+	stmt := parseSQLStatement(query)
+	table, i, ok := stmt.insertTarget()
+	if !ok {
+		return "", false
 	}
-	size := fi.Size()
-
-	f, err := os.Open(b.batchFile)
-	if err != nil {
-		return nil, errors.Wrap(err, "open batch file failed")
+	if stmt.op(i, "(") {
+		i = stmt.skipParens(i)
+	}
+	if stmt.word(i, "ON") {
+		i++
+		if !stmt.op(i, "(") {
+			return "", false
+		}
+		i = stmt.skipParens(i)
 	}
-	defer f.Close()
-	input := bufio.NewReader(f)
-	stage := &StageLocation{
-		Name: "~",
-		Path: fmt.Sprintf("batch/%d-%s", time.Now().Unix(), filepath.Base(b.batchFile)),
+	if !stmt.word(i, "VALUES") {
+		return "", false
 	}
-	return stage, b.conn.rest.UploadToStage(ctx, stage, input, size)
+	return table, true
 }
@@ -0,0 +1,122 @@
+package godatabend
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInsertTable(t *testing.T) {
+	testCases := []struct {
+		query string
+		table string
+		ok    bool
+	}{
+		{"INSERT INTO t VALUES", "t", true},
+		{"insert into t values", "t", true},
+		{"  INSERT\n INTO db.t VALUES (?, ?)", "db.t", true},
+		{"INSERT INTO `db`.`t` (a, b) VALUES", "`db`.`t`", true},
+		{`INSERT INTO "T" ("a b") VALUES`, `"T"`, true},
+		{"REPLACE INTO t ON (id) VALUES", "t", true},
+		{"REPLACE INTO t (id, v) ON (id) VALUES", "t", true},
+		{"/* batch */ INSERT INTO t VALUES", "t", true},
+		{"INSERT OVERWRITE INTO t VALUES", "t", true},
+		{"INSERT INTO t SELECT * FROM s", "", false},
+		{"SELECT 'INSERT INTO t VALUES'", "", false},
+		{"INSERT t VALUES", "", false},
+		{"-- INSERT INTO t VALUES", "", false},
+	}
+
+	for _, tc := range testCases {
+		table, ok := parseInsertTable(tc.query)
+		assert.Equal(t, tc.ok, ok, tc.query)
+		assert.Equal(t, tc.table, table, tc.query)
+	}
+}
+
+func TestAppendToFileTextValues(t *testing.T) {
+	b, err := newHTTPBatch(context.Background(), nil, "INSERT INTO t VALUES", batchOptions{})
+	require.NoError(t, err)
+	defer b.buf.Reset()
+	require.NoError(t, b.AppendToFile([]driver.Value{
+		[]float32{0.5, -1, 1e-30},
+		NewBitmap(1, 2),
+		IntervalValue{Days: 1},
+		(*Bitmap)(nil),
+		[]byte{0xab, 1},
+		Array[int]{1, 2},
+		UInt64(1 << 63),
+		90 * time.Minute,
+	}))
+
+	require.NoError(t, b.enc.flush())
+	r, err := b.buf.Reader()
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "\"[0.5,-1,1e-30]\",\"1,2\",1 day,NULL,AB01,\"[1,2]\",9223372036854775808,01:30:00\n", string(data))
+}
+
+func TestAppendToFileReportsRow(t *testing.T) {
+	b, err := newHTTPBatch(context.Background(), nil, "INSERT INTO t VALUES", batchOptions{})
+	require.NoError(t, err)
+	defer b.buf.Reset()
+	columns := []tableColumn{{name: "id", desc: &TypeDesc{Name: "UInt8"}}}
+	require.NoError(t, b.setEncoder(func(w io.Writer) (batchEncoder, error) {
+		return &csvBatchEncoder{writer: csv.NewWriter(w), columns: columns, opts: defaultColumnTypeOptions()}, nil
+	}))
+
+	require.NoError(t, b.AppendToFile([]driver.Value{int64(1)}))
+	require.NoError(t, b.AppendToFile([]driver.Value{"2"}))
+	err = b.AppendToFile([]driver.Value{int64(-1)})
+	var valueErr *BatchValueError
+	require.ErrorAs(t, err, &valueErr)
+	assert.Equal(t, int64(2), valueErr.Row)
+	assert.Equal(t, "id", valueErr.Column)
+	assert.Equal(t, int64(2), b.rows)
+}
+
+func TestPrepareBatchUnknownColumnType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/query" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set(contentType, jsonMediaType)
+		require.NoError(t, json.NewEncoder(w).Encode(QueryResponse{
+			ID:     "q",
+			State:  "Succeeded",
+			Schema: &[]DataField{{Name: "Field", Type: "String"}, {Name: "Type", Type: "String"}, {Name: "Null", Type: "String"}},
+			Data: [][]*string{
+				{strPtr("id"), strPtr("INT"), strPtr("NO")},
+				{strPtr("g"), strPtr("UNKNOWN(a b c)"), strPtr("NO")},
+			},
+		}))
+	}))
+	defer server.Close()
+	dc, err := buildDatabendConn(context.Background(), testHTTPConfig(t, server.URL))
+	require.NoError(t, err)
+	defer dc.Close()
+
+	// the rows are written untyped rather than the batch failing
+	b, err := dc.prepareBatch(context.Background(), "INSERT INTO t VALUES")
+	require.NoError(t, err)
+	defer b.buf.Reset()
+	assert.Nil(t, b.columns)
+	require.NoError(t, b.AppendToFile([]driver.Value{int64(1), "x y"}))
+	require.NoError(t, b.enc.flush())
+	r, err := b.buf.Reader()
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "1,x y\n", string(data))
+}
@@ -0,0 +1,103 @@
+package godatabend
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// defaultBatchSpillThreshold is the size up to which a batch is kept in memory
+// when Config.BatchSpillThreshold is not set.
+const defaultBatchSpillThreshold = 64 << 20
+
+// batchBuffer holds the file staged by a batch. It is kept in memory until it
+// grows past spillThreshold bytes, and then moved to a temporary file, so that
+// small batches never touch the disk. A negative threshold never spills.
+type batchBuffer struct {
+	spillThreshold int64
+	mem            bytes.Buffer
+	file           *os.File
+	fileWriter     *bufio.Writer
+	size           int64
+}
+
+func newBatchBuffer(spillThreshold int64) *batchBuffer {
+	if spillThreshold == 0 {
+		spillThreshold = defaultBatchSpillThreshold
+	}
+	return &batchBuffer{spillThreshold: spillThreshold}
+}
+
+func (b *batchBuffer) Write(p []byte) (int, error) {
+	if b.file == nil && b.spillThreshold >= 0 && b.size+int64(len(p)) > b.spillThreshold {
+		if err := b.spill(); err != nil {
+			return 0, err
+		}
+	}
+	var (
+		n   int
+		err error
+	)
+	if b.fileWriter != nil {
+		n, err = b.fileWriter.Write(p)
+	} else {
+		n, err = b.mem.Write(p)
+	}
+	b.size += int64(n)
+	return n, err
+}
+
+// spill moves the buffered data to a temporary file.
+func (b *batchBuffer) spill() error {
+	f, err := os.CreateTemp("", "databend-batch-*")
+	if err != nil {
+		return errors.Wrap(err, "create batch spill file failed")
+	}
+	b.file = f
+	b.fileWriter = bufio.NewWriter(f)
+	if _, err := b.mem.WriteTo(b.fileWriter); err != nil {
+		return errors.Wrap(err, "write batch spill file failed")
+	}
+	b.mem = bytes.Buffer{}
+	return nil
+}
+
+// Size returns the number of bytes written.
+func (b *batchBuffer) Size() int64 {
+	return b.size
+}
+
+// spilled reports whether the data was moved to a temporary file.
+func (b *batchBuffer) spilled() bool {
+	return b.file != nil
+}
+
+// Reader returns a reader of everything written so far.
+func (b *batchBuffer) Reader() (io.Reader, error) {
+	if b.file == nil {
+		return bytes.NewReader(b.mem.Bytes()), nil
+	}
+	if err := b.fileWriter.Flush(); err != nil {
+		return nil, errors.Wrap(err, "flush batch spill file failed")
+	}
+	return io.NewSectionReader(b.file, 0, b.size), nil
+}
+
+// Reset discards the data, removing the temporary file if there is one.
+func (b *batchBuffer) Reset() error {
+	b.mem.Reset()
+	b.size = 0
+	if b.file == nil {
+		return nil
+	}
+	name := b.file.Name()
+	err := b.file.Close()
+	b.file, b.fileWriter = nil, nil
+	if rmErr := os.Remove(name); err == nil {
+		err = rmErr
+	}
+	return err
+}
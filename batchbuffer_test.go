@@ -0,0 +1,67 @@
+package godatabend
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readBatchBuffer(t *testing.T, b *batchBuffer) string {
+	r, err := b.Reader()
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestBatchBufferInMemory(t *testing.T) {
+	b := newBatchBuffer(0)
+	_, err := io.WriteString(b, "a,b\n")
+	require.NoError(t, err)
+	_, err = io.WriteString(b, "c,d\n")
+	require.NoError(t, err)
+
+	assert.False(t, b.spilled())
+	assert.Equal(t, int64(8), b.Size())
+	assert.Equal(t, "a,b\nc,d\n", readBatchBuffer(t, b))
+
+	require.NoError(t, b.Reset())
+	assert.Equal(t, int64(0), b.Size())
+	assert.Equal(t, "", readBatchBuffer(t, b))
+}
+
+func TestBatchBufferSpill(t *testing.T) {
+	b := newBatchBuffer(10)
+	_, err := io.WriteString(b, "0123456789")
+	require.NoError(t, err)
+	assert.False(t, b.spilled())
+
+	_, err = io.WriteString(b, "abc")
+	require.NoError(t, err)
+	require.True(t, b.spilled())
+	name := b.file.Name()
+	assert.Equal(t, int64(13), b.Size())
+	assert.Equal(t, "0123456789abc", readBatchBuffer(t, b))
+	// the reader can be taken again, e.g. when an upload is retried
+	assert.Equal(t, "0123456789abc", readBatchBuffer(t, b))
+
+	require.NoError(t, b.Reset())
+	assert.False(t, b.spilled())
+	_, err = os.Stat(name)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestBatchBufferNeverSpills(t *testing.T) {
+	b := newBatchBuffer(-1)
+	data := strings.Repeat("x", 1<<10)
+	for range 100 {
+		_, err := io.WriteString(b, data)
+		require.NoError(t, err)
+	}
+	assert.False(t, b.spilled())
+	assert.Equal(t, int64(100<<10), b.Size())
+}
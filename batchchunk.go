@@ -0,0 +1,259 @@
+package godatabend
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const defaultBatchUploadConcurrency = 4
+
+// batchOptions are the settings of Config that control how a batch is split
+// into chunks, uploaded and loaded.
+type batchOptions struct {
+	spillThreshold int64
+	chunkRows      int64
+	chunkBytes     int64
+	chunkInterval  time.Duration
+	concurrency    int
+	retries        int
+	loadPerChunk   bool
+	onError        string
+	maxErrors      int
+	deadLetterFile string
+}
+
+func (cfg *Config) batchOptions() batchOptions {
+	if cfg == nil {
+		return batchOptions{}
+	}
+	return batchOptions{
+		spillThreshold: cfg.BatchSpillThreshold,
+		chunkRows:      cfg.BatchChunkRows,
+		chunkBytes:     cfg.BatchChunkBytes,
+		chunkInterval:  cfg.BatchChunkInterval,
+		concurrency:    cfg.BatchUploadConcurrency,
+		retries:        cfg.BatchChunkRetries,
+		loadPerChunk:   cfg.BatchLoadPerChunk,
+		onError:        cfg.BatchOnError,
+		maxErrors:      cfg.BatchMaxErrors,
+		deadLetterFile: cfg.BatchDeadLetterFile,
+	}
+}
+
+func (o batchOptions) uploadConcurrency() int {
+	if o.concurrency > 0 {
+		return o.concurrency
+	}
+	return defaultBatchUploadConcurrency
+}
+
+// chunkFull reports whether a chunk with the given rows and size, started
+// elapsed ago, has reached one of the thresholds.
+func (o batchOptions) chunkFull(rows, size int64, elapsed time.Duration) bool {
+	return o.chunkRows > 0 && rows >= o.chunkRows ||
+		o.chunkBytes > 0 && size >= o.chunkBytes ||
+		o.chunkInterval > 0 && elapsed >= o.chunkInterval
+}
+
+// batchStage uploads the chunks of a batch and loads them. It is implemented
+// by APIClient.
+type batchStage interface {
+	UploadToStage(ctx context.Context, stage *StageLocation, input *bufio.Reader, size int64) error
+	InsertWithStage(ctx context.Context, sql string, stage *StageLocation, fileFormatOptions, copyOptions map[string]string) (*QueryResponse, error)
+	querySyncJSON(ctx context.Context, query string) (*QueryResponse, error)
+	NewDefaultCSVFormatOptions() map[string]string
+}
+
+// batchChunk is a file of a batch, uploaded in the background.
+type batchChunk struct {
+	index int
+	// firstRow is the position in the batch of the first row of the chunk
+	firstRow      int64
+	rows          int64
+	buf           *batchBuffer
+	stage         *StageLocation
+	formatOptions map[string]string
+	// multiLine lists the records of a CSV chunk that span several lines
+	multiLine []csvRecordLines
+
+	// set once the upload is done
+	loaded bool
+	// loads is what COPY INTO loaded, when the chunk is loaded with copyOnError
+	loads []batchFileLoad
+	err   error
+}
+
+// maybeCutChunk starts uploading the current chunk if it reached a threshold,
+// and starts a new one.
+func (b *httpBatch) maybeCutChunk() error {
+	if !b.opts.chunkFull(b.chunkRows, b.buf.Size(), time.Since(b.chunkStart)) {
+		return nil
+	}
+	if err := b.sealChunk(); err != nil {
+		return err
+	}
+	enc, err := b.newEncoder(b.buf)
+	if err != nil {
+		return err
+	}
+	b.enc = enc
+	return nil
+}
+
+// sealChunk completes the current chunk and starts uploading it, waiting while
+// the maximum number of uploads are in flight. An empty chunk is skipped unless
+// it is the only one, so that an empty batch behaves as before.
+func (b *httpBatch) sealChunk() error {
+	if b.chunkRows == 0 && len(b.chunks) > 0 {
+		return nil
+	}
+	if err := b.enc.flush(); err != nil {
+		return errors.Wrap(err, "write batch file failed")
+	}
+	var formatOptions map[string]string
+	if format := b.enc.fileFormat(); format != nil {
+		var err error
+		if formatOptions, err = format.Options(); err != nil {
+			return err
+		}
+	}
+	c := &batchChunk{
+		index:         len(b.chunks),
+		firstRow:      b.rows - b.chunkRows,
+		rows:          b.chunkRows,
+		buf:           b.buf,
+		stage:         &StageLocation{Name: "~", Path: fmt.Sprintf("%s/%d.%s", b.stageDir, len(b.chunks), b.enc.extension())},
+		formatOptions: formatOptions,
+	}
+	if enc, ok := b.enc.(*csvBatchEncoder); ok {
+		c.multiLine = enc.multiLine
+	}
+	b.chunks = append(b.chunks, c)
+	b.buf = newBatchBuffer(b.opts.spillThreshold)
+	b.chunkRows = 0
+	b.chunkStart = time.Now()
+
+	b.uploads <- struct{}{}
+	b.wg.Add(1)
+	go func() {
+		defer func() {
+			<-b.uploads
+			b.wg.Done()
+		}()
+		c.err = b.processChunk(c)
+	}()
+	return nil
+}
+
+// processChunk uploads a chunk, retrying failed uploads, and loads it with
+// loadPerChunk. The buffer of the chunk is released once it is uploaded, unless
+// it is kept for the dead letter file.
+func (b *httpBatch) processChunk(c *batchChunk) error {
+	if b.opts.deadLetterFile == "" {
+		defer func() {
+			if err := c.buf.Reset(); err != nil {
+				b.conn.log("delete batch insert file failed: ", err)
+			}
+		}()
+	}
+	// concurrent requests must not share a query ID
+	ctx := context.WithValue(b.ctx, ContextKeyQueryID, strings.ReplaceAll(uuid.NewString(), "-", ""))
+	for attempt := 0; ; attempt++ {
+		input, err := c.buf.Reader()
+		if err == nil {
+			err = b.stage.UploadToStage(ctx, c.stage, bufio.NewReader(input), c.buf.Size())
+		}
+		if err == nil {
+			break
+		}
+		if attempt >= b.opts.retries || ctx.Err() != nil {
+			return errors.Wrap(err, "upload to stage failed")
+		}
+		b.conn.log("retry batch chunk upload: ", c.stage, err)
+	}
+	if !b.opts.loadPerChunk {
+		return nil
+	}
+	if b.opts.copyOnError() != "" {
+		loads, err := b.copyFiles(ctx, c.stage, c.formatOptions)
+		if err != nil {
+			return err
+		}
+		c.loads = loads
+	} else if _, err := b.stage.InsertWithStage(ctx, b.query, c.stage, c.formatOptions, nil); err != nil {
+		return errors.Wrap(err, "insert with stage failed")
+	}
+	c.loaded = true
+	return nil
+}
+
+func (b *httpBatch) failedChunks() []BatchChunkFailure {
+	var failed []BatchChunkFailure
+	for _, c := range b.chunks {
+		if c.err != nil {
+			failed = append(failed, BatchChunkFailure{Index: c.index, Rows: c.rows, Err: c.err})
+		}
+	}
+	return failed
+}
+
+// chunkResult returns what the chunks loaded with loadPerChunk loaded.
+func (b *httpBatch) chunkResult() *BatchResult {
+	r := &BatchResult{}
+	for _, c := range b.chunks {
+		switch {
+		case c.loads != nil:
+			b.addLoads(r, c.loads)
+		case c.loaded:
+			r.LoadedRows += c.rows
+		}
+	}
+	return r
+}
+
+// removeStageDir removes the chunks that were uploaded, after others failed.
+func (b *httpBatch) removeStageDir() {
+	location := &StageLocation{Name: "~", Path: b.stageDir + "/"}
+	if _, err := b.stage.querySyncJSON(b.ctx, fmt.Sprintf("REMOVE %s", location)); err != nil {
+		b.conn.log("remove batch chunks failed: ", err)
+	}
+}
+
+// BatchChunkFailure is a chunk of a batch that could not be uploaded or loaded.
+type BatchChunkFailure struct {
+	// Index is the position of the chunk in the batch, from 0.
+	Index int
+	Rows  int64
+	Err   error
+}
+
+// BatchChunkError is returned by BatchInsert when chunks of a batch failed
+// after their retries. Unless Config.BatchLoadPerChunk is set, no rows are
+// loaded in that case.
+type BatchChunkError struct {
+	// LoadedRows is the number of rows of the chunks that were loaded.
+	LoadedRows int64
+	Chunks     []BatchChunkFailure
+}
+
+func (e *BatchChunkError) Error() string {
+	first := e.Chunks[0]
+	if len(e.Chunks) == 1 {
+		return fmt.Sprintf("batch chunk %d failed: %v", first.Index, first.Err)
+	}
+	return fmt.Sprintf("%d batch chunks failed, chunk %d: %v", len(e.Chunks), first.Index, first.Err)
+}
+
+func (e *BatchChunkError) Unwrap() []error {
+	errs := make([]error, len(e.Chunks))
+	for i, c := range e.Chunks {
+		errs[i] = c.Err
+	}
+	return errs
+}
@@ -0,0 +1,189 @@
+package godatabend
+
+import (
+	"bufio"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBatchStage records uploads and loads, failing the first uploads of the
+// paths in failures.
+type fakeBatchStage struct {
+	mu       sync.Mutex
+	files    map[string]string
+	failures map[string]int
+	loads    []string
+	queries  []string
+	// copyResult is the result of COPY INTO queries
+	copyResult  [][]*string
+	inFlight    int
+	maxInFlight int
+}
+
+func newFakeBatchStage() *fakeBatchStage {
+	return &fakeBatchStage{files: map[string]string{}, failures: map[string]int{}}
+}
+
+func (s *fakeBatchStage) UploadToStage(_ context.Context, stage *StageLocation, input *bufio.Reader, _ int64) error {
+	s.mu.Lock()
+	s.inFlight++
+	s.maxInFlight = max(s.maxInFlight, s.inFlight)
+	s.mu.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	data, err := io.ReadAll(input)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	if err != nil {
+		return err
+	}
+	if s.failures[stage.Path] > 0 {
+		s.failures[stage.Path]--
+		return errors.New("upload failed")
+	}
+	s.files[stage.Path] = string(data)
+	return nil
+}
+
+func (s *fakeBatchStage) InsertWithStage(_ context.Context, _ string, stage *StageLocation, _, _ map[string]string) (*QueryResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loads = append(s.loads, stage.String())
+	return &QueryResponse{}, nil
+}
+
+func (s *fakeBatchStage) querySyncJSON(_ context.Context, query string) (*QueryResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries = append(s.queries, query)
+	if strings.HasPrefix(query, "COPY INTO") {
+		return &QueryResponse{Data: s.copyResult}, nil
+	}
+	return &QueryResponse{}, nil
+}
+
+func (s *fakeBatchStage) NewDefaultCSVFormatOptions() map[string]string {
+	return map[string]string{"type": "CSV"}
+}
+
+func (s *fakeBatchStage) fileContents() []string {
+	paths := make([]string, 0, len(s.files))
+	for path := range s.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	contents := make([]string, len(paths))
+	for i, path := range paths {
+		contents[i] = s.files[path]
+	}
+	return contents
+}
+
+func newTestChunkBatch(t *testing.T, opts batchOptions) (*httpBatch, *fakeBatchStage) {
+	stage := newFakeBatchStage()
+	b, err := newHTTPBatch(context.Background(), &DatabendConn{}, "INSERT INTO t VALUES", opts)
+	require.NoError(t, err)
+	b.stage = stage
+	return b, stage
+}
+
+func appendTestRows(t *testing.T, b *httpBatch, n int) {
+	for i := range n {
+		require.NoError(t, b.AppendToFile([]driver.Value{int64(i)}))
+	}
+}
+
+func TestBatchSingleChunk(t *testing.T) {
+	b, stage := newTestChunkBatch(t, batchOptions{})
+	appendTestRows(t, b, 3)
+	require.NoError(t, b.BatchInsert())
+
+	assert.Equal(t, []string{"0\n1\n2\n"}, stage.fileContents())
+	assert.Equal(t, []string{"@~/" + b.stageDir + "/0.csv"}, stage.loads)
+}
+
+func TestBatchChunkRows(t *testing.T) {
+	b, stage := newTestChunkBatch(t, batchOptions{chunkRows: 2, concurrency: 2})
+	appendTestRows(t, b, 5)
+	require.NoError(t, b.BatchInsert())
+
+	assert.Equal(t, []string{"0\n1\n", "2\n3\n", "4\n"}, stage.fileContents())
+	assert.Equal(t, []string{"@~/" + b.stageDir + "/"}, stage.loads)
+	assert.LessOrEqual(t, stage.maxInFlight, 2)
+	for _, c := range b.chunks {
+		assert.False(t, c.buf.spilled())
+		assert.Equal(t, int64(0), c.buf.Size())
+	}
+}
+
+func TestBatchChunkRowsSkipsEmptyLastChunk(t *testing.T) {
+	b, stage := newTestChunkBatch(t, batchOptions{chunkRows: 2})
+	appendTestRows(t, b, 4)
+	require.NoError(t, b.BatchInsert())
+
+	assert.Equal(t, []string{"0\n1\n", "2\n3\n"}, stage.fileContents())
+	assert.Len(t, b.chunks, 2)
+}
+
+func TestBatchChunkRetry(t *testing.T) {
+	b, stage := newTestChunkBatch(t, batchOptions{chunkRows: 2, retries: 1})
+	stage.failures[b.stageDir+"/1.csv"] = 1
+	appendTestRows(t, b, 4)
+	require.NoError(t, b.BatchInsert())
+
+	assert.Equal(t, []string{"0\n1\n", "2\n3\n"}, stage.fileContents())
+	assert.Len(t, stage.loads, 1)
+}
+
+func TestBatchChunkFailure(t *testing.T) {
+	b, stage := newTestChunkBatch(t, batchOptions{chunkRows: 2})
+	stage.failures[b.stageDir+"/1.csv"] = 1
+	appendTestRows(t, b, 5)
+	err := b.BatchInsert()
+
+	var chunkErr *BatchChunkError
+	require.ErrorAs(t, err, &chunkErr)
+	assert.Equal(t, int64(0), chunkErr.LoadedRows)
+	require.Len(t, chunkErr.Chunks, 1)
+	assert.Equal(t, 1, chunkErr.Chunks[0].Index)
+	assert.Equal(t, int64(2), chunkErr.Chunks[0].Rows)
+	assert.EqualError(t, err, "batch chunk 1 failed: upload to stage failed: upload failed")
+	assert.Empty(t, stage.loads)
+	assert.Equal(t, []string{"REMOVE @~/" + b.stageDir + "/"}, stage.queries)
+}
+
+func TestBatchLoadPerChunk(t *testing.T) {
+	b, stage := newTestChunkBatch(t, batchOptions{chunkRows: 2, loadPerChunk: true})
+	stage.failures[b.stageDir+"/0.csv"] = 1
+	appendTestRows(t, b, 5)
+	err := b.BatchInsert()
+
+	var chunkErr *BatchChunkError
+	require.ErrorAs(t, err, &chunkErr)
+	assert.Equal(t, int64(3), chunkErr.LoadedRows)
+	require.Len(t, chunkErr.Chunks, 1)
+	assert.Equal(t, 0, chunkErr.Chunks[0].Index)
+	sort.Strings(stage.loads)
+	assert.Equal(t, []string{"@~/" + b.stageDir + "/1.csv", "@~/" + b.stageDir + "/2.csv"}, stage.loads)
+	assert.Empty(t, stage.queries)
+}
+
+func TestBatchOptionsChunkFull(t *testing.T) {
+	assert.False(t, batchOptions{}.chunkFull(1<<40, 1<<40, time.Hour))
+	assert.True(t, batchOptions{chunkRows: 10}.chunkFull(10, 0, 0))
+	assert.False(t, batchOptions{chunkRows: 10}.chunkFull(9, 1<<40, time.Hour))
+	assert.True(t, batchOptions{chunkBytes: 100}.chunkFull(1, 100, 0))
+	assert.True(t, batchOptions{chunkInterval: time.Second}.chunkFull(1, 1, time.Second))
+	assert.Equal(t, defaultBatchUploadConcurrency, batchOptions{}.uploadConcurrency())
+}
@@ -0,0 +1,327 @@
+package godatabend
+
+import (
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/arrow/decimal256"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+// batchEncoder writes the rows appended to a batch in the format of the file
+// it stages.
+type batchEncoder interface {
+	appendRow(row []driver.Value) error
+	// flush writes out everything appended so far.
+	flush() error
+	// fileFormat returns the format the staged file is loaded with, or nil for
+	// the default CSV options.
+	fileFormat() FileFormatOptions
+	extension() string
+}
+
+// csvBatchEncoder writes rows as CSV. With the columns of the target table,
+// values are checked against the column types and formatted the way Databend
+// reads them; without, they are written in their text form.
+type csvBatchEncoder struct {
+	writer  *csv.Writer
+	columns []tableColumn
+	opts    *ColumnTypeOptions
+
+	// rows and lines written so far, and the records among them with values
+	// spanning several lines, to map the lines COPY INTO reports to rows
+	rows      int64
+	lines     int64
+	multiLine []csvRecordLines
+}
+
+// csvRecordLines is a CSV record that spans several lines of its file.
+type csvRecordLines struct {
+	// row is the position of the record in the file, from 0
+	row int64
+	// line is its first line, from 1, and count the number of its lines
+	line  int64
+	count int64
+}
+
+func (e *csvBatchEncoder) appendRow(row []driver.Value) error {
+	lineData := make([]string, 0, len(row))
+	if e.columns == nil {
+		for _, v := range row {
+			s, err := batchValueText(v)
+			if err != nil {
+				return err
+			}
+			lineData = append(lineData, s)
+		}
+		return e.write(lineData)
+	}
+	values, err := convertBatchRow(e.columns, row, e.opts)
+	if err != nil {
+		return err
+	}
+	for i, x := range values {
+		desc := e.columns[i].desc
+		if s, ok := derefBatchValue(row[i]).(string); ok && !isNestedTypeName(desc.Name) {
+			// text is passed on as is once validated, so that Databend
+			// parses it as before, e.g. timestamps in the session timezone
+			lineData = append(lineData, s)
+			continue
+		}
+		lineData = append(lineData, csvBatchText(desc, x))
+	}
+	return e.write(lineData)
+}
+
+func (e *csvBatchEncoder) write(record []string) error {
+	lines := int64(1)
+	for _, v := range record {
+		lines += int64(strings.Count(v, "\n"))
+	}
+	if lines > 1 {
+		e.multiLine = append(e.multiLine, csvRecordLines{row: e.rows, line: e.lines + 1, count: lines})
+	}
+	e.rows++
+	e.lines += lines
+	return e.writer.Write(record)
+}
+
+func (e *csvBatchEncoder) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvBatchEncoder) fileFormat() FileFormatOptions {
+	return nil
+}
+
+func (e *csvBatchEncoder) extension() string {
+	return "csv"
+}
+
+// parquetBatchRowGroupRows is the number of rows per Parquet row group.
+const parquetBatchRowGroupRows = 64 * 1024
+
+// parquetBatchEncoder writes rows as Parquet, with the column types of the
+// target table.
+type parquetBatchEncoder struct {
+	columns []tableColumn
+	opts    *ColumnTypeOptions
+	builder *array.RecordBuilder
+	writer  *pqarrow.FileWriter
+	rows    int
+	closed  bool
+}
+
+func newParquetBatchEncoder(w io.Writer, columns []tableColumn, opts *ColumnTypeOptions) (*parquetBatchEncoder, error) {
+	schema, err := arrowBatchSchema(columns)
+	if err != nil {
+		return nil, err
+	}
+	props := parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Zstd))
+	writer, err := pqarrow.NewFileWriter(schema, w, props, pqarrow.DefaultWriterProps())
+	if err != nil {
+		return nil, fmt.Errorf("create parquet writer failed: %w", err)
+	}
+	return &parquetBatchEncoder{
+		columns: columns,
+		opts:    opts,
+		builder: array.NewRecordBuilder(memory.DefaultAllocator, schema),
+		writer:  writer,
+	}, nil
+}
+
+func (e *parquetBatchEncoder) appendRow(row []driver.Value) error {
+	if e.closed {
+		return fmt.Errorf("batch is already flushed")
+	}
+	// convert the whole row first, so that a bad value leaves no partial row
+	values, err := convertBatchRow(e.columns, row, e.opts)
+	if err != nil {
+		return err
+	}
+	for i, x := range values {
+		appendArrowValue(e.builder.Field(i), x)
+	}
+	e.rows++
+	if e.rows >= parquetBatchRowGroupRows {
+		return e.writeRowGroup()
+	}
+	return nil
+}
+
+func (e *parquetBatchEncoder) writeRowGroup() error {
+	record := e.builder.NewRecord()
+	defer record.Release()
+	e.rows = 0
+	return e.writer.Write(record)
+}
+
+func (e *parquetBatchEncoder) flush() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	defer e.builder.Release()
+	if e.rows > 0 {
+		if err := e.writeRowGroup(); err != nil {
+			return err
+		}
+	}
+	return e.writer.Close()
+}
+
+func (e *parquetBatchEncoder) fileFormat() FileFormatOptions {
+	return ParquetFormat{}
+}
+
+func (e *parquetBatchEncoder) extension() string {
+	return "parquet"
+}
+
+// BatchValueError reports a row appended to a batch with a value that does not
+// fit its column, or with the wrong number of values.
+type BatchValueError struct {
+	// Row is the position of the row in the batch, from 0.
+	Row int64
+	// Column is the name of the column, or empty if the number of values is wrong.
+	Column string
+	Err    error
+}
+
+func (e *BatchValueError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("batch row %d: %v", e.Row, e.Err)
+	}
+	return fmt.Sprintf("batch row %d, column %s: %v", e.Row, e.Column, e.Err)
+}
+
+func (e *BatchValueError) Unwrap() error {
+	return e.Err
+}
+
+// convertBatchRow converts the values of a row with convertBatchValue.
+func convertBatchRow(columns []tableColumn, row []driver.Value, opts *ColumnTypeOptions) ([]any, error) {
+	if len(row) != len(columns) {
+		return nil, &BatchValueError{Err: fmt.Errorf("expected %d values, got %d", len(columns), len(row))}
+	}
+	values := make([]any, len(row))
+	for i, v := range row {
+		x, err := convertBatchValue(columns[i].desc, v, opts)
+		if err != nil {
+			return nil, &BatchValueError{Column: columns[i].name, Err: err}
+		}
+		values[i] = x
+	}
+	return values, nil
+}
+
+// csvBatchText formats a value converted by convertBatchValue for a CSV file.
+func csvBatchText(desc *TypeDesc, v any) string {
+	if v == nil {
+		return "NULL"
+	}
+	switch v := v.(type) {
+	case string:
+		return v
+	case time.Time:
+		if desc.Name == "Date" {
+			return v.Format(dateFormat)
+		}
+		return v.Format(timeFormat)
+	case []any, []batchMapEntry:
+		var sb strings.Builder
+		writeBatchNestedText(&sb, desc, v)
+		return sb.String()
+	}
+	return batchScalarText(desc, v)
+}
+
+// batchScalarText formats numbers, booleans, decimals and binary values.
+func batchScalarText(desc *TypeDesc, v any) string {
+	switch v := v.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		if desc.Name == "Float32" {
+			return strconv.FormatFloat(v, 'g', -1, 32)
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case decimal128.Num:
+		_, scale, _ := decimalPrecisionScale(desc)
+		return v.ToString(int32(scale))
+	case decimal256.Num:
+		_, scale, _ := decimalPrecisionScale(desc)
+		return v.ToString(int32(scale))
+	case []byte:
+		return binaryText(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// binaryText returns the text form of a Binary value in the default
+// binary_format of Databend, upper case hex.
+func binaryText(b []byte) string {
+	return strings.ToUpper(hex.EncodeToString(b))
+}
+
+// writeBatchNestedText writes a value in the text form of nested values, e.g.
+// `[1,2]`, `{'k':'v'}` or `(1,'a')`.
+func writeBatchNestedText(sb *strings.Builder, desc *TypeDesc, v any) {
+	switch v := v.(type) {
+	case nil:
+		sb.WriteString("NULL")
+	case []any:
+		open, end := "[", "]"
+		if desc.Name == "Tuple" {
+			open, end = "(", ")"
+		}
+		sb.WriteString(open)
+		for i, elem := range v {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			elemDesc := &TypeDesc{Name: "Float32"}
+			switch desc.Name {
+			case "Array":
+				elemDesc = desc.Args[0]
+			case "Tuple":
+				elemDesc = desc.Args[i]
+			}
+			writeBatchNestedText(sb, elemDesc, elem)
+		}
+		sb.WriteString(end)
+	case []batchMapEntry:
+		sb.WriteByte('{')
+		for i, entry := range v {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeBatchNestedText(sb, desc.Args[0], entry.key)
+			sb.WriteByte(':')
+			writeBatchNestedText(sb, desc.Args[1], entry.value)
+		}
+		sb.WriteByte('}')
+	case string:
+		sb.WriteString(quote(escape(v)))
+	case time.Time, []byte:
+		sb.WriteString(quote(csvBatchText(desc, v)))
+	default:
+		sb.WriteString(batchScalarText(desc, v))
+	}
+}
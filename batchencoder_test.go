@@ -0,0 +1,227 @@
+package godatabend
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/csv"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseSQLTypeDesc(t *testing.T, s string) *TypeDesc {
+	desc, err := parseSQLTypeDesc(s)
+	require.NoError(t, err)
+	return desc
+}
+
+func readParquetBatch(t *testing.T, buf *batchBuffer) arrow.Table {
+	r, err := buf.Reader()
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	table, err := pqarrow.ReadTable(context.Background(), bytes.NewReader(data), parquet.NewReaderProperties(nil), pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	require.NoError(t, err)
+	t.Cleanup(table.Release)
+	return table
+}
+
+func TestParquetBatchEncoder(t *testing.T) {
+	columns := []tableColumn{
+		{name: "id", desc: mustParseSQLTypeDesc(t, "BIGINT")},
+		{name: "name", desc: mustParseSQLTypeDesc(t, "Nullable(String)")},
+		{name: "price", desc: mustParseSQLTypeDesc(t, "DECIMAL(10, 2)")},
+		{name: "tags", desc: mustParseSQLTypeDesc(t, "ARRAY(INT16)")},
+		{name: "ts", desc: mustParseSQLTypeDesc(t, "TIMESTAMP")},
+		{name: "raw", desc: mustParseSQLTypeDesc(t, "BINARY")},
+	}
+	buf := newBatchBuffer(0)
+	enc, err := newParquetBatchEncoder(buf, columns, defaultColumnTypeOptions())
+	require.NoError(t, err)
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	require.NoError(t, enc.appendRow([]driver.Value{int64(1), "a", "1.25", []int16{1, 2}, ts, []byte{0, 1}}))
+	require.NoError(t, enc.appendRow([]driver.Value{"2", nil, NewDecimalFromInt64(-5, 1), "[4, 5, 6]", "2024-01-02 03:04:05", []byte(nil)}))
+	require.NoError(t, enc.flush())
+	assert.Equal(t, ParquetFormat{}, enc.fileFormat())
+
+	table := readParquetBatch(t, buf)
+	require.Equal(t, int64(2), table.NumRows())
+	reader := array.NewTableReader(table, -1)
+	defer reader.Release()
+	require.True(t, reader.Next())
+	record := reader.Record()
+
+	ids := record.Column(0).(*array.Int64)
+	assert.Equal(t, []int64{1, 2}, ids.Int64Values())
+	names := record.Column(1).(*array.String)
+	assert.Equal(t, "a", names.Value(0))
+	assert.True(t, names.IsNull(1))
+	prices := record.Column(2).(*array.Decimal128)
+	assert.Equal(t, decimal128.FromI64(125), prices.Value(0))
+	assert.Equal(t, decimal128.FromI64(-50), prices.Value(1))
+	tags := record.Column(3).(*array.List)
+	assert.Equal(t, "[1,2]", tags.ValueStr(0))
+	assert.Equal(t, "[4,5,6]", tags.ValueStr(1))
+	tss := record.Column(4).(*array.Timestamp)
+	assert.Equal(t, ts.UnixMicro(), int64(tss.Value(0)))
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).UnixMicro(), int64(tss.Value(1)))
+	raw := record.Column(5).(*array.Binary)
+	assert.Equal(t, []byte{0, 1}, raw.Value(0))
+}
+
+func TestParquetBatchEncoderRejectsBadRows(t *testing.T) {
+	columns := []tableColumn{
+		{name: "id", desc: mustParseSQLTypeDesc(t, "INT")},
+		{name: "flag", desc: mustParseSQLTypeDesc(t, "BOOLEAN")},
+	}
+	buf := newBatchBuffer(0)
+	enc, err := newParquetBatchEncoder(buf, columns, defaultColumnTypeOptions())
+	require.NoError(t, err)
+
+	assert.EqualError(t, enc.appendRow([]driver.Value{int64(1)}), "batch row 0: expected 2 values, got 1")
+	assert.EqualError(t, enc.appendRow([]driver.Value{int64(1) << 40, true}), "batch row 0, column id: value 1099511627776 out of range for Int32")
+	assert.EqualError(t, enc.appendRow([]driver.Value{nil, true}), "batch row 0, column id: NULL is not allowed in Int32")
+	require.NoError(t, enc.appendRow([]driver.Value{int32(7), "true"}))
+	require.NoError(t, enc.flush())
+
+	table := readParquetBatch(t, buf)
+	assert.Equal(t, int64(1), table.NumRows())
+}
+
+func TestCSVBatchEncoder(t *testing.T) {
+	columns := []tableColumn{
+		{name: "id", desc: mustParseSQLTypeDesc(t, "TINYINT UNSIGNED")},
+		{name: "price", desc: mustParseSQLTypeDesc(t, "Nullable(DECIMAL(10, 2))")},
+		{name: "ratio", desc: mustParseSQLTypeDesc(t, "FLOAT")},
+		{name: "ok", desc: mustParseSQLTypeDesc(t, "BOOLEAN")},
+		{name: "d", desc: mustParseSQLTypeDesc(t, "DATE")},
+		{name: "ts", desc: mustParseSQLTypeDesc(t, "TIMESTAMP")},
+		{name: "raw", desc: mustParseSQLTypeDesc(t, "BINARY")},
+		{name: "m", desc: mustParseSQLTypeDesc(t, "MAP(STRING, ARRAY(DATE))")},
+		{name: "t", desc: mustParseSQLTypeDesc(t, "TUPLE(INT, Nullable(STRING))")},
+	}
+	buf := newBatchBuffer(0)
+	enc := &csvBatchEncoder{writer: csv.NewWriter(buf), columns: columns, opts: defaultColumnTypeOptions()}
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.UTC)
+	require.NoError(t, enc.appendRow([]driver.Value{
+		int64(7), 1.5, float32(0.1), true, ts, ts, []byte{0xab, 1},
+		map[string][]time.Time{"k'1": {ts}}, []any{int32(1), nil},
+	}))
+	require.NoError(t, enc.appendRow([]driver.Value{
+		"8", nil, "0.5", "false", "2024-01-02", "2024-01-02 03:04:05", "AB01",
+		"{'a':['2024-01-02']}", "(1,'x')",
+	}))
+	require.NoError(t, enc.flush())
+
+	r, err := buf.Reader()
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, `7,1.50,0.1,true,2024-01-02,2024-01-02 03:04:05.000006+00:00,AB01,{'k\'1':['2024-01-02']},"(1,NULL)"
+8,NULL,0.5,false,2024-01-02,2024-01-02 03:04:05,AB01,{'a':['2024-01-02']},"(1,'x')"
+`, string(data))
+}
+
+func TestBatchEncodersBinaryStrings(t *testing.T) {
+	columns := []tableColumn{{name: "raw", desc: mustParseSQLTypeDesc(t, "BINARY")}}
+
+	// strings are read as hex by both encoders, as Databend reads them from CSV
+	csvBuf := newBatchBuffer(0)
+	csvEnc := &csvBatchEncoder{writer: csv.NewWriter(csvBuf), columns: columns, opts: defaultColumnTypeOptions()}
+	require.NoError(t, csvEnc.appendRow([]driver.Value{"AB01"}))
+	require.NoError(t, csvEnc.appendRow([]driver.Value{[]byte{0xab, 1}}))
+	assert.Error(t, csvEnc.appendRow([]driver.Value{"xyz"}))
+	require.NoError(t, csvEnc.flush())
+	r, err := csvBuf.Reader()
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "AB01\nAB01\n", string(data))
+
+	parquetBuf := newBatchBuffer(0)
+	parquetEnc, err := newParquetBatchEncoder(parquetBuf, columns, defaultColumnTypeOptions())
+	require.NoError(t, err)
+	require.NoError(t, parquetEnc.appendRow([]driver.Value{"AB01"}))
+	require.NoError(t, parquetEnc.appendRow([]driver.Value{[]byte{0xab, 1}}))
+	assert.EqualError(t, parquetEnc.appendRow([]driver.Value{"xyz"}), `batch row 0, column raw: invalid hex value for Binary: "xyz"`)
+	require.NoError(t, parquetEnc.flush())
+	table := readParquetBatch(t, parquetBuf)
+	reader := array.NewTableReader(table, -1)
+	defer reader.Release()
+	require.True(t, reader.Next())
+	raw := reader.Record().Column(0).(*array.Binary)
+	assert.Equal(t, []byte{0xab, 1}, raw.Value(0))
+	assert.Equal(t, []byte{0xab, 1}, raw.Value(1))
+}
+
+func TestArrowBatchSchemaNamedTuple(t *testing.T) {
+	schema, err := arrowBatchSchema([]tableColumn{
+		{name: "t", desc: mustParseSQLTypeDesc(t, "TUPLE(a INT, b VARCHAR)")},
+		{name: "u", desc: mustParseSQLTypeDesc(t, "TUPLE(INT, VARCHAR)")},
+	})
+	require.NoError(t, err)
+	named := schema.Field(0).Type.(*arrow.StructType)
+	assert.Equal(t, "a", named.Field(0).Name)
+	assert.Equal(t, "b", named.Field(1).Name)
+	unnamed := schema.Field(1).Type.(*arrow.StructType)
+	assert.Equal(t, "1", unnamed.Field(0).Name)
+}
+
+func TestCSVBatchEncoderNullWrappers(t *testing.T) {
+	columns := []tableColumn{
+		{name: "tags", desc: mustParseSQLTypeDesc(t, "Nullable(ARRAY(INT))")},
+		{name: "raw", desc: mustParseSQLTypeDesc(t, "Nullable(BINARY)")},
+	}
+	row := []driver.Value{sql.Null[Array[int]]{V: Array[int]{1, 2}, Valid: true}, sql.Null[[]byte]{}}
+
+	buf := newBatchBuffer(0)
+	enc := &csvBatchEncoder{writer: csv.NewWriter(buf), columns: columns, opts: defaultColumnTypeOptions()}
+	require.NoError(t, enc.appendRow(row))
+	require.NoError(t, enc.flush())
+	r, err := buf.Reader()
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "\"[1,2]\",NULL\n", string(data))
+
+	buf = newBatchBuffer(0)
+	penc, err := newParquetBatchEncoder(buf, columns, defaultColumnTypeOptions())
+	require.NoError(t, err)
+	require.NoError(t, penc.appendRow(row))
+	require.NoError(t, penc.flush())
+	table := readParquetBatch(t, buf)
+	reader := array.NewTableReader(table, -1)
+	defer reader.Release()
+	require.True(t, reader.Next())
+	assert.Equal(t, "[1,2]", reader.Record().Column(0).(*array.List).ValueStr(0))
+	assert.True(t, reader.Record().Column(1).IsNull(0))
+}
+
+func TestCSVBatchEncoderRejectsBadRows(t *testing.T) {
+	columns := []tableColumn{
+		{name: "id", desc: mustParseSQLTypeDesc(t, "SMALLINT")},
+		{name: "tags", desc: mustParseSQLTypeDesc(t, "ARRAY(INT)")},
+	}
+	enc := &csvBatchEncoder{writer: csv.NewWriter(io.Discard), columns: columns, opts: defaultColumnTypeOptions()}
+
+	err := enc.appendRow([]driver.Value{"abc", "[1]"})
+	var valueErr *BatchValueError
+	require.ErrorAs(t, err, &valueErr)
+	assert.Equal(t, "id", valueErr.Column)
+	assert.ErrorContains(t, enc.appendRow([]driver.Value{int64(1), "[1, x]"}), "batch row 0, column tags: ")
+	assert.EqualError(t, enc.appendRow([]driver.Value{int64(1)}), "batch row 0: expected 2 values, got 1")
+	assert.ErrorContains(t, enc.appendRow([]driver.Value{int64(1), true}), "batch row 0, column tags: ")
+}
@@ -0,0 +1,245 @@
+package godatabend
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BatchResult is the outcome of a batch insert. It implements driver.Result,
+// with RowsAffected returning LoadedRows.
+type BatchResult struct {
+	LoadedRows int64
+	// RejectedRows is the number of rows skipped with Config.BatchOnError.
+	RejectedRows int64
+	// Rejected holds the first rejected row of each staged file, which is the
+	// one Databend reports.
+	Rejected []BatchRejectedRow
+}
+
+// BatchRejectedRow is a row of a batch that Databend could not load.
+type BatchRejectedRow struct {
+	// Row is the position of the row in the batch, from 0, or -1 if the file
+	// is not one of the batch.
+	Row int64
+	// File and Line locate the row in the staged files, with lines from 1.
+	File    string
+	Line    int64
+	Message string
+}
+
+func (r *BatchResult) LastInsertId() (int64, error) {
+	return 0, errors.New("LastInsertId is not supported")
+}
+
+func (r *BatchResult) RowsAffected() (int64, error) {
+	return r.LoadedRows, nil
+}
+
+// batchFileLoad is a row of the result of COPY INTO, one per loaded file.
+type batchFileLoad struct {
+	file           string
+	loaded         int64
+	errors         int64
+	firstError     string
+	firstErrorLine int64
+}
+
+// parseCopyResult reads the File, Rows_loaded, Errors_seen, First_error and
+// First_error_line columns of the result of COPY INTO.
+func parseCopyResult(data [][]*string) ([]batchFileLoad, error) {
+	loads := make([]batchFileLoad, 0, len(data))
+	for _, row := range data {
+		if len(row) < 5 || row[0] == nil || row[1] == nil {
+			return nil, errors.New("unexpected result of COPY INTO")
+		}
+		load := batchFileLoad{file: *row[0]}
+		var err error
+		if load.loaded, err = strconv.ParseInt(*row[1], 10, 64); err != nil {
+			return nil, errors.Wrap(err, "unexpected result of COPY INTO")
+		}
+		if row[2] != nil {
+			if load.errors, err = strconv.ParseInt(*row[2], 10, 64); err != nil {
+				return nil, errors.Wrap(err, "unexpected result of COPY INTO")
+			}
+		}
+		if row[3] != nil {
+			load.firstError = *row[3]
+		}
+		if row[4] != nil {
+			if load.firstErrorLine, err = strconv.ParseInt(*row[4], 10, 64); err != nil {
+				return nil, errors.Wrap(err, "unexpected result of COPY INTO")
+			}
+		}
+		loads = append(loads, load)
+	}
+	return loads, nil
+}
+
+// copyOnError returns the ON_ERROR copy option batches are loaded with, or an
+// empty string to load them with an insert that fails on the first bad row.
+func (o batchOptions) copyOnError() string {
+	if o.maxErrors > 0 {
+		// let Databend stop the load, rather than finding out once it is done
+		return fmt.Sprintf("abort_%d", o.maxErrors+1)
+	}
+	return o.onError
+}
+
+// checkCopyTarget reports an error unless the rejected rows of query can be
+// reported, which requires loading them with COPY INTO.
+func checkCopyTarget(query string) error {
+	stmt := parseSQLStatement(query)
+	if !stmt.word(0, "INSERT") || stmt.word(1, "OVERWRITE") {
+		return errors.New("batch_on_error and batch_max_errors only support INSERT INTO")
+	}
+	return nil
+}
+
+// copyQuery returns the COPY INTO statement that loads the files at location
+// into the table and columns of the batch.
+func (b *httpBatch) copyQuery(location *StageLocation, fileFormatOptions map[string]string) (string, error) {
+	database, table, err := getTableNameFromInsertQuery(b.query)
+	if err != nil {
+		return "", err
+	}
+	if fileFormatOptions == nil {
+		fileFormatOptions = b.stage.NewDefaultCSVFormatOptions()
+	}
+	return CopyIntoBuilder{
+		Database:   database,
+		Table:      table,
+		Columns:    parseInsertColumnNames(b.query),
+		From:       location,
+		FileFormat: fileFormatOptions,
+		Copy:       &CopyOptions{OnError: b.opts.copyOnError(), Purge: true},
+	}.Build()
+}
+
+// copyFiles loads the files at location with COPY INTO and returns what was
+// loaded from each.
+func (b *httpBatch) copyFiles(ctx context.Context, location *StageLocation, fileFormatOptions map[string]string) ([]batchFileLoad, error) {
+	query, err := b.copyQuery(location, fileFormatOptions)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.stage.querySyncJSON(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "copy into failed")
+	}
+	return parseCopyResult(resp.Data)
+}
+
+// addLoads adds what COPY INTO loaded from the files of the batch to r.
+func (b *httpBatch) addLoads(r *BatchResult, loads []batchFileLoad) {
+	for _, load := range loads {
+		r.LoadedRows += load.loaded
+		r.RejectedRows += load.errors
+		if load.errors == 0 {
+			continue
+		}
+		rejected := BatchRejectedRow{Row: -1, File: load.file, Line: load.firstErrorLine, Message: load.firstError}
+		if c := b.chunkOfFile(load.file); c != nil && load.firstErrorLine > 0 {
+			rejected.Row = c.firstRow + c.rowOfLine(load.firstErrorLine)
+		}
+		r.Rejected = append(r.Rejected, rejected)
+	}
+}
+
+func (b *httpBatch) chunkOfFile(file string) *batchChunk {
+	for _, c := range b.chunks {
+		if strings.HasSuffix(file, c.stage.Path) {
+			return c
+		}
+	}
+	return nil
+}
+
+// writeDeadLetters appends the rejected rows to the dead letter file, each as a
+// CSV record of its position in the batch, the error and, for CSV batches, the
+// values as staged.
+func (b *httpBatch) writeDeadLetters(rejected []BatchRejectedRow) error {
+	f, err := os.OpenFile(b.opts.deadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "open dead letter file failed")
+	}
+	w := csv.NewWriter(f)
+	for _, r := range rejected {
+		record := []string{strconv.FormatInt(r.Row, 10), r.Message}
+		if c := b.chunkOfFile(r.File); c != nil && r.Line > 0 {
+			values, err := c.stagedCSVRecord(r.Line)
+			if err != nil {
+				b.conn.log("read rejected batch row failed: ", err)
+			}
+			record = append(record, values...)
+		}
+		if err := w.Write(record); err != nil {
+			_ = f.Close()
+			return errors.Wrap(err, "write dead letter file failed")
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, "write dead letter file failed")
+	}
+	return f.Close()
+}
+
+// rowOfLine returns the position in the chunk, from 0, of the row at line of
+// its file, skipping the extra lines of CSV records whose values span several.
+func (c *batchChunk) rowOfLine(line int64) int64 {
+	extra := int64(0)
+	for _, r := range c.multiLine {
+		if line < r.line {
+			break
+		}
+		if line < r.line+r.count {
+			return r.row
+		}
+		extra += r.count - 1
+	}
+	return line - 1 - extra
+}
+
+// stagedCSVRecord returns the values of the record at line of a CSV chunk, or
+// nil for other formats.
+func (c *batchChunk) stagedCSVRecord(line int64) ([]string, error) {
+	if !strings.HasSuffix(c.stage.Path, ".csv") {
+		return nil, nil
+	}
+	input, err := c.buf.Reader()
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(input)
+	r.FieldsPerRecord = -1
+	row := c.rowOfLine(line)
+	for i := int64(0); ; i++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			return nil, fmt.Errorf("line %d not found in %s", line, c.stage.Path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if i == row {
+			return record, nil
+		}
+	}
+}
+
+// checkMaxErrors reports an error if more rows were rejected than allowed
+// across all the files of the batch.
+func (o batchOptions) checkMaxErrors(r *BatchResult) error {
+	if o.maxErrors > 0 && r.RejectedRows > int64(o.maxErrors) {
+		return fmt.Errorf("batch rejected %d rows, more than the maximum of %d", r.RejectedRows, o.maxErrors)
+	}
+	return nil
+}
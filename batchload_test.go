@@ -0,0 +1,105 @@
+package godatabend
+
+import (
+	"database/sql/driver"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func copyResultRow(file, loaded, errors, firstError, firstErrorLine string) []*string {
+	row := []*string{strPtr(file), strPtr(loaded), strPtr(errors), nil, nil}
+	if firstError != "" {
+		row[3], row[4] = strPtr(firstError), strPtr(firstErrorLine)
+	}
+	return row
+}
+
+func TestBatchOnErrorContinue(t *testing.T) {
+	deadLetters := filepath.Join(t.TempDir(), "rejected.csv")
+	b, stage := newTestChunkBatch(t, batchOptions{chunkRows: 2, onError: "continue", deadLetterFile: deadLetters})
+	b.query = "INSERT INTO db.t (id) VALUES"
+	stage.copyResult = [][]*string{
+		copyResultRow(b.stageDir+"/0.csv", "2", "0", "", ""),
+		copyResultRow(b.stageDir+"/1.csv", "1", "1", "bad value", "2"),
+		copyResultRow(b.stageDir+"/2.csv", "1", "0", "", ""),
+	}
+	appendTestRows(t, b, 5)
+	result, err := b.BatchInsertWithResult()
+	require.NoError(t, err)
+
+	assert.Empty(t, stage.loads)
+	assert.Equal(t, []string{
+		"COPY INTO `db`.`t` (`id`) FROM @~/" + b.stageDir + "/ FILE_FORMAT = (TYPE = 'CSV') ON_ERROR = continue PURGE = true",
+	}, stage.queries)
+	assert.Equal(t, &BatchResult{
+		LoadedRows:   4,
+		RejectedRows: 1,
+		Rejected:     []BatchRejectedRow{{Row: 3, File: b.stageDir + "/1.csv", Line: 2, Message: "bad value"}},
+	}, result)
+	n, err := result.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), n)
+
+	data, err := os.ReadFile(deadLetters)
+	require.NoError(t, err)
+	assert.Equal(t, "3,bad value,3\n", string(data))
+}
+
+func TestBatchMaxErrors(t *testing.T) {
+	b, stage := newTestChunkBatch(t, batchOptions{chunkRows: 2, loadPerChunk: true, maxErrors: 1})
+	stage.copyResult = [][]*string{copyResultRow("0.csv", "1", "1", "bad value", "1")}
+	appendTestRows(t, b, 4)
+	result, err := b.BatchInsertWithResult()
+	assert.EqualError(t, err, "batch rejected 2 rows, more than the maximum of 1")
+
+	assert.Len(t, stage.queries, 2)
+	assert.Contains(t, stage.queries[0], "ON_ERROR = abort_2")
+	assert.Equal(t, int64(2), result.LoadedRows)
+	assert.Equal(t, int64(2), result.RejectedRows)
+	// the file name does not match a chunk of the batch
+	assert.Equal(t, int64(-1), result.Rejected[0].Row)
+}
+
+func TestCheckCopyTarget(t *testing.T) {
+	assert.NoError(t, checkCopyTarget("insert into t (a) values"))
+	assert.Error(t, checkCopyTarget("INSERT OVERWRITE INTO t VALUES"))
+	assert.Error(t, checkCopyTarget("REPLACE INTO t ON (a) VALUES"))
+}
+
+func TestParseCopyResult(t *testing.T) {
+	loads, err := parseCopyResult([][]*string{copyResultRow("a.csv", "3", "1", "bad", "2")})
+	require.NoError(t, err)
+	assert.Equal(t, []batchFileLoad{{file: "a.csv", loaded: 3, errors: 1, firstError: "bad", firstErrorLine: 2}}, loads)
+
+	_, err = parseCopyResult([][]*string{{strPtr("a.csv"), strPtr("x"), nil, nil, nil}})
+	assert.Error(t, err)
+}
+
+func TestBatchRejectedRowAfterMultiLineValue(t *testing.T) {
+	deadLetters := filepath.Join(t.TempDir(), "rejected.csv")
+	b, stage := newTestChunkBatch(t, batchOptions{onError: "continue", deadLetterFile: deadLetters})
+	b.query = "INSERT INTO t (id, note) VALUES"
+	// the second row starts on line 3, after the two lines of the first
+	stage.copyResult = [][]*string{copyResultRow(b.stageDir+"/0.csv", "2", "1", "bad value", "3")}
+	require.NoError(t, b.AppendToFile([]driver.Value{int64(0), "a\nb"}))
+	require.NoError(t, b.AppendToFile([]driver.Value{int64(1), "x"}))
+	require.NoError(t, b.AppendToFile([]driver.Value{int64(2), "c\nd\ne"}))
+	result, err := b.BatchInsertWithResult()
+	require.NoError(t, err)
+
+	assert.Equal(t, []BatchRejectedRow{{Row: 1, File: b.stageDir + "/0.csv", Line: 3, Message: "bad value"}}, result.Rejected)
+	data, err := os.ReadFile(deadLetters)
+	require.NoError(t, err)
+	assert.Equal(t, "1,bad value,1,x\n", string(data))
+}
+
+func TestBatchChunkRowOfLine(t *testing.T) {
+	c := &batchChunk{multiLine: []csvRecordLines{{row: 1, line: 2, count: 2}, {row: 3, line: 5, count: 3}}}
+	for line, row := range map[int64]int64{1: 0, 2: 1, 3: 1, 4: 2, 5: 3, 7: 3, 8: 4} {
+		assert.Equal(t, row, c.rowOfLine(line), "line %d", line)
+	}
+}
@@ -0,0 +1,220 @@
+package godatabend
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	arrowarray "github.com/apache/arrow-go/v18/arrow/array"
+)
+
+var reflectTypeInt256 = reflect.TypeOf(Int256{})
+
+// bigIntBits returns the width and signedness of the 128 and 256-bit integer
+// types.
+func bigIntBits(name string) (bits uint, signed bool, ok bool) {
+	switch name {
+	case "Int128":
+		return 128, true, true
+	case "UInt128":
+		return 128, false, true
+	case "Int256":
+		return 256, true, true
+	case "UInt256":
+		return 256, false, true
+	}
+	return 0, false, false
+}
+
+func checkBigIntRange(v *big.Int, bits uint, signed bool) error {
+	if signed {
+		limit := new(big.Int).Lsh(big.NewInt(1), bits-1)
+		if v.Cmp(limit) >= 0 || v.Cmp(limit.Neg(limit)) < 0 {
+			return fmt.Errorf("%s out of range for Int%d", v, bits)
+		}
+		return nil
+	}
+	if v.Sign() < 0 || v.BitLen() > int(bits) {
+		return fmt.Errorf("%s out of range for UInt%d", v, bits)
+	}
+	return nil
+}
+
+// parseBigInt parses the text form of a value of the integer type name.
+func parseBigInt(name, s string) (*big.Int, error) {
+	bits, signed, _ := bigIntBits(name)
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s value %q", name, s)
+	}
+	if err := checkBigIntRange(v, bits, signed); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// bigIntFromLittleEndian decodes a two's complement integer, or an unsigned
+// one, stored in little-endian order.
+func bigIntFromLittleEndian(b []byte, signed bool) *big.Int {
+	be := slices.Clone(b)
+	slices.Reverse(be)
+	v := new(big.Int).SetBytes(be)
+	if signed && len(be) > 0 && be[0]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(8*len(be))))
+	}
+	return v
+}
+
+// materializeArrowBigIntDriverValue reads a 128 or 256-bit integer sent as a
+// Decimal with scale 0, a fixed-size binary or text, and returns its text.
+func materializeArrowBigIntDriverValue(name string, column arrow.Array, rowIdx int) (driver.Value, error) {
+	bits, signed, _ := bigIntBits(name)
+	var v *big.Int
+	switch arr := column.(type) {
+	case *arrowarray.Decimal128:
+		v = arr.Value(rowIdx).BigInt()
+	case *arrowarray.Decimal256:
+		v = arr.Value(rowIdx).BigInt()
+	case *arrowarray.FixedSizeBinary:
+		b := arr.Value(rowIdx)
+		if uint(len(b))*8 != bits {
+			return nil, fmt.Errorf("invalid arrow %s value of %d bytes", name, len(b))
+		}
+		return bigIntFromLittleEndian(b, signed).String(), nil
+	case *arrowarray.String:
+		return arr.Value(rowIdx), nil
+	case *arrowarray.LargeString:
+		return arr.Value(rowIdx), nil
+	default:
+		return nil, fmt.Errorf("unsupported arrow %s column %T", name, column)
+	}
+	if !signed && v.Sign() < 0 {
+		// unsigned values above the signed maximum wrap around in a decimal
+		v.Add(v, new(big.Int).Lsh(big.NewInt(1), bits))
+	}
+	// as text, like the JSON format
+	return v.String(), nil
+}
+
+type bigIntColumnType struct {
+	dbType string
+	columnTypeDefault
+	isNullable
+}
+
+func (c bigIntColumnType) Parse(s string) (driver.Value, error) {
+	if c.checkNull(s) {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// ScanType is Int256, which holds all but the largest UInt256 values; those
+// are left as text.
+func (c bigIntColumnType) ScanType() reflect.Type {
+	if c.dbType == "UInt256" {
+		return reflectTypeString
+	}
+	return reflectTypeInt256
+}
+
+func (c bigIntColumnType) DatabaseTypeName() string {
+	return c.wrapName(c.dbType)
+}
+
+func (c bigIntColumnType) Desc() *TypeDesc {
+	return &TypeDesc{Name: c.dbType, Nullable: bool(c.isNullable)}
+}
+
+// Int256 is a signed 256-bit integer. Unlike a *big.Int, it is checked to be
+// in the range of Int256 columns, and can be scanned from and bound to them
+// directly. The zero value is 0.
+type Int256 struct {
+	v *big.Int
+}
+
+// NewInt256 returns v as an Int256, or an error if it is out of range.
+func NewInt256(v *big.Int) (Int256, error) {
+	if err := checkBigIntRange(v, 256, true); err != nil {
+		return Int256{}, err
+	}
+	return Int256{v: new(big.Int).Set(v)}, nil
+}
+
+// ParseInt256 parses a base 10 integer.
+func ParseInt256(s string) (Int256, error) {
+	v, err := parseBigInt("Int256", s)
+	if err != nil {
+		return Int256{}, err
+	}
+	return Int256{v: v}, nil
+}
+
+// BigInt returns the value as a *big.Int.
+func (i Int256) BigInt() *big.Int {
+	if i.v == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(i.v)
+}
+
+// LittleEndian returns the 32-byte two's complement representation.
+func (i Int256) LittleEndian() [32]byte {
+	var out [32]byte
+	v := i.BigInt()
+	if v.Sign() < 0 {
+		v.Add(v, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+	v.FillBytes(out[:])
+	slices.Reverse(out[:])
+	return out
+}
+
+// Int256FromLittleEndian decodes the 32-byte two's complement representation.
+func Int256FromLittleEndian(b [32]byte) Int256 {
+	return Int256{v: bigIntFromLittleEndian(b[:], true)}
+}
+
+// String returns the value in base 10.
+func (i Int256) String() string {
+	return i.BigInt().String()
+}
+
+// Value implements driver.Valuer
+func (i Int256) Value() (driver.Value, error) {
+	return i.String(), nil
+}
+
+// Scan implements sql.Scanner
+func (i *Int256) Scan(src any) error {
+	var (
+		v   Int256
+		err error
+	)
+	switch s := src.(type) {
+	case *big.Int:
+		v, err = NewInt256(s)
+	case string:
+		v, err = ParseInt256(s)
+	case []byte:
+		v, err = ParseInt256(string(s))
+	case int64:
+		v = Int256{v: big.NewInt(s)}
+	case uint64:
+		v = Int256{v: new(big.Int).SetUint64(s)}
+	case Int256:
+		v = s
+	case nil:
+		return fmt.Errorf("cannot scan NULL into Int256, use sql.Null[Int256]")
+	default:
+		return fmt.Errorf("cannot scan %T into Int256", src)
+	}
+	if err != nil {
+		return err
+	}
+	*i = v
+	return nil
+}
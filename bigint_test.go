@@ -0,0 +1,190 @@
+package godatabend
+
+import (
+	"database/sql"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	arrowarray "github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bigIntFromString(t *testing.T, s string) *big.Int {
+	t.Helper()
+	v, ok := new(big.Int).SetString(s, 10)
+	require.True(t, ok, s)
+	return v
+}
+
+func TestParseBigInt(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		ok    bool
+	}{
+		{name: "Int128", input: "-170141183460469231731687303715884105728", ok: true},
+		{name: "Int128", input: "170141183460469231731687303715884105727", ok: true},
+		{name: "Int128", input: "170141183460469231731687303715884105728"},
+		{name: "UInt128", input: "340282366920938463463374607431768211455", ok: true},
+		{name: "UInt128", input: "340282366920938463463374607431768211456"},
+		{name: "UInt128", input: "-1"},
+		{name: "Int256", input: "-57896044618658097711785492504343953926634992332820282019728792003956564819968", ok: true},
+		{name: "UInt256", input: "115792089237316195423570985008687907853269984665640564039457584007913129639935", ok: true},
+		{name: "Int256", input: "1.5"},
+	}
+	for _, tc := range tests {
+		v, err := parseBigInt(tc.name, tc.input)
+		if !tc.ok {
+			assert.Error(t, err, "%s %s", tc.name, tc.input)
+			continue
+		}
+		require.NoError(t, err, "%s %s", tc.name, tc.input)
+		assert.Equal(t, tc.input, v.String())
+	}
+}
+
+func TestBigIntColumnType(t *testing.T) {
+	db := sql.OpenDB(&fakeConnector{
+		resp: &QueryResponse{
+			Schema: &[]DataField{{Name: "x", Type: "Nullable(UInt256)"}, {Name: "y", Type: "Nullable(Int128)"}},
+			Data: [][]*string{
+				{strPtr("115792089237316195423570985008687907853269984665640564039457584007913129639935"), strPtr("-170141183460469231731687303715884105728")},
+				{strPtr("42"), strPtr("7")},
+				{nil, nil},
+			},
+		},
+	})
+
+	rows, err := db.Query("x")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	assert.Equal(t, reflectTypeString, types[0].ScanType())
+	assert.Equal(t, "UInt256 NULL", types[0].DatabaseTypeName())
+	assert.Equal(t, reflectTypeInt256, types[1].ScanType())
+
+	require.True(t, rows.Next())
+	var s string
+	var v Int256
+	require.NoError(t, rows.Scan(&s, &v))
+	assert.Equal(t, "115792089237316195423570985008687907853269984665640564039457584007913129639935", s)
+	assert.Equal(t, "-170141183460469231731687303715884105728", v.String())
+
+	require.True(t, rows.Next())
+	var n int64
+	require.NoError(t, rows.Scan(&n, &s))
+	assert.Equal(t, int64(42), n)
+	assert.Equal(t, "7", s)
+
+	require.True(t, rows.Next())
+	var ns sql.NullString
+	var nv sql.Null[Int256]
+	require.NoError(t, rows.Scan(&ns, &nv))
+	assert.False(t, ns.Valid)
+	assert.False(t, nv.Valid)
+}
+
+func TestInt256(t *testing.T) {
+	var zero Int256
+	assert.Equal(t, "0", zero.String())
+
+	v, err := ParseInt256("-12345678901234567890123456789012345678901234567890")
+	require.NoError(t, err)
+	assert.Equal(t, "-12345678901234567890123456789012345678901234567890", v.String())
+	assert.Equal(t, v, Int256FromLittleEndian(v.LittleEndian()))
+
+	minusOne := Int256FromLittleEndian([32]byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	})
+	assert.Equal(t, "-1", minusOne.String())
+
+	_, err = NewInt256(new(big.Int).Lsh(big.NewInt(1), 255))
+	assert.Error(t, err)
+
+	// the value does not alias the argument
+	b := big.NewInt(7)
+	v, err = NewInt256(b)
+	require.NoError(t, err)
+	b.SetInt64(8)
+	assert.Equal(t, "7", v.String())
+
+	dv, err := v.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "7", dv)
+
+	require.NoError(t, v.Scan(int64(-3)))
+	assert.Equal(t, "-3", v.String())
+	require.NoError(t, v.Scan([]byte("99")))
+	assert.Equal(t, "99", v.String())
+	require.NoError(t, v.Scan(big.NewInt(5)))
+	assert.Equal(t, "5", v.String())
+	assert.Error(t, v.Scan(nil))
+	assert.Error(t, v.Scan("x"))
+	assert.Error(t, v.Scan(1.5))
+}
+
+func TestDecodeArrowResponseMaterializesBigInt(t *testing.T) {
+	resp := QueryResponse{
+		ID: "query-bigint",
+		Schema: &[]DataField{
+			{Name: "i", Type: "Int128"},
+			{Name: "u", Type: "UInt128"},
+			{Name: "w", Type: "Int256"},
+		},
+	}
+
+	var minusTwo [32]byte
+	for i := range minusTwo {
+		minusTwo[i] = 0xff
+	}
+	minusTwo[0] = 0xfe
+
+	payload := buildArrowPayload(t, resp, []arrow.Field{
+		{Name: "i", Type: &arrow.Decimal128Type{Precision: 38, Scale: 0}},
+		{Name: "u", Type: &arrow.Decimal128Type{Precision: 38, Scale: 0}},
+		{Name: "w", Type: &arrow.FixedSizeBinaryType{ByteWidth: 32}},
+	}, func(builder *arrowarray.RecordBuilder) {
+		builder.Field(0).(*arrowarray.Decimal128Builder).Append(decimal128.FromI64(-5))
+		// UInt128 max has all bits set, which reads as -1 in a signed decimal
+		builder.Field(1).(*arrowarray.Decimal128Builder).Append(decimal128.FromI64(-1))
+		builder.Field(2).(*arrowarray.FixedSizeBinaryBuilder).Append(minusTwo[:])
+	})
+
+	decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
+		headers: http.Header{contentType: []string{arrowStreamContentType}},
+		body:    payload,
+	})
+	require.NoError(t, err)
+	require.Len(t, decoded.typedRows, 1)
+	assert.Equal(t, "-5", decoded.typedRows[0][0])
+	assert.Equal(t, "340282366920938463463374607431768211455", decoded.typedRows[0][1])
+	assert.Equal(t, "-2", decoded.typedRows[0][2])
+}
+
+func TestNestedBigInt(t *testing.T) {
+	db := sql.OpenDB(&fakeConnector{
+		resp: &QueryResponse{
+			Schema: &[]DataField{{Name: "x", Type: "Array(Int128)"}},
+			Data:   [][]*string{{strPtr("[1,-170141183460469231731687303715884105728]")}},
+		},
+	})
+
+	rows, err := db.Query("x")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	require.True(t, rows.Next())
+	var s []string
+	require.NoError(t, rows.Scan(&s))
+	assert.Equal(t, []string{"1", "-170141183460469231731687303715884105728"}, s)
+	var v Array[*big.Int]
+	require.NoError(t, rows.Scan(&v))
+	assert.Equal(t, Array[*big.Int]{big.NewInt(1), bigIntFromString(t, "-170141183460469231731687303715884105728")}, v)
+}
@@ -0,0 +1,674 @@
+package godatabend
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"iter"
+	"math/bits"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+)
+
+// Limits and cookies of the portable roaring format, see
+// https://github.com/RoaringBitmap/RoaringFormatSpec.
+const (
+	bitmapArrayMax = 4096
+	bitmapWords    = 1024
+
+	roaringSerialCookieNoRun = 12346
+	roaringSerialCookie      = 12347
+	roaringNoOffsetThreshold = 4
+)
+
+var reflectTypeBitmap = reflect.TypeOf((*Bitmap)(nil))
+
+// Bitmap is a set of uint64, the value of Bitmap columns. Like the roaring
+// bitmaps Databend stores, values are grouped by their upper 48 bits into
+// containers holding the lower 16 bits, either as a sorted array or, past 4096
+// values, as a bitset.
+//
+// The zero value is an empty set. Use Clone rather than copying a Bitmap.
+type Bitmap struct {
+	keys       []uint64
+	containers []*bitmapContainer
+}
+
+// NewBitmap returns a bitmap holding values.
+func NewBitmap(values ...uint64) *Bitmap {
+	b := &Bitmap{}
+	for _, v := range values {
+		b.Add(v)
+	}
+	return b
+}
+
+// ParseBitmap parses the text form of a bitmap, a comma-separated list of
+// values such as "1,3,5".
+func ParseBitmap(s string) (*Bitmap, error) {
+	b := &Bitmap{}
+	if strings.TrimSpace(s) == "" {
+		return b, nil
+	}
+	for _, field := range strings.Split(s, ",") {
+		v, err := strconv.ParseUint(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bitmap %q", s)
+		}
+		b.Add(v)
+	}
+	return b, nil
+}
+
+func (b *Bitmap) find(key uint64) (int, bool) {
+	i := sort.Search(len(b.keys), func(i int) bool { return b.keys[i] >= key })
+	return i, i < len(b.keys) && b.keys[i] == key
+}
+
+// Add adds v to the set, reporting whether it was not present.
+func (b *Bitmap) Add(v uint64) bool {
+	i, ok := b.find(v >> 16)
+	if !ok {
+		b.keys = append(b.keys, 0)
+		copy(b.keys[i+1:], b.keys[i:])
+		b.keys[i] = v >> 16
+		b.containers = append(b.containers, nil)
+		copy(b.containers[i+1:], b.containers[i:])
+		b.containers[i] = &bitmapContainer{}
+	}
+	return b.containers[i].add(uint16(v))
+}
+
+// Remove removes v from the set, reporting whether it was present.
+func (b *Bitmap) Remove(v uint64) bool {
+	i, ok := b.find(v >> 16)
+	if !ok || !b.containers[i].remove(uint16(v)) {
+		return false
+	}
+	if b.containers[i].card == 0 {
+		b.keys = append(b.keys[:i], b.keys[i+1:]...)
+		b.containers = append(b.containers[:i], b.containers[i+1:]...)
+	}
+	return true
+}
+
+// Contains reports whether v is in the set.
+func (b *Bitmap) Contains(v uint64) bool {
+	i, ok := b.find(v >> 16)
+	return ok && b.containers[i].contains(uint16(v))
+}
+
+// Cardinality returns the number of values in the set.
+func (b *Bitmap) Cardinality() uint64 {
+	var n uint64
+	for _, c := range b.containers {
+		n += uint64(c.card)
+	}
+	return n
+}
+
+// IsEmpty reports whether the set has no values.
+func (b *Bitmap) IsEmpty() bool {
+	return len(b.keys) == 0
+}
+
+// Values iterates over the set in ascending order.
+func (b *Bitmap) Values() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for i, c := range b.containers {
+			high := b.keys[i] << 16
+			if !c.each(func(lo uint16) bool { return yield(high | uint64(lo)) }) {
+				return
+			}
+		}
+	}
+}
+
+// ToSlice returns the values of the set in ascending order.
+func (b *Bitmap) ToSlice() []uint64 {
+	values := make([]uint64, 0, b.Cardinality())
+	for v := range b.Values() {
+		values = append(values, v)
+	}
+	return values
+}
+
+// Clone returns a copy of b.
+func (b *Bitmap) Clone() *Bitmap {
+	clone := &Bitmap{
+		keys:       append([]uint64(nil), b.keys...),
+		containers: make([]*bitmapContainer, len(b.containers)),
+	}
+	for i, c := range b.containers {
+		clone.containers[i] = &bitmapContainer{
+			array:  append([]uint16(nil), c.array...),
+			bitset: append([]uint64(nil), c.bitset...),
+			card:   c.card,
+		}
+	}
+	return clone
+}
+
+// Equal reports whether b and other hold the same values.
+func (b *Bitmap) Equal(other *Bitmap) bool {
+	if len(b.keys) != len(other.keys) {
+		return false
+	}
+	for i, key := range b.keys {
+		if key != other.keys[i] || b.containers[i].card != other.containers[i].card {
+			return false
+		}
+		x, y := b.containers[i].words(), other.containers[i].words()
+		for j := range x {
+			if x[j] != y[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Or returns the union of b and other.
+func (b *Bitmap) Or(other *Bitmap) *Bitmap {
+	return b.combine(other, func(x, y uint64) uint64 { return x | y }, true, true)
+}
+
+// And returns the intersection of b and other.
+func (b *Bitmap) And(other *Bitmap) *Bitmap {
+	return b.combine(other, func(x, y uint64) uint64 { return x & y }, false, false)
+}
+
+// AndNot returns the values of b that are not in other.
+func (b *Bitmap) AndNot(other *Bitmap) *Bitmap {
+	return b.combine(other, func(x, y uint64) uint64 { return x &^ y }, true, false)
+}
+
+// Xor returns the values that are in exactly one of b and other.
+func (b *Bitmap) Xor(other *Bitmap) *Bitmap {
+	return b.combine(other, func(x, y uint64) uint64 { return x ^ y }, true, true)
+}
+
+// combine merges the containers of b and other, applying op to the bitsets of
+// the containers they share. keepB and keepOther tell whether containers found
+// in only one of them are kept.
+func (b *Bitmap) combine(other *Bitmap, op func(x, y uint64) uint64, keepB, keepOther bool) *Bitmap {
+	out := &Bitmap{}
+	appendContainer := func(key uint64, c *bitmapContainer) {
+		if c != nil && c.card > 0 {
+			out.keys = append(out.keys, key)
+			out.containers = append(out.containers, c)
+		}
+	}
+	i, j := 0, 0
+	for i < len(b.keys) || j < len(other.keys) {
+		switch {
+		case j == len(other.keys) || i < len(b.keys) && b.keys[i] < other.keys[j]:
+			if keepB {
+				appendContainer(b.keys[i], containerFromWords(b.containers[i].words()))
+			}
+			i++
+		case i == len(b.keys) || other.keys[j] < b.keys[i]:
+			if keepOther {
+				appendContainer(other.keys[j], containerFromWords(other.containers[j].words()))
+			}
+			j++
+		default:
+			x, y := b.containers[i].words(), other.containers[j].words()
+			for k := range x {
+				x[k] = op(x[k], y[k])
+			}
+			appendContainer(b.keys[i], containerFromWords(x))
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// String returns the values as a comma-separated list, the text form of a
+// bitmap in Databend.
+func (b *Bitmap) String() string {
+	var sb strings.Builder
+	for v := range b.Values() {
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatUint(v, 10))
+	}
+	return sb.String()
+}
+
+// MarshalBinary encodes the bitmap in the portable 64-bit roaring format
+// (a RoaringTreemap), which is how Databend serializes bitmaps.
+func (b *Bitmap) MarshalBinary() ([]byte, error) {
+	var buf []byte
+	var groups []int // index of the first container of each 32-bit group
+	for i, key := range b.keys {
+		if i == 0 || key>>16 != b.keys[i-1]>>16 {
+			groups = append(groups, i)
+		}
+	}
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(groups)))
+	for g, start := range groups {
+		end := len(b.keys)
+		if g+1 < len(groups) {
+			end = groups[g+1]
+		}
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(b.keys[start]>>16))
+		buf = b.appendRoaring32(buf, start, end)
+	}
+	return buf, nil
+}
+
+// appendRoaring32 writes containers [start, end), which share their upper 32
+// bits, as a 32-bit roaring bitmap without run containers.
+func (b *Bitmap) appendRoaring32(buf []byte, start, end int) []byte {
+	n := end - start
+	buf = binary.LittleEndian.AppendUint32(buf, roaringSerialCookieNoRun)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(n))
+	for i := start; i < end; i++ {
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(b.keys[i]))
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(b.containers[i].card-1))
+	}
+	offset := 8 + 8*n
+	for i := start; i < end; i++ {
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(offset))
+		if b.containers[i].bitset != nil {
+			offset += 8 * bitmapWords
+		} else {
+			offset += 2 * b.containers[i].card
+		}
+	}
+	for i := start; i < end; i++ {
+		c := b.containers[i]
+		if c.bitset != nil {
+			for _, w := range c.bitset {
+				buf = binary.LittleEndian.AppendUint64(buf, w)
+			}
+			continue
+		}
+		for _, lo := range c.array {
+			buf = binary.LittleEndian.AppendUint16(buf, lo)
+		}
+	}
+	return buf
+}
+
+// UnmarshalBinary decodes a bitmap in the portable 64-bit roaring format.
+func (b *Bitmap) UnmarshalBinary(data []byte) error {
+	r := &bitmapReader{data: data}
+	groups, err := r.uint64()
+	if err != nil {
+		return err
+	}
+	if groups > uint64(len(data)) {
+		return fmt.Errorf("invalid bitmap: %d groups in %d bytes", groups, len(data))
+	}
+	out := Bitmap{}
+	for g := uint64(0); g < groups; g++ {
+		high, err := r.uint32()
+		if err != nil {
+			return err
+		}
+		if err := r.roaring32(&out, uint64(high)<<16); err != nil {
+			return err
+		}
+	}
+	if r.pos != len(data) {
+		return fmt.Errorf("invalid bitmap: %d trailing bytes", len(data)-r.pos)
+	}
+	*b = out
+	return nil
+}
+
+type bitmapReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitmapReader) read(n int) ([]byte, error) {
+	if n < 0 || len(r.data)-r.pos < n {
+		return nil, fmt.Errorf("invalid bitmap: truncated at offset %d", r.pos)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *bitmapReader) uint16() (uint16, error) {
+	b, err := r.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(b), nil
+}
+
+func (r *bitmapReader) uint32() (uint32, error) {
+	b, err := r.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+func (r *bitmapReader) uint64() (uint64, error) {
+	b, err := r.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+// roaring32 reads a 32-bit roaring bitmap into out, whose keys it prefixes
+// with high.
+func (r *bitmapReader) roaring32(out *Bitmap, high uint64) error {
+	cookie, err := r.uint32()
+	if err != nil {
+		return err
+	}
+	var n int
+	var runs []byte
+	switch {
+	case cookie == roaringSerialCookieNoRun:
+		count, err := r.uint32()
+		if err != nil {
+			return err
+		}
+		n = int(count)
+	case cookie&0xFFFF == roaringSerialCookie:
+		n = int(cookie>>16) + 1
+		if runs, err = r.read((n + 7) / 8); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid bitmap: unknown roaring cookie %#x", cookie)
+	}
+	if n > bitmapArrayMax*16 || 4*n > len(r.data)-r.pos {
+		return fmt.Errorf("invalid bitmap: %d containers", n)
+	}
+
+	keys := make([]uint16, n)
+	cards := make([]int, n)
+	for i := range keys {
+		if keys[i], err = r.uint16(); err != nil {
+			return err
+		}
+		card, err := r.uint16()
+		if err != nil {
+			return err
+		}
+		cards[i] = int(card) + 1
+	}
+	if runs == nil || n >= roaringNoOffsetThreshold {
+		// the offsets are only needed for random access
+		if _, err := r.read(4 * n); err != nil {
+			return err
+		}
+	}
+
+	for i, key := range keys {
+		var c *bitmapContainer
+		switch {
+		case runs != nil && runs[i/8]&(1<<(i%8)) != 0:
+			c, err = r.runContainer()
+		case cards[i] > bitmapArrayMax:
+			c, err = r.bitsetContainer()
+		default:
+			c, err = r.arrayContainer(cards[i])
+		}
+		if err != nil {
+			return err
+		}
+		if c == nil {
+			continue
+		}
+		k := high | uint64(key)
+		if len(out.keys) > 0 && out.keys[len(out.keys)-1] >= k {
+			return fmt.Errorf("invalid bitmap: unsorted containers")
+		}
+		out.keys = append(out.keys, k)
+		out.containers = append(out.containers, c)
+	}
+	return nil
+}
+
+func (r *bitmapReader) arrayContainer(card int) (*bitmapContainer, error) {
+	data, err := r.read(2 * card)
+	if err != nil {
+		return nil, err
+	}
+	c := &bitmapContainer{array: make([]uint16, card), card: card}
+	for i := range c.array {
+		c.array[i] = binary.LittleEndian.Uint16(data[2*i:])
+		if i > 0 && c.array[i] <= c.array[i-1] {
+			return nil, fmt.Errorf("invalid bitmap: unsorted array container")
+		}
+	}
+	return c, nil
+}
+
+func (r *bitmapReader) bitsetContainer() (*bitmapContainer, error) {
+	data, err := r.read(8 * bitmapWords)
+	if err != nil {
+		return nil, err
+	}
+	words := make([]uint64, bitmapWords)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint64(data[8*i:])
+	}
+	return containerFromWords(words), nil
+}
+
+func (r *bitmapReader) runContainer() (*bitmapContainer, error) {
+	n, err := r.uint16()
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.read(4 * int(n))
+	if err != nil {
+		return nil, err
+	}
+	words := make([]uint64, bitmapWords)
+	for i := 0; i < int(n); i++ {
+		start := int(binary.LittleEndian.Uint16(data[4*i:]))
+		end := start + int(binary.LittleEndian.Uint16(data[4*i+2:]))
+		if end > 0xFFFF {
+			return nil, fmt.Errorf("invalid bitmap: run out of range")
+		}
+		for v := start; v <= end; v++ {
+			words[v/64] |= 1 << (v % 64)
+		}
+	}
+	return containerFromWords(words), nil
+}
+
+// Value implements driver.Valuer, returning the text form of the bitmap.
+func (b *Bitmap) Value() (driver.Value, error) {
+	if b == nil {
+		return nil, nil
+	}
+	return b.String(), nil
+}
+
+// Scan implements sql.Scanner
+func (b *Bitmap) Scan(src any) error {
+	switch v := src.(type) {
+	case *Bitmap:
+		*b = *v.Clone()
+	case []byte:
+		return b.UnmarshalBinary(v)
+	case string:
+		parsed, err := ParseBitmap(v)
+		if err != nil {
+			return err
+		}
+		*b = *parsed
+	case nil:
+		return fmt.Errorf("cannot scan NULL into Bitmap, use *Bitmap")
+	default:
+		return fmt.Errorf("cannot scan %T into Bitmap", src)
+	}
+	return nil
+}
+
+type bitmapContainer struct {
+	array  []uint16 // sorted values, unless bitset is set
+	bitset []uint64
+	card   int
+}
+
+func containerFromWords(words []uint64) *bitmapContainer {
+	card := 0
+	for _, w := range words {
+		card += bits.OnesCount64(w)
+	}
+	if card == 0 {
+		return nil
+	}
+	if card > bitmapArrayMax {
+		return &bitmapContainer{bitset: words, card: card}
+	}
+	c := &bitmapContainer{array: make([]uint16, 0, card), card: card}
+	for i, w := range words {
+		for w != 0 {
+			c.array = append(c.array, uint16(i*64+bits.TrailingZeros64(w)))
+			w &= w - 1
+		}
+	}
+	return c
+}
+
+func (c *bitmapContainer) search(lo uint16) (int, bool) {
+	i := sort.Search(len(c.array), func(i int) bool { return c.array[i] >= lo })
+	return i, i < len(c.array) && c.array[i] == lo
+}
+
+func (c *bitmapContainer) contains(lo uint16) bool {
+	if c.bitset != nil {
+		return c.bitset[lo/64]&(1<<(lo%64)) != 0
+	}
+	_, ok := c.search(lo)
+	return ok
+}
+
+func (c *bitmapContainer) add(lo uint16) bool {
+	if c.contains(lo) {
+		return false
+	}
+	c.card++
+	if c.bitset != nil {
+		c.bitset[lo/64] |= 1 << (lo % 64)
+		return true
+	}
+	i, _ := c.search(lo)
+	c.array = append(c.array, 0)
+	copy(c.array[i+1:], c.array[i:])
+	c.array[i] = lo
+	if len(c.array) > bitmapArrayMax {
+		c.bitset, c.array = c.words(), nil
+	}
+	return true
+}
+
+func (c *bitmapContainer) remove(lo uint16) bool {
+	if !c.contains(lo) {
+		return false
+	}
+	c.card--
+	if c.bitset != nil {
+		c.bitset[lo/64] &^= 1 << (lo % 64)
+		if c.card <= bitmapArrayMax {
+			*c = *containerFromWords(c.bitset)
+		}
+		return true
+	}
+	i, _ := c.search(lo)
+	c.array = append(c.array[:i], c.array[i+1:]...)
+	return true
+}
+
+// each calls fn for every value in ascending order, stopping when it returns
+// false. It reports whether all values were visited.
+func (c *bitmapContainer) each(fn func(uint16) bool) bool {
+	if c.bitset == nil {
+		for _, lo := range c.array {
+			if !fn(lo) {
+				return false
+			}
+		}
+		return true
+	}
+	for i, w := range c.bitset {
+		for w != 0 {
+			if !fn(uint16(i*64 + bits.TrailingZeros64(w))) {
+				return false
+			}
+			w &= w - 1
+		}
+	}
+	return true
+}
+
+// words returns a copy of the container as a bitset.
+func (c *bitmapContainer) words() []uint64 {
+	words := make([]uint64, bitmapWords)
+	if c.bitset != nil {
+		copy(words, c.bitset)
+		return words
+	}
+	for _, lo := range c.array {
+		words[lo/64] |= 1 << (lo % 64)
+	}
+	return words
+}
+
+func materializeArrowBitmapDriverValue(column arrow.Array, rowIdx int) (driver.Value, error) {
+	marshaled, ok := column.(marshaledArrowArray)
+	if !ok {
+		return nil, fmt.Errorf("arrow column does not support row materialization: %T", column)
+	}
+
+	switch value := marshaled.GetOneForMarshal(rowIdx).(type) {
+	case []byte:
+		b := &Bitmap{}
+		if err := b.UnmarshalBinary(value); err != nil {
+			return nil, err
+		}
+		// as text, like the JSON format
+		return b.String(), nil
+	case string:
+		return value, nil
+	default:
+		return nil, fmt.Errorf("unsupported arrow bitmap value type %T", value)
+	}
+}
+
+type bitmapColumnType struct {
+	columnTypeDefault
+	isNullable
+}
+
+func (c bitmapColumnType) Parse(s string) (driver.Value, error) {
+	if c.checkNull(s) {
+		return nil, nil
+	}
+	// passed on as is, since the server may send a placeholder such as
+	// `<bitmap binary>`; Bitmap.Scan decodes the `1,2,3` form
+	return s, nil
+}
+
+func (bitmapColumnType) ScanType() reflect.Type {
+	return reflectTypeBitmap
+}
+
+func (c bitmapColumnType) DatabaseTypeName() string {
+	return c.wrapName("Bitmap")
+}
+
+func (c bitmapColumnType) Desc() *TypeDesc {
+	return &TypeDesc{Name: "Bitmap", Nullable: bool(c.isNullable)}
+}
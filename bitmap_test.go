@@ -0,0 +1,189 @@
+package godatabend
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"net/http"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	arrowarray "github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitmapSet(t *testing.T) {
+	b := NewBitmap(5, 1, 1<<40, 3)
+	assert.True(t, b.Contains(1))
+	assert.True(t, b.Contains(1<<40))
+	assert.False(t, b.Contains(2))
+	assert.Equal(t, uint64(4), b.Cardinality())
+	assert.Equal(t, []uint64{1, 3, 5, 1 << 40}, b.ToSlice())
+	assert.Equal(t, "1,3,5,1099511627776", b.String())
+
+	assert.False(t, b.Add(3))
+	assert.True(t, b.Remove(1<<40))
+	assert.False(t, b.Remove(1<<40))
+	assert.Equal(t, []uint64{1, 3, 5}, b.ToSlice())
+
+	var first []uint64
+	for v := range b.Values() {
+		first = append(first, v)
+		break
+	}
+	assert.Equal(t, []uint64{1}, first)
+
+	other := NewBitmap(3, 4, 5, 70000)
+	assert.Equal(t, []uint64{1, 3, 4, 5, 70000}, b.Or(other).ToSlice())
+	assert.Equal(t, []uint64{3, 5}, b.And(other).ToSlice())
+	assert.Equal(t, []uint64{1}, b.AndNot(other).ToSlice())
+	assert.Equal(t, []uint64{1, 4, 70000}, b.Xor(other).ToSlice())
+	assert.True(t, b.And(NewBitmap(2)).IsEmpty())
+	assert.Equal(t, []uint64{1, 3, 5}, b.ToSlice(), "set operations do not modify their operands")
+
+	clone := b.Clone()
+	clone.Add(9)
+	assert.False(t, b.Contains(9))
+	assert.True(t, b.Equal(NewBitmap(1, 3, 5)))
+	assert.False(t, b.Equal(clone))
+}
+
+func TestBitmapLargeContainer(t *testing.T) {
+	b := &Bitmap{}
+	for v := uint64(0); v < 10000; v += 2 {
+		b.Add(v)
+	}
+	require.NotNil(t, b.containers[0].bitset)
+	assert.Equal(t, uint64(5000), b.Cardinality())
+	assert.True(t, b.Contains(9998))
+	assert.False(t, b.Contains(9999))
+
+	for v := uint64(0); v < 2000; v += 2 {
+		b.Remove(v)
+	}
+	require.Nil(t, b.containers[0].bitset)
+	assert.Equal(t, uint64(4000), b.Cardinality())
+
+	odd := &Bitmap{}
+	for v := uint64(1); v < 10000; v += 2 {
+		odd.Add(v)
+	}
+	union := b.Or(odd)
+	assert.Equal(t, uint64(9000), union.Cardinality())
+	assert.True(t, union.Contains(1999))
+	assert.False(t, union.Contains(1998))
+}
+
+func TestBitmapBinary(t *testing.T) {
+	dense := &Bitmap{}
+	for v := uint64(1 << 33); v < 1<<33+5000; v++ {
+		dense.Add(v)
+	}
+	for _, b := range []*Bitmap{{}, NewBitmap(1, 2, 70000), NewBitmap(7, 1<<32, 1<<63), dense} {
+		data, err := b.MarshalBinary()
+		require.NoError(t, err)
+		var decoded Bitmap
+		require.NoError(t, decoded.UnmarshalBinary(data))
+		assert.True(t, b.Equal(&decoded), b.String())
+	}
+
+	// a 64-bit roaring bitmap with one group holding a run container [10, 14]
+	data := binary.LittleEndian.AppendUint64(nil, 1)
+	data = binary.LittleEndian.AppendUint32(data, 0)
+	data = binary.LittleEndian.AppendUint32(data, roaringSerialCookie)
+	data = append(data, 1)                            // run flags
+	data = binary.LittleEndian.AppendUint16(data, 0)  // key
+	data = binary.LittleEndian.AppendUint16(data, 4)  // cardinality - 1
+	data = binary.LittleEndian.AppendUint16(data, 1)  // runs
+	data = binary.LittleEndian.AppendUint16(data, 10) // start
+	data = binary.LittleEndian.AppendUint16(data, 4)  // length - 1
+	var b Bitmap
+	require.NoError(t, b.UnmarshalBinary(data))
+	assert.Equal(t, []uint64{10, 11, 12, 13, 14}, b.ToSlice())
+
+	for _, invalid := range [][]byte{nil, {1, 0, 0, 0, 0, 0, 0, 0}, data[:len(data)-1], append(data, 0)} {
+		assert.Error(t, b.UnmarshalBinary(invalid))
+	}
+}
+
+func TestBitmapScan(t *testing.T) {
+	var b Bitmap
+	require.NoError(t, b.Scan("1, 2,3"))
+	assert.Equal(t, []uint64{1, 2, 3}, b.ToSlice())
+	require.NoError(t, b.Scan(""))
+	assert.True(t, b.IsEmpty())
+	require.NoError(t, b.Scan(NewBitmap(4)))
+	assert.Equal(t, []uint64{4}, b.ToSlice())
+	assert.Error(t, b.Scan("1,x"))
+	assert.Error(t, b.Scan(nil))
+
+	v, err := NewBitmap(2, 1).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "1,2", v)
+	v, err = (*Bitmap)(nil).Value()
+	require.NoError(t, err)
+	assert.Nil(t, v)
+}
+
+func TestBitmapColumnType(t *testing.T) {
+	db := sql.OpenDB(&fakeConnector{
+		resp: &QueryResponse{
+			Schema: &[]DataField{{Name: "x", Type: "Nullable(Bitmap)"}},
+			Data:   [][]*string{{strPtr("1,5")}, {nil}, {strPtr("<bitmap binary>")}},
+		},
+	})
+
+	rows, err := db.Query("x")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	assert.Equal(t, reflectTypeBitmap, types[0].ScanType())
+
+	require.True(t, rows.Next())
+	var b Bitmap
+	require.NoError(t, rows.Scan(&b))
+	assert.Equal(t, []uint64{1, 5}, b.ToSlice())
+	var s string
+	require.NoError(t, rows.Scan(&s))
+	assert.Equal(t, "1,5", s)
+
+	require.True(t, rows.Next())
+	var ptr *Bitmap
+	require.NoError(t, rows.Scan(&ptr))
+	assert.Nil(t, ptr)
+
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&s))
+	assert.Equal(t, "<bitmap binary>", s)
+	assert.Error(t, rows.Scan(&b))
+	assert.False(t, rows.Next())
+}
+
+func TestDecodeArrowResponseMaterializesBitmap(t *testing.T) {
+	resp := QueryResponse{
+		ID:     "query-bitmap",
+		Schema: &[]DataField{{Name: "b", Type: "Bitmap"}},
+	}
+	data, err := NewBitmap(1, 2, 1<<40).MarshalBinary()
+	require.NoError(t, err)
+
+	payload := buildArrowPayload(t, resp, []arrow.Field{
+		{
+			Name:     "b",
+			Type:     arrow.BinaryTypes.LargeBinary,
+			Metadata: arrow.NewMetadata([]string{arrowExtensionKey}, []string{arrowExtensionBitmap}),
+		},
+	}, func(builder *arrowarray.RecordBuilder) {
+		builder.Field(0).(*arrowarray.BinaryBuilder).Append(data)
+	})
+
+	decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
+		headers: http.Header{contentType: []string{arrowStreamContentType}},
+		body:    payload,
+	})
+	require.NoError(t, err)
+	require.Len(t, decoded.typedRows, 1)
+	assert.Equal(t, "1,2,1099511627776", decoded.typedRows[0][0])
+}
@@ -106,6 +106,7 @@ type APIClient struct {
 
 	statsTracker      QueryStatsTracker
 	accessTokenLoader AccessTokenLoader
+	types             *TypeRegistry
 
 	WaitTimeSeconds      int64
 	MaxRowsInBuffer      int64
@@ -211,6 +212,7 @@ func NewAPIClientFromConfig(cfg *Config) *APIClient {
 
 		accessTokenLoader: initAccessTokenLoader(cfg),
 		statsTracker:      cfg.StatsTracker,
+		types:             cfg.Types,
 
 		WaitTimeSeconds:      cfg.WaitTimeSecs,
 		MaxRowsInBuffer:      cfg.MaxRowsInBuffer,
@@ -530,6 +532,15 @@ func (c *APIClient) QuerySync(ctx context.Context, query string) (*QueryResponse
 	return c.querySyncWithTransport(ctx, query, queryTransportAuto)
 }
 
+// querySyncJSON is QuerySync with the result as text in Data, whatever the
+// query result format, for results read by the driver itself.
+func (c *APIClient) querySyncJSON(ctx context.Context, query string) (*QueryResponse, error) {
+	if err := c.initializeConnectionInfo(ctx); err != nil {
+		return nil, err
+	}
+	return c.querySyncWithTransport(ctx, query, queryTransportJSON)
+}
+
 func (c *APIClient) querySyncWithTransport(ctx context.Context, query string, transport queryTransport) (*QueryResponse, error) {
 	if transport == queryTransportAuto && c.stateRestored && c.queryResultFormat == QueryResultFormatArrow {
 		transport = queryTransportJSON
@@ -587,7 +598,7 @@ func (c *APIClient) startQueryRequest(ctx context.Context, request *QueryRequest
 
 func (c *APIClient) finalizeQueryResponse(resp *QueryResponse, respHeaders http.Header, err error) (*QueryResponse, error) {
 	if err == nil {
-		if materializeErr := materializeJSONQueryRows(resp); materializeErr != nil {
+		if materializeErr := materializeJSONQueryRows(resp, c.types); materializeErr != nil {
 			return nil, errors.Wrap(materializeErr, "failed to materialize query rows")
 		}
 	}
@@ -641,7 +652,7 @@ func (c *APIClient) startQueryRequestWithTransport(ctx context.Context, request
 				return reqErr
 			}
 			respHeaders = rawResp.headers
-			resp, reqErr = decodeQueryResponse(rawResp)
+			resp, reqErr = decodeQueryResponse(c.types, rawResp)
 			return reqErr
 		}, Query)
 	} else {
@@ -706,7 +717,7 @@ func (c *APIClient) pollQueryWithTransport(ctx context.Context, nextURI string,
 			if reqErr != nil {
 				return reqErr
 			}
-			result, reqErr = decodeQueryResponse(rawResp)
+			result, reqErr = decodeQueryResponse(c.types, rawResp)
 			return reqErr
 		}, Page)
 	} else {
@@ -720,7 +731,7 @@ func (c *APIClient) pollQueryWithTransport(ctx context.Context, nextURI string,
 		result = &jsonResp
 	}
 	if err == nil {
-		if materializeErr := materializeJSONQueryRows(result); materializeErr != nil {
+		if materializeErr := materializeJSONQueryRows(result, c.types); materializeErr != nil {
 			return nil, errors.Wrap(materializeErr, "failed to materialize query rows")
 		}
 	}
@@ -793,6 +804,24 @@ func (c *APIClient) InsertWithStage(ctx context.Context, sql string, stage *Stag
 	return c.PollUntilQueryEnd(ctx, resp)
 }
 
+// InsertWithStageOptions is InsertWithStage with typed options, which are
+// validated first. Nil options are replaced by the defaults of InsertWithStage.
+func (c *APIClient) InsertWithStageOptions(ctx context.Context, sql string, stage *StageLocation, format FileFormatOptions, copyOptions *CopyOptions) (*QueryResponse, error) {
+	var formatMap, copyMap map[string]string
+	var err error
+	if format != nil {
+		if formatMap, err = format.Options(); err != nil {
+			return nil, err
+		}
+	}
+	if copyOptions != nil {
+		if copyMap, err = copyOptions.Options(); err != nil {
+			return nil, err
+		}
+	}
+	return c.InsertWithStage(ctx, sql, stage, formatMap, copyMap)
+}
+
 func (c *APIClient) UploadToStage(ctx context.Context, stage *StageLocation, input *bufio.Reader, size int64) error {
 	if c.PresignedURLDisabled {
 		return c.UploadToStageByAPI(ctx, stage, input)
@@ -802,8 +831,18 @@ func (c *APIClient) UploadToStage(ctx context.Context, stage *StageLocation, inp
 }
 
 func (c *APIClient) GetPresignedURL(ctx context.Context, stage *StageLocation) (*PresignedResponse, error) {
-	presignUploadSQL := fmt.Sprintf("PRESIGN UPLOAD %s", stage)
-	resp, err := c.QuerySync(ctx, presignUploadSQL)
+	return c.presign(ctx, "UPLOAD", stage)
+}
+
+// GetPresignedDownloadURL returns a URL the file at stage can be downloaded
+// from without going through Databend.
+func (c *APIClient) GetPresignedDownloadURL(ctx context.Context, stage *StageLocation) (*PresignedResponse, error) {
+	return c.presign(ctx, "DOWNLOAD", stage)
+}
+
+func (c *APIClient) presign(ctx context.Context, op string, stage *StageLocation) (*PresignedResponse, error) {
+	presignSQL := fmt.Sprintf("PRESIGN %s %s", op, stage)
+	resp, err := c.QuerySync(ctx, presignSQL)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to query presign url")
 	}
@@ -832,19 +871,29 @@ func (c *APIClient) GetPresignedURL(ctx context.Context, stage *StageLocation) (
 	return result, nil
 }
 
+// newPresignedRequest returns a request to a presigned URL, with the headers
+// it was signed with.
+func newPresignedRequest(ctx context.Context, presigned *PresignedResponse, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, presigned.Method, presigned.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range presigned.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
 func (c *APIClient) UploadToStageByPresignURL(ctx context.Context, stage *StageLocation, input *bufio.Reader, size int64) error {
 	presigned, err := c.GetPresignedURL(ctx, stage)
 	if err != nil {
 		return errors.Wrap(err, "failed to get presigned url")
 	}
 
-	req, err := http.NewRequest("PUT", presigned.URL, input)
+	req, err := newPresignedRequest(ctx, presigned, input)
 	if err != nil {
 		return err
 	}
-	for k, v := range presigned.Headers {
-		req.Header.Set(k, v)
-	}
 	req.ContentLength = size
 	// TODO: configurable timeout
 	httpClient := &http.Client{
@@ -868,21 +917,22 @@ func (c *APIClient) UploadToStageByPresignURL(ctx context.Context, stage *StageL
 }
 
 func (c *APIClient) UploadToStageByAPI(ctx context.Context, stage *StageLocation, input *bufio.Reader) error {
-	body := new(bytes.Buffer)
-	writer := multipart.NewWriter(body)
-	part, err := writer.CreateFormFile("upload", stage.Path)
-	if err != nil {
-		return errors.Wrap(err, "failed to create multipart writer form file")
-	}
-	// TODO: do async upload
-	_, err = io.Copy(part, input)
-	if err != nil {
-		return errors.Wrap(err, "failed to copy file to multipart writer form file")
-	}
-	err = writer.Close()
-	if err != nil {
-		return errors.Wrap(err, "failed to close multipart writer")
-	}
+	// stream the multipart body instead of buffering the whole file
+	body, pw := io.Pipe()
+	defer body.Close()
+	writer := multipart.NewWriter(pw)
+	go func() {
+		part, err := writer.CreateFormFile("upload", stage.Path)
+		if err != nil {
+			_ = pw.CloseWithError(errors.Wrap(err, "failed to create multipart writer form file"))
+			return
+		}
+		if _, err = io.Copy(part, input); err != nil {
+			_ = pw.CloseWithError(errors.Wrap(err, "failed to copy file to multipart writer form file"))
+			return
+		}
+		_ = pw.CloseWithError(errors.Wrap(writer.Close(), "failed to close multipart writer"))
+	}()
 
 	path := "/v1/upload_to_stage"
 	url := c.makeURL(path)
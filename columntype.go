@@ -6,8 +6,12 @@ import (
 	"reflect"
 	"strconv"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+var reflectTypeUUID = reflect.TypeOf(uuid.UUID{})
+
 type ColumnType interface {
 	Desc() *TypeDesc
 	DatabaseTypeName() string
@@ -253,7 +257,10 @@ func (c *decimalColumnType) Desc() *TypeDesc {
 	return &TypeDesc{Name: "Decimal", Nullable: bool(c.isNullable), Args: []*TypeDesc{{Name: strconv.Itoa(int(c.precision))}, {Name: strconv.Itoa(int(c.scale))}}}
 }
 
-func (*decimalColumnType) Parse(s string) (driver.Value, error) {
+func (c *decimalColumnType) Parse(s string) (driver.Value, error) {
+	if c.checkNull(s) {
+		return nil, nil
+	}
 	return s, nil
 }
 
@@ -262,7 +269,7 @@ func (c *decimalColumnType) PrecisionScale() (int64, int64, bool) {
 }
 
 func (*decimalColumnType) ScanType() reflect.Type {
-	return reflectTypeString
+	return reflectTypeDecimal
 }
 
 func NewColumnType(dbType string, opts *ColumnTypeOptions) (ColumnType, error) {
@@ -274,6 +281,9 @@ func NewColumnType(dbType string, opts *ColumnTypeOptions) (ColumnType, error) {
 		return nil, err
 	}
 	desc = desc.Normalize()
+	if factory := opts.types.columnType(desc.Name); factory != nil {
+		return factory(desc, opts)
+	}
 	nullable := isNullable(desc.Nullable)
 	parseNull := opts.formatNullAsStr
 	switch desc.Name {
@@ -297,10 +307,18 @@ func NewColumnType(dbType string, opts *ColumnTypeOptions) (ColumnType, error) {
 		return &simpleColumnType{dbType: nullable.wrapName(desc.Name), scanType: reflectTypeUInt32, nullable: desc.Nullable, parseNull: parseNull}, nil
 	case "UInt64":
 		return &simpleColumnType{dbType: nullable.wrapName(desc.Name), scanType: reflectTypeUInt64, nullable: desc.Nullable, parseNull: parseNull}, nil
+	case "Int128", "Int256", "UInt128", "UInt256":
+		return &bigIntColumnType{dbType: desc.Name, isNullable: nullable}, nil
 	case "Float32":
 		return &simpleColumnType{dbType: nullable.wrapName(desc.Name), scanType: reflectTypeFloat32, nullable: desc.Nullable, parseNull: parseNull}, nil
 	case "Float64":
 		return &simpleColumnType{dbType: nullable.wrapName(desc.Name), scanType: reflectTypeFloat64, nullable: desc.Nullable, parseNull: parseNull}, nil
+	case "UUID":
+		return &simpleColumnType{dbType: nullable.wrapName(desc.Name), scanType: reflectTypeUUID, nullable: desc.Nullable, parseNull: parseNull}, nil
+	case "IPv4", "IPv6":
+		return &simpleColumnType{dbType: nullable.wrapName(desc.Name), scanType: reflectTypeIPAddr, nullable: desc.Nullable, parseNull: parseNull}, nil
+	case "Enum", "Enum8", "Enum16":
+		return &enumColumnType{desc: desc, isNullable: nullable}, nil
 	case "Timestamp":
 		return &timestampColumnType{isNullable: nullable, tz: opts.timezone}, nil
 	case "Timestamp_Tz":
@@ -309,29 +327,59 @@ func NewColumnType(dbType string, opts *ColumnTypeOptions) (ColumnType, error) {
 		return &dateColumnType{isNullable: nullable}, nil
 	case "Binary":
 		return &binaryColumnType{format: opts.binaryOutputFormat, mode: opts.httpJSONResultMode, isNullable: nullable}, nil
+	case "Interval":
+		return &intervalColumnType{isNullable: nullable}, nil
+	case "Bitmap":
+		return &bitmapColumnType{isNullable: nullable}, nil
+	case "Vector":
+		dim, err := vectorDimension(desc)
+		if err != nil {
+			return nil, err
+		}
+		return &vectorColumnType{dim: dim, isNullable: nullable}, nil
+	case "Variant", "VariantObject", "VariantArray":
+		return &variantColumnType{dbType: desc.Name, isNullable: nullable}, nil
 	case "Geometry", "Geography":
 		return &geoColumnType{dbType: desc.Name, format: opts.geometryOutputFormat, isNullable: nullable}, nil
 	case "Decimal":
-		precision, err := strconv.ParseInt(desc.Args[0].Name, 10, 64)
+		precision, scale, err := decimalPrecisionScale(desc)
 		if err != nil {
-			return nil, fmt.Errorf("malformed precision specified for Decimal: %v", err)
+			return nil, err
 		}
-		scale, err := strconv.ParseInt(desc.Args[1].Name, 10, 64)
+		return &decimalColumnType{isNullable: nullable, precision: precision, scale: scale}, nil
+	case "Array", "Map", "Tuple", "EmptyArray", "EmptyMap":
+		typ, err := newNestedType(desc, opts)
 		if err != nil {
-			return nil, fmt.Errorf("malformed scale specified for Decimal: %v", err)
+			return nil, err
 		}
-		return &decimalColumnType{isNullable: nullable, precision: precision, scale: scale}, nil
+		return &nestedColumnType{desc: desc, typ: typ, isNullable: nullable}, nil
 	default:
 		return unknownColumnType{dbType: dbType, desc: desc}, nil
 	}
 }
 
+func decimalPrecisionScale(desc *TypeDesc) (int64, int64, error) {
+	if len(desc.Args) != 2 {
+		return 0, 0, fmt.Errorf("precision and scale not specified for Decimal")
+	}
+	precision, err := strconv.ParseInt(desc.Args[0].Name, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed precision specified for Decimal: %v", err)
+	}
+	scale, err := strconv.ParseInt(desc.Args[1].Name, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("malformed scale specified for Decimal: %v", err)
+	}
+	return precision, scale, nil
+}
+
 type ColumnTypeOptions struct {
 	formatNullAsStr      bool
 	timezone             *time.Location
 	geometryOutputFormat geoOutputFormat
 	binaryOutputFormat   binaryOutputFormat
 	httpJSONResultMode   httpJSONResultMode
+	types                *TypeRegistry
 }
 
 func defaultColumnTypeOptions() *ColumnTypeOptions {
@@ -363,3 +411,14 @@ func (opt *ColumnTypeOptions) SetBinaryOutputFormat(v string) {
 func (opt *ColumnTypeOptions) SetHTTPJSONResultMode(v string) {
 	opt.httpJSONResultMode = parseHTTPJSONResultMode(v)
 }
+
+// SetTypeRegistry makes NewColumnType use the types registered in r ahead of
+// the global ones.
+func (opt *ColumnTypeOptions) SetTypeRegistry(r *TypeRegistry) {
+	opt.types = r
+}
+
+// Timezone returns the location timestamps are converted to.
+func (opt *ColumnTypeOptions) Timezone() *time.Location {
+	return opt.timezone
+}
@@ -5,11 +5,16 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
+	"math"
+	"net/netip"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+
+	"github.com/datafuselabs/databend-go/geo"
 )
 
 func TestColumnType(t *testing.T) {
@@ -18,6 +23,8 @@ func TestColumnType(t *testing.T) {
 		input    string
 		want     any
 		settings *Settings
+		// scanType is the ScanType of types parsed as text, if not that of want
+		scanType reflect.Type
 	}{
 		{typeDesc: "String", input: "123", want: "123"},
 		{typeDesc: "Nullable(String)", input: "123", want: "123"},
@@ -34,15 +41,19 @@ func TestColumnType(t *testing.T) {
 		{typeDesc: "Float64", input: "123.0", want: float64(123)},
 		{typeDesc: "Timestamp", input: "2025-01-16 02:01:26.739219", want: time.Date(2025, 1, 16, 2, 1, 26, 739219000, time.UTC)},
 		{typeDesc: "Date", input: "2025-01-16", want: time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
-		{typeDesc: "Decimal(10, 2)", input: "123.45", want: "123.45"},
+		{typeDesc: "Decimal(10, 2)", input: "123.45", want: "123.45", scanType: reflectTypeDecimal},
 		{typeDesc: "Binary", input: "616263", want: []byte("abc")},
 		{typeDesc: "Binary", input: "YWJj", want: []byte("abc"), settings: &Settings{BinaryOutputFormat: "BASE64", HTTPJSONResultMode: "display"}},
 		{typeDesc: "Geometry", input: "01010000000000000000004E400000000000804240", want: []byte{1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 78, 64, 0, 0, 0, 0, 0, 128, 66, 64}, settings: &Settings{GeometryOutputFormat: "WKB"}},
 		{typeDesc: "Geography", input: "POINT(60 37)", want: "POINT(60 37)", settings: &Settings{GeometryOutputFormat: "WKT"}},
+		{typeDesc: "UUID", input: "9d4f6a3e-8c1b-4f0e-a3a1-0c2b5e8f7d61", want: uuid.MustParse("9d4f6a3e-8c1b-4f0e-a3a1-0c2b5e8f7d61")},
+		{typeDesc: "IPv4", input: "10.0.0.1", want: IPAddr{netip.MustParseAddr("10.0.0.1")}},
+		{typeDesc: "Nullable(IPv6)", input: "::1", want: IPAddr{netip.MustParseAddr("::1")}},
+		{typeDesc: "Enum8('a' = 1, 'b, c' = 2)", input: "b, c", want: "b, c"},
 	}
 	for _, tc := range tests {
 		t.Run(fmt.Sprintf("%s::%s", tc.input, tc.typeDesc), func(t *testing.T) {
-			opts, err := queryResponseColumnTypeOptions(tc.settings)
+			opts, err := queryResponseColumnTypeOptions(tc.settings, nil)
 			require.NoError(t, err)
 
 			colType, err := NewColumnType(tc.typeDesc, opts)
@@ -52,7 +63,9 @@ func TestColumnType(t *testing.T) {
 			require.NoError(t, err)
 			require.True(t, driver.IsValue(v))
 
-			if tc.want != nil {
+			if tc.scanType != nil {
+				require.Equal(t, tc.scanType, colType.ScanType())
+			} else if tc.want != nil {
 				require.Equal(t, reflect.TypeOf(tc.want), colType.ScanType())
 			}
 
@@ -65,10 +78,189 @@ func TestColumnType(t *testing.T) {
 			require.Equal(t, desc, desc2)
 
 			runScan(t, tc.typeDesc, tc.input, tc.want, tc.settings)
+			if tc.scanType != nil {
+				// text is also scanned into the typed value
+				runScanInto(t, tc.typeDesc, tc.input, tc.scanType, tc.settings)
+			}
 		})
 	}
 }
 
+func TestNestedColumnType(t *testing.T) {
+	tests := []struct {
+		typeDesc string
+		input    string
+		want     any
+	}{
+		{typeDesc: "Array(Int32)", input: "[1,2,3]", want: []int32{1, 2, 3}},
+		{typeDesc: "Array(UInt64)", input: "[18446744073709551615]", want: []uint64{18446744073709551615}},
+		{typeDesc: "Array(Nullable(Int64))", input: "[1,NULL]", want: []*int64{ptrTo(int64(1)), nil}},
+		{typeDesc: "Array(String)", input: `['a', 'b\'c', 'NULL', '[x]']`, want: []string{"a", "b'c", "NULL", "[x]"}},
+		{typeDesc: "Array(String NULL)", input: "['a',NULL]", want: []*string{ptrTo("a"), nil}},
+		{typeDesc: "Array(Array(UInt8))", input: "[[1],[],[2,3]]", want: [][]uint8{{1}, {}, {2, 3}}},
+		{typeDesc: "Array(Date)", input: "['2025-01-16']", want: []time.Time{time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)}},
+		{typeDesc: "Array(Float64)", input: "[1.5,NaN]", want: []float64{1.5, math.NaN()}},
+		{typeDesc: "Map(String, Int64)", input: "{'a':1,'b':2}", want: map[string]int64{"a": 1, "b": 2}},
+		{typeDesc: "Map(Int32, Array(String))", input: "{1:['x'],2:[]}", want: map[int32][]string{1: {"x"}, 2: {}}},
+		{typeDesc: "Tuple(Int32, String NULL, Array(Float64))", input: "(1,NULL,[1.5])", want: []any{int32(1), nil, []float64{1.5}}},
+		{typeDesc: "Tuple(Boolean, Nullable(Int8))", input: "(true,5)", want: []any{true, int8(5)}},
+		{typeDesc: "Array(Decimal(10, 2))", input: "[1.50,-0.01]", want: []string{"1.50", "-0.01"}},
+		{typeDesc: "Array(UUID)", input: "['9d4f6a3e-8c1b-4f0e-a3a1-0c2b5e8f7d61']", want: []uuid.UUID{uuid.MustParse("9d4f6a3e-8c1b-4f0e-a3a1-0c2b5e8f7d61")}},
+		{typeDesc: "Array(IPv6)", input: "['::1','10.0.0.1']", want: []IPAddr{{netip.MustParseAddr("::1")}, {netip.MustParseAddr("10.0.0.1")}}},
+		{typeDesc: "EmptyArray", input: "[]", want: []any{}},
+		{typeDesc: "EmptyMap", input: "{}", want: map[string]any{}},
+	}
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%s::%s", tc.input, tc.typeDesc), func(t *testing.T) {
+			colType, err := NewColumnType(tc.typeDesc, nil)
+			require.NoError(t, err)
+			require.Equal(t, reflect.TypeOf(tc.want), colType.ScanType())
+
+			desc, err := ParseTypeDesc(tc.typeDesc)
+			require.NoError(t, err)
+			desc2, err := ParseTypeDesc(colType.DatabaseTypeName())
+			require.NoError(t, err)
+			require.Equal(t, desc.Normalize(), desc2)
+
+			v, err := colType.Parse(tc.input)
+			require.NoError(t, err)
+			if f, ok := v.([]float64); ok && len(f) == 2 {
+				require.Equal(t, 1.5, f[0])
+				require.True(t, math.IsNaN(f[1]))
+				return
+			}
+			require.Equal(t, tc.want, v)
+
+			runScan(t, tc.typeDesc, tc.input, tc.want, nil)
+		})
+	}
+}
+
+func TestNestedColumnTypeNull(t *testing.T) {
+	colType, err := NewColumnType("Nullable(Array(Int32))", nil)
+	require.NoError(t, err)
+	nullable, ok := colType.Nullable()
+	require.True(t, ok)
+	require.True(t, nullable)
+
+	v, err := colType.Parse("NULL")
+	require.NoError(t, err)
+	require.Nil(t, v)
+}
+
+func TestNestedColumnTypeInvalid(t *testing.T) {
+	tests := []struct {
+		typeDesc string
+		input    string
+	}{
+		{typeDesc: "Array(Int32)", input: "[1,2"},
+		{typeDesc: "Array(Int32)", input: "[1,2] x"},
+		{typeDesc: "Array(Int8)", input: "[300]"},
+		{typeDesc: "Array(Int32)", input: "[1,NULL]"},
+		{typeDesc: "Array(String)", input: "['a"},
+		{typeDesc: "Map(String, Int32)", input: "{'a' 1}"},
+		{typeDesc: "Tuple(Int32, Int32)", input: "(1)"},
+	}
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%s::%s", tc.input, tc.typeDesc), func(t *testing.T) {
+			colType, err := NewColumnType(tc.typeDesc, nil)
+			require.NoError(t, err)
+			_, err = colType.Parse(tc.input)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestNestedScanIntoAny(t *testing.T) {
+	db := sql.OpenDB(&fakeConnector{
+		resp: &QueryResponse{
+			Schema: &[]DataField{{Name: "x", Type: "Map(String, Array(Int16))"}},
+			Data:   [][]*string{{strPtr("{'k':[1,2]}")}},
+		},
+	})
+
+	rows, err := db.Query("x")
+	require.NoError(t, err)
+	require.True(t, rows.Next())
+
+	var out any
+	require.NoError(t, rows.Scan(&out))
+	require.Equal(t, map[string][]int16{"k": {1, 2}}, out)
+}
+
+func TestGeoScanIntoShape(t *testing.T) {
+	tests := []struct {
+		format string
+		input  string
+	}{
+		{format: "WKB", input: "0101000000000000000000F03F0000000000000040"},
+		{format: "EWKB", input: "0101000020E6100000000000000000F03F0000000000000040"},
+		{format: "WKT", input: "POINT(1 2)"},
+		{format: "EWKT", input: "SRID=4326;POINT(1 2)"},
+		{format: "GEOJSON", input: `{"type": "Point", "coordinates": [1.0,2.0]}`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.format, func(t *testing.T) {
+			db := sql.OpenDB(&fakeConnector{
+				resp: &QueryResponse{
+					Settings: &Settings{GeometryOutputFormat: tc.format},
+					Schema:   &[]DataField{{Name: "x", Type: "Nullable(Geometry)"}},
+					Data:     [][]*string{{strPtr(tc.input)}, {nil}},
+				},
+			})
+
+			rows, err := db.Query("x")
+			require.NoError(t, err)
+			defer rows.Close()
+
+			var shape geo.Shape
+			require.True(t, rows.Next())
+			require.NoError(t, rows.Scan(&shape))
+			require.Equal(t, geo.Point{X: 1, Y: 2}, shape.Geometry)
+
+			require.True(t, rows.Next())
+			require.NoError(t, rows.Scan(&shape))
+			require.Nil(t, shape.Geometry)
+		})
+	}
+}
+
+func TestTypedStringColumnsScanIntoString(t *testing.T) {
+	for typeDesc, input := range map[string]string{
+		"UUID":               "9d4f6a3e-8c1b-4f0e-a3a1-0c2b5e8f7d61",
+		"IPv4":               "10.0.0.1",
+		"IPv6":               "2001:db8::1",
+		"Enum16('x' = 1000)": "x",
+	} {
+		db := sql.OpenDB(&fakeConnector{
+			resp: &QueryResponse{
+				Schema: &[]DataField{{Name: "x", Type: typeDesc}},
+				Data:   [][]*string{{strPtr(input)}},
+			},
+		})
+		var s string
+		require.NoError(t, db.QueryRow("x").Scan(&s), typeDesc)
+		require.Equal(t, input, s)
+	}
+}
+
+func TestEnumColumnTypeDesc(t *testing.T) {
+	colType, err := NewColumnType(`Nullable(Enum8('a' = 1, 'it\'s' = 2))`, nil)
+	require.NoError(t, err)
+	require.Equal(t, `Enum8('a' = 1, 'it\'s' = 2) NULL`, colType.DatabaseTypeName())
+
+	desc := colType.Desc()
+	require.Len(t, desc.Args, 2)
+	require.Equal(t, "a", desc.Args[0].Name)
+	require.Equal(t, "1", desc.Args[0].Args[0].Name)
+	require.Equal(t, "it's", desc.Args[1].Name)
+	require.Equal(t, "2", desc.Args[1].Args[0].Name)
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
+
 func runScan(t *testing.T, desc string, input string, want any, settings *Settings) {
 	db := sql.OpenDB(&fakeConnector{
 		resp: &QueryResponse{
@@ -86,12 +278,33 @@ func runScan(t *testing.T, desc string, input string, want any, settings *Settin
 	types, err := rows.ColumnTypes()
 	require.NoError(t, err)
 
-	a := reflect.New(types[0].ScanType()).Interface()
+	scanType := types[0].ScanType()
+	if want != nil && reflect.TypeOf(want) != scanType {
+		// columns parsed as text keep scanning into strings
+		scanType = reflect.TypeOf(want)
+	}
+	a := reflect.New(scanType).Interface()
 	err = rows.Scan(a)
 	require.NoError(t, err)
 	require.Equal(t, want, reflect.ValueOf(a).Elem().Interface())
 }
 
+func runScanInto(t *testing.T, desc string, input string, scanType reflect.Type, settings *Settings) {
+	db := sql.OpenDB(&fakeConnector{
+		resp: &QueryResponse{
+			Settings: settings,
+			Schema:   &[]DataField{{Name: "x", Type: desc}},
+			Data:     [][]*string{{&input}},
+		},
+	})
+
+	rows, err := db.Query("x")
+	require.NoError(t, err)
+	defer rows.Close()
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(reflect.New(scanType).Interface()))
+}
+
 func TestBinaryScanIntoStringUsesDatabaseSQLDefaultConversion(t *testing.T) {
 	db := sql.OpenDB(&fakeConnector{
 		resp: &QueryResponse{
@@ -33,6 +33,7 @@ type DatabendConn struct {
 
 func (dc *DatabendConn) columnTypeOptions(settings *Settings, location *time.Location) *ColumnTypeOptions {
 	opts := defaultColumnTypeOptions()
+	opts.SetTypeRegistry(dc.typeRegistry())
 	if location != nil {
 		opts.SetTimezone(location)
 	} else if dc.cfg.Location != nil {
@@ -57,9 +58,23 @@ func (dc *DatabendConn) columnTypeOptions(settings *Settings, location *time.Loc
 	return opts
 }
 
-func (dc *DatabendConn) exec(ctx context.Context, query string, placeholders *[]int, args []driver.Value) (driver.Result, error) {
+func (dc *DatabendConn) typeRegistry() *TypeRegistry {
+	if dc.cfg == nil {
+		return nil
+	}
+	return dc.cfg.Types
+}
+
+func (dc *DatabendConn) encoder() encoder {
+	if dc.cfg != nil && dc.cfg.RawBytesParams {
+		return &textEncoder{rawBytes: true}
+	}
+	return textEncode
+}
+
+func (dc *DatabendConn) exec(ctx context.Context, query string, params []placeholder, args []driver.NamedValue) (driver.Result, error) {
 	ctx = checkQueryID(ctx)
-	query, err := buildQuery(query, args, placeholders)
+	query, err := buildQuery(dc.encoder(), query, args, params)
 	if err != nil {
 		return emptyResult, err
 	}
@@ -76,9 +91,9 @@ func (dc *DatabendConn) exec(ctx context.Context, query string, placeholders *[]
 	return newDatabendResult(affectedRows, 0), nil
 }
 
-func (dc *DatabendConn) query(ctx context.Context, query string, placeholders *[]int, args []driver.Value) (rows driver.Rows, err error) {
+func (dc *DatabendConn) query(ctx context.Context, query string, params []placeholder, args []driver.NamedValue) (rows driver.Rows, err error) {
 	ctx = checkQueryID(ctx)
-	query, err = buildQuery(query, args, placeholders)
+	query, err = buildQuery(dc.encoder(), query, args, params)
 	if err != nil {
 		return nil, err
 	}
@@ -142,11 +157,10 @@ func (dc *DatabendConn) prepare(_ context.Context, query string) (*databendStmt,
 	if dc.rest == nil {
 		return nil, driver.ErrBadConn
 	}
-	placeholders := placeholders(query)
 	stmt := &databendStmt{
-		dc:           dc,
-		query:        query,
-		placeholders: placeholders,
+		dc:     dc,
+		query:  query,
+		params: placeholders(query),
 	}
 	return stmt, nil
 }
@@ -196,19 +210,23 @@ func (dc *DatabendConn) Close() error {
 }
 
 func (dc *DatabendConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
-	values := make([]driver.Value, len(args))
-	for i, arg := range args {
-		values[i] = arg.Value
-	}
-	return dc.exec(ctx, query, nil, values)
+	return dc.exec(ctx, query, nil, args)
 }
 
 func (dc *DatabendConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
-	values := make([]driver.Value, len(args))
-	for i, arg := range args {
-		values[i] = arg.Value
+	return dc.query(ctx, query, nil, args)
+}
+
+var _ driver.NamedValueChecker = (*DatabendConn)(nil)
+
+// CheckNamedValue lets arguments reach the query encoder unconverted, so slices,
+// maps, structs and types such as uuid.UUID or *big.Int are bound as literals
+// instead of being rejected or flattened by database/sql.
+func (dc *DatabendConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if !encodable(nv.Value) {
+		return driver.ErrSkip
 	}
-	return dc.query(ctx, query, nil, values)
+	return nil
 }
 
 func (dc *DatabendConn) ExecBatch(ctx context.Context, query string, rows [][]driver.Value) (driver.Result, error) {
@@ -222,11 +240,12 @@ func (dc *DatabendConn) ExecBatch(ctx context.Context, query string, rows [][]dr
 			return nil, err
 		}
 	}
-	err = batch.BatchInsert()
+	result, err := batch.BatchInsertWithResult()
 	if err != nil {
+		// not a nil *BatchResult in a non-nil driver.Result
 		return nil, err
 	}
-	return newDatabendResult(int64(len(rows)), 0), nil
+	return result, nil
 }
 
 // checkQueryID checks if query_id exists in context, if not, generate a new one
@@ -2,12 +2,16 @@ package godatabend
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"sync"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -119,3 +123,65 @@ func TestBuildDatabendConnSkipsLoginWhenDisabled(t *testing.T) {
 	defer mu.Unlock()
 	assert.Equal(t, 0, loginCount)
 }
+
+// newSQLRecorderServer starts a server that accepts every query with an empty
+// result and records the SQL it received.
+func newSQLRecorderServer(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/query":
+			var req QueryRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			mu.Lock()
+			queries = append(queries, req.SQL)
+			mu.Unlock()
+			w.Header().Set(contentType, jsonMediaType)
+			require.NoError(t, json.NewEncoder(w).Encode(QueryResponse{ID: "q", State: "Succeeded", Schema: &[]DataField{}}))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), queries...)
+	}
+}
+
+func TestCheckNamedValuePassesRichTypes(t *testing.T) {
+	server, recorded := newSQLRecorderServer(t)
+	db := sql.OpenDB(testHTTPConfig(t, server.URL))
+	defer db.Close()
+
+	id := uuid.MustParse("9d4f6a3e-8c1b-4f0e-a3a1-0c2b5e8f7d61")
+	_, err := db.Exec("INSERT INTO t VALUES (?, ?, ?, ?, ?)",
+		uint64(1)<<63,
+		[]string{"a", "b"},
+		id,
+		map[string]int{"y": 2, "x": 1},
+		sql.Null[int64]{},
+	)
+	require.NoError(t, err)
+	_, err = db.Exec("SELECT :n", sql.Named("n", big.NewInt(7)))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"INSERT INTO t VALUES (9223372036854775808, ['a','b'], '9d4f6a3e-8c1b-4f0e-a3a1-0c2b5e8f7d61', {'x':1,'y':2}, NULL)",
+		"SELECT 7",
+	}, recorded())
+}
+
+func TestCheckNamedValueSkipsUnsupportedTypes(t *testing.T) {
+	dc := &DatabendConn{}
+	assert.NoError(t, dc.CheckNamedValue(&driver.NamedValue{Value: []int{1}}))
+	assert.NoError(t, dc.CheckNamedValue(&driver.NamedValue{Value: nil}))
+	assert.Equal(t, driver.ErrSkip, dc.CheckNamedValue(&driver.NamedValue{Value: make(chan int)}))
+}
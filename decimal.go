@@ -0,0 +1,199 @@
+package godatabend
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var reflectTypeDecimal = reflect.TypeOf(Decimal{})
+
+// Decimal is an exact decimal number of arbitrary precision, the value of
+// Decimal columns. It is stored as an unscaled integer and a scale, so that
+// Decimal(38, 2) 123.40 has the unscaled value 12340 and scale 2.
+//
+// The zero value is 0. Decimal is immutable and safe to copy.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int32
+}
+
+// NewDecimal returns unscaled * 10^-scale.
+func NewDecimal(unscaled *big.Int, scale int32) Decimal {
+	v := new(big.Int)
+	if unscaled != nil {
+		v.Set(unscaled)
+	}
+	if scale < 0 {
+		v.Mul(v, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{unscaled: v, scale: scale}
+}
+
+// NewDecimalFromInt64 returns unscaled * 10^-scale.
+func NewDecimalFromInt64(unscaled int64, scale int32) Decimal {
+	return NewDecimal(big.NewInt(unscaled), scale)
+}
+
+// maxDecimalExponent bounds the exponent accepted by ParseDecimal, far beyond
+// the 76 digits of the widest Decimal column.
+const maxDecimalExponent = 1000
+
+// ParseDecimal parses a decimal number such as "-123.45" or "1.5e3", keeping
+// every digit. The scale is the number of digits after the decimal point.
+func ParseDecimal(s string) (Decimal, error) {
+	text := s
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		exp, err := strconv.ParseInt(text[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		if exp > maxDecimalExponent || exp < -maxDecimalExponent {
+			return Decimal{}, fmt.Errorf("decimal exponent out of range %q", s)
+		}
+		d, err := ParseDecimal(text[:i])
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		return NewDecimal(d.unscaled, d.scale-int32(exp)), nil
+	}
+
+	sign := ""
+	if text != "" && (text[0] == '-' || text[0] == '+') {
+		sign, text = text[:1], text[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(text, ".")
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	v, ok := new(big.Int).SetString(sign+intPart+fracPart, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	return Decimal{unscaled: v, scale: int32(len(fracPart))}, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) unscaledValue() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// Unscaled returns the unscaled integer value.
+func (d Decimal) Unscaled() *big.Int {
+	return new(big.Int).Set(d.unscaledValue())
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Precision returns the number of digits, counting a leading zero when there
+// are only digits after the decimal point.
+func (d Decimal) Precision() int32 {
+	digits := int32(len(new(big.Int).Abs(d.unscaledValue()).String()))
+	if digits <= d.scale {
+		return d.scale + 1
+	}
+	return digits
+}
+
+// Sign returns -1, 0 or 1 depending on the sign of d.
+func (d Decimal) Sign() int {
+	return d.unscaledValue().Sign()
+}
+
+// Cmp compares d and other numerically, regardless of their scales.
+func (d Decimal) Cmp(other Decimal) int {
+	return d.Rat().Cmp(other.Rat())
+}
+
+// Rat returns d as an exact fraction.
+func (d Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.unscaledValue(), pow10(d.scale))
+}
+
+// BigInt returns the integer part of d, truncated towards zero.
+func (d Decimal) BigInt() *big.Int {
+	return new(big.Int).Quo(d.unscaledValue(), pow10(d.scale))
+}
+
+// Float64 returns the float64 nearest to d, and whether it is exact.
+func (d Decimal) Float64() (float64, bool) {
+	return d.Rat().Float64()
+}
+
+// String formats d with exactly Scale digits after the decimal point.
+func (d Decimal) String() string {
+	v := d.unscaledValue()
+	digits := new(big.Int).Abs(v).String()
+	if d.scale > 0 {
+		if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		point := len(digits) - int(d.scale)
+		digits = digits[:point] + "." + digits[point:]
+	}
+	if v.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// Value implements driver.Valuer
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan implements sql.Scanner
+func (d *Decimal) Scan(src any) error {
+	switch v := src.(type) {
+	case Decimal:
+		*d = v
+	case string:
+		return d.scanText(v)
+	case []byte:
+		return d.scanText(string(v))
+	case int64:
+		*d = NewDecimalFromInt64(v, 0)
+	case uint64:
+		*d = NewDecimal(new(big.Int).SetUint64(v), 0)
+	case float64:
+		return d.scanText(strconv.FormatFloat(v, 'f', -1, 64))
+	case *big.Int:
+		*d = NewDecimal(v, 0)
+	case nil:
+		return fmt.Errorf("cannot scan NULL into Decimal, use sql.Null[Decimal]")
+	default:
+		return fmt.Errorf("cannot scan %T into Decimal", src)
+	}
+	return nil
+}
+
+func (d *Decimal) scanText(s string) error {
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
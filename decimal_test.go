@@ -0,0 +1,161 @@
+package godatabend
+
+import (
+	"database/sql"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		unscaled string
+		scale    int32
+		str      string
+	}{
+		{input: "0", unscaled: "0", scale: 0, str: "0"},
+		{input: "123.45", unscaled: "12345", scale: 2, str: "123.45"},
+		{input: "-0.001", unscaled: "-1", scale: 3, str: "-0.001"},
+		{input: "+1.50", unscaled: "150", scale: 2, str: "1.50"},
+		{input: ".5", unscaled: "5", scale: 1, str: "0.5"},
+		{input: "1.5e3", unscaled: "1500", scale: 0, str: "1500"},
+		{input: "15E-3", unscaled: "15", scale: 3, str: "0.015"},
+		{
+			input:    "-12345678901234567890123456789012345678901234567890.123456789",
+			unscaled: "-12345678901234567890123456789012345678901234567890123456789",
+			scale:    9,
+			str:      "-12345678901234567890123456789012345678901234567890.123456789",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			d, err := ParseDecimal(tc.input)
+			require.NoError(t, err)
+			assert.Equal(t, tc.unscaled, d.Unscaled().String())
+			assert.Equal(t, tc.scale, d.Scale())
+			assert.Equal(t, tc.str, d.String())
+		})
+	}
+
+	for _, input := range []string{"", "-", ".", "1.2.3", "1e", "abc", "1,5", "NaN", "1e999999999", "1e-999999999"} {
+		_, err := ParseDecimal(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestDecimalConversions(t *testing.T) {
+	d, err := ParseDecimal("-12.75")
+	require.NoError(t, err)
+
+	assert.Equal(t, big.NewRat(-51, 4), d.Rat())
+	assert.Equal(t, big.NewInt(-12), d.BigInt())
+	f, exact := d.Float64()
+	assert.Equal(t, -12.75, f)
+	assert.True(t, exact)
+	assert.Equal(t, -1, d.Sign())
+	assert.Equal(t, int32(4), d.Precision())
+
+	_, exact = NewDecimalFromInt64(1, 1).Float64()
+	assert.False(t, exact)
+
+	assert.Equal(t, 0, NewDecimalFromInt64(150, 2).Cmp(NewDecimalFromInt64(15, 1)))
+	assert.Equal(t, "0", Decimal{}.String())
+	assert.Equal(t, "0.0012", NewDecimalFromInt64(12, 4).String())
+	assert.Equal(t, "1200", NewDecimalFromInt64(12, -2).String())
+}
+
+func TestDecimalScan(t *testing.T) {
+	var d Decimal
+	require.NoError(t, d.Scan("1.10"))
+	assert.Equal(t, "1.10", d.String())
+	require.NoError(t, d.Scan([]byte("-2")))
+	assert.Equal(t, "-2", d.String())
+	require.NoError(t, d.Scan(int64(42)))
+	assert.Equal(t, "42", d.String())
+	require.NoError(t, d.Scan(0.25))
+	assert.Equal(t, "0.25", d.String())
+	require.NoError(t, d.Scan(NewDecimalFromInt64(5, 1)))
+	assert.Equal(t, "0.5", d.String())
+	assert.Error(t, d.Scan(nil))
+	assert.Error(t, d.Scan(true))
+}
+
+func TestDecimalColumnType(t *testing.T) {
+	colType, err := NewColumnType("Nullable(Decimal(10, 2))", nil)
+	require.NoError(t, err)
+	assert.Equal(t, reflectTypeDecimal, colType.ScanType())
+	assert.Equal(t, "Decimal(10, 2) NULL", colType.DatabaseTypeName())
+	precision, scale, ok := colType.PrecisionScale()
+	assert.True(t, ok)
+	assert.Equal(t, int64(10), precision)
+	assert.Equal(t, int64(2), scale)
+
+	v, err := colType.Parse("123.40")
+	require.NoError(t, err)
+	assert.Equal(t, "123.40", v)
+	v, err = colType.Parse("NULL")
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	db := sql.OpenDB(&fakeConnector{
+		resp: &QueryResponse{
+			Schema: &[]DataField{{Name: "x", Type: "Decimal(38, 10)"}, {Name: "y", Type: "Decimal(38, 10)"}},
+			Data:   [][]*string{{strPtr("12345678901234567890.0123456789"), strPtr("0.5000000000")}},
+		},
+	})
+	rows, err := db.Query("x")
+	require.NoError(t, err)
+	require.True(t, rows.Next())
+
+	var (
+		exact Decimal
+		f     float64
+		text  string
+	)
+	require.NoError(t, rows.Scan(&exact, &f))
+	assert.Equal(t, "12345678901234567890.0123456789", exact.String())
+	assert.Equal(t, int32(10), exact.Scale())
+	assert.Equal(t, 0.5, f)
+	require.NoError(t, rows.Scan(&text, &f))
+	assert.Equal(t, "12345678901234567890.0123456789", text)
+	require.NoError(t, rows.Close())
+}
+
+func TestNestedDecimalColumnType(t *testing.T) {
+	db := sql.OpenDB(&fakeConnector{
+		resp: &QueryResponse{
+			Schema: &[]DataField{{Name: "x", Type: "Decimal(10, 2)"}, {Name: "y", Type: "Array(Decimal(10, 2))"}},
+			Data:   [][]*string{{strPtr("1.50"), strPtr("[1.50,-0.01]")}},
+		},
+	})
+	rows, err := db.Query("x")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	assert.Equal(t, reflectTypeDecimal, types[0].ScanType())
+	assert.Equal(t, reflect.TypeOf([]string(nil)), types[1].ScanType())
+	require.True(t, rows.Next())
+
+	// the same text at the top level and in arrays
+	var (
+		text  string
+		texts []string
+	)
+	require.NoError(t, rows.Scan(&text, &texts))
+	assert.Equal(t, "1.50", text)
+	assert.Equal(t, []string{"1.50", "-0.01"}, texts)
+
+	var (
+		exact  Decimal
+		exacts Array[Decimal]
+	)
+	require.NoError(t, rows.Scan(&exact, &exacts))
+	assert.Equal(t, "1.50", exact.String())
+	assert.Equal(t, Array[Decimal]{NewDecimalFromInt64(150, 2), NewDecimalFromInt64(-1, 2)}, exacts)
+}
@@ -22,6 +22,11 @@ const (
 	QueryResultFormatArrow = "arrow"
 )
 
+const (
+	BatchFormatCSV     = "csv"
+	BatchFormatParquet = "parquet"
+)
+
 // Config is a set of configuration parameters
 type Config struct {
 	Tenant    string // Tenant
@@ -55,9 +60,63 @@ type Config struct {
 	// track the progress of query execution
 	StatsTracker QueryStatsTracker
 
+	// Types overrides how result columns are decoded on connections made with
+	// this Config, ahead of RegisterColumnType and RegisterArrowType.
+	Types *TypeRegistry
+
 	// used on the storage which does not support presigned url like HDFS, local fs
 	PresignedURLDisabled bool
 
+	// BatchSpillThreshold is the size in bytes up to which batch inserts are kept
+	// in memory before being moved to a temporary file. It defaults to 64MiB, and
+	// a negative value keeps batches in memory regardless of their size.
+	BatchSpillThreshold int64
+
+	// BatchFormat is the file format batch inserts are staged in. BatchFormatCSV,
+	// the default, sends values in their text form, while BatchFormatParquet
+	// encodes them with the column types of the target table.
+	BatchFormat string
+
+	// BatchChunkRows, BatchChunkBytes and BatchChunkInterval split batch inserts
+	// into files of about that many rows or bytes, or of the rows appended within
+	// that interval, which are uploaded while further rows are appended. Zero
+	// disables a threshold; by default a batch is a single file.
+	BatchChunkRows     int64
+	BatchChunkBytes    int64
+	BatchChunkInterval time.Duration
+
+	// BatchUploadConcurrency is the maximum number of chunks uploaded at once,
+	// 4 by default. Appending waits while that many uploads are in flight.
+	BatchUploadConcurrency int
+
+	// BatchChunkRetries is the number of times a failed chunk upload is retried.
+	BatchChunkRetries int
+
+	// BatchLoadPerChunk loads each chunk with its own insert once it is uploaded,
+	// so that chunks loaded before a failure are kept. By default all chunks are
+	// loaded by a single insert once every upload succeeded.
+	BatchLoadPerChunk bool
+
+	// BatchOnError is the ON_ERROR copy option batch inserts are loaded with.
+	// With "continue", or "abort_N" to fail once a file has N bad rows, rows
+	// Databend cannot load are skipped and reported by BatchInsertWithResult;
+	// batches are then loaded with COPY INTO, so the statement must be a plain
+	// INSERT INTO. By default, as with "abort", a bad row fails the batch.
+	BatchOnError string
+
+	// BatchMaxErrors fails a batch with more rejected rows than that, loading
+	// it as with BatchOnError "continue" otherwise.
+	BatchMaxErrors int
+
+	// BatchDeadLetterFile is a local file rows rejected with BatchOnError are
+	// appended to, as CSV records of the row number, the error and the values.
+	BatchDeadLetterFile string
+
+	// RawBytesParams makes []byte query arguments be spliced into the SQL verbatim,
+	// as older versions of the driver did, instead of being encoded as Binary literals.
+	// Prefer wrapping intentional SQL fragments with Raw.
+	RawBytesParams bool
+
 	// Specifies the value that should be used when encountering empty fields, including both ,, and ,"",, in the CSV data being loaded into the table.
 	// https://docs.databend.com/sql/sql-reference/file-format-options#empty_field_as
 	// default is `string`
@@ -156,6 +215,42 @@ func (cfg *Config) FormatDSN() string {
 	if cfg.PresignedURLDisabled {
 		query.Set("presigned_url_disabled", "1")
 	}
+	if cfg.RawBytesParams {
+		query.Set("raw_bytes_params", "1")
+	}
+	if cfg.BatchSpillThreshold != 0 {
+		query.Set("batch_spill_threshold", strconv.FormatInt(cfg.BatchSpillThreshold, 10))
+	}
+	if cfg.BatchFormat != "" && cfg.BatchFormat != BatchFormatCSV {
+		query.Set("batch_format", cfg.BatchFormat)
+	}
+	if cfg.BatchChunkRows != 0 {
+		query.Set("batch_chunk_rows", strconv.FormatInt(cfg.BatchChunkRows, 10))
+	}
+	if cfg.BatchChunkBytes != 0 {
+		query.Set("batch_chunk_bytes", strconv.FormatInt(cfg.BatchChunkBytes, 10))
+	}
+	if cfg.BatchChunkInterval != 0 {
+		query.Set("batch_chunk_interval", cfg.BatchChunkInterval.String())
+	}
+	if cfg.BatchUploadConcurrency != 0 {
+		query.Set("batch_upload_concurrency", strconv.Itoa(cfg.BatchUploadConcurrency))
+	}
+	if cfg.BatchChunkRetries != 0 {
+		query.Set("batch_chunk_retries", strconv.Itoa(cfg.BatchChunkRetries))
+	}
+	if cfg.BatchLoadPerChunk {
+		query.Set("batch_load_per_chunk", "1")
+	}
+	if cfg.BatchOnError != "" {
+		query.Set("batch_on_error", cfg.BatchOnError)
+	}
+	if cfg.BatchMaxErrors != 0 {
+		query.Set("batch_max_errors", strconv.Itoa(cfg.BatchMaxErrors))
+	}
+	if cfg.BatchDeadLetterFile != "" {
+		query.Set("batch_dead_letter_file", cfg.BatchDeadLetterFile)
+	}
 	if cfg.EmptyFieldAs != "" {
 		query.Set("empty_field_as", cfg.EmptyFieldAs)
 	} else {
@@ -207,6 +302,30 @@ func (cfg *Config) AddParams(params map[string]string) (err error) {
 			cfg.Params[k] = v
 		case "presigned_url_disabled":
 			cfg.PresignedURLDisabled, err = strconv.ParseBool(v)
+		case "raw_bytes_params":
+			cfg.RawBytesParams, err = strconv.ParseBool(v)
+		case "batch_spill_threshold":
+			cfg.BatchSpillThreshold, err = strconv.ParseInt(v, 10, 64)
+		case "batch_format":
+			cfg.BatchFormat, err = normalizeBatchFormat(v)
+		case "batch_chunk_rows":
+			cfg.BatchChunkRows, err = strconv.ParseInt(v, 10, 64)
+		case "batch_chunk_bytes":
+			cfg.BatchChunkBytes, err = strconv.ParseInt(v, 10, 64)
+		case "batch_chunk_interval":
+			cfg.BatchChunkInterval, err = time.ParseDuration(v)
+		case "batch_upload_concurrency":
+			cfg.BatchUploadConcurrency, err = strconv.Atoi(v)
+		case "batch_chunk_retries":
+			cfg.BatchChunkRetries, err = strconv.Atoi(v)
+		case "batch_load_per_chunk":
+			cfg.BatchLoadPerChunk, err = strconv.ParseBool(v)
+		case "batch_on_error":
+			cfg.BatchOnError, err = normalizeBatchOnError(v)
+		case "batch_max_errors":
+			cfg.BatchMaxErrors, err = strconv.Atoi(v)
+		case "batch_dead_letter_file":
+			cfg.BatchDeadLetterFile = v
 		case "empty_field_as":
 			cfg.EmptyFieldAs = v
 		case "tls_config":
@@ -277,6 +396,33 @@ func normalizeQueryResultFormat(v string) (string, error) {
 	}
 }
 
+func normalizeBatchFormat(v string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", BatchFormatCSV:
+		return BatchFormatCSV, nil
+	case BatchFormatParquet:
+		return BatchFormatParquet, nil
+	default:
+		return "", fmt.Errorf("invalid batch_format: %s", v)
+	}
+}
+
+// normalizeBatchOnError returns the ON_ERROR option of v, or an empty string
+// for the default of aborting.
+func normalizeBatchOnError(v string) (string, error) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	switch v {
+	case "", "abort":
+		return "", nil
+	case "continue":
+		return v, nil
+	}
+	if isOnErrorMode(v) {
+		return v, nil
+	}
+	return "", fmt.Errorf("invalid batch_on_error: %s", v)
+}
+
 func needEscape(s string) bool {
 	unescaped, err := url.QueryUnescape(s)
 	if err != nil {
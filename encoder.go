@@ -2,10 +2,19 @@ package godatabend
 
 import (
 	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 var (
@@ -17,24 +26,93 @@ type encoder interface {
 }
 
 type textEncoder struct {
+	// rawBytes keeps the legacy behaviour of emitting []byte verbatim
+	rawBytes bool
 }
 
 // Encode encodes driver value into string
 // Note: there is 2 convention:
 // type string will be quoted
-// type []byte will be encoded as is (raw string)
+// type []byte will be encoded as a Binary literal, unless rawBytes is set
+// values wrapped with Raw are emitted as is
 func (e *textEncoder) Encode(value driver.Value) ([]byte, error) {
 	switch v := value.(type) {
-	case array:
-		return e.encodeArray(reflect.ValueOf(v.v))
-	case tuple:
-		return e.encodeTuple(reflect.ValueOf(v.v))
-	case tmap:
-		return e.encodeMap(reflect.ValueOf(v.v))
+	case nil:
+		return []byte("NULL"), nil
+	case Raw:
+		return []byte(v), nil
+	case date, bigUint64, decimal:
+		// these wrappers produce a SQL literal already
+		dv, err := v.(driver.Valuer).Value()
+		if err != nil {
+			return nil, err
+		}
+		return dv.([]byte), nil
+	case nestedValue:
+		return v.encodeNested(e)
 	case []byte:
-		return v, nil
+		if e.rawBytes {
+			return v, nil
+		}
+		if v == nil {
+			return []byte("NULL"), nil
+		}
+		return e.encodeBinary(v), nil
 	case time.Time:
 		return []byte(e.encode(v)), nil
+	case time.Duration:
+		return e.Encode(NewIntervalFromDuration(v))
+	case json.RawMessage:
+		return []byte(quote(escape(string(v)))), nil
+	case uuid.UUID:
+		return []byte(quote(v.String())), nil
+	case net.IP:
+		return []byte(quote(v.String())), nil
+	case netip.Addr:
+		if !v.IsValid() {
+			return []byte("NULL"), nil
+		}
+		return []byte(quote(v.String())), nil
+	case *big.Int:
+		if v == nil {
+			return []byte("NULL"), nil
+		}
+		return []byte(v.String()), nil
+	case big.Int:
+		return []byte(v.String()), nil
+	case Int256:
+		return []byte(v.String()), nil
+	case []float32:
+		return e.encodeFloat32s(v), nil
+	case Variant:
+		if v == nil {
+			return []byte("NULL"), nil
+		}
+		return []byte("PARSE_JSON(" + quote(escape(string(v))) + ")"), nil
+	case *Bitmap:
+		if v == nil {
+			return []byte("NULL"), nil
+		}
+		return []byte("TO_BITMAP(" + quote(v.String()) + ")"), nil
+	case Bitmap:
+		return []byte("TO_BITMAP(" + quote(v.String()) + ")"), nil
+	case Decimal:
+		// an unquoted literal with a fractional part is parsed as an exact Decimal
+		return []byte(v.String()), nil
+	case *Decimal:
+		if v == nil {
+			return []byte("NULL"), nil
+		}
+		return []byte(v.String()), nil
+	case driver.Valuer:
+		if inner, ok := unwrapNull(v); ok {
+			return e.Encode(inner)
+		}
+		dv, err := callValuer(v)
+		if err != nil {
+			return nil, err
+		}
+		return e.Encode(dv)
 	}
 
 	vv := reflect.ValueOf(value)
@@ -46,12 +124,59 @@ func (e *textEncoder) Encode(value driver.Value) ([]byte, error) {
 		return e.Encode(vv.Elem().Interface())
 	case reflect.Slice, reflect.Array:
 		return e.encodeArray(vv)
+	case reflect.Map:
+		return e.encodeMap(vv)
 	case reflect.Struct:
 		return e.encodeTuple(vv)
+	case reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return nil, fmt.Errorf("unsupported parameter type %T", value)
 	}
 	return []byte(e.encode(value)), nil
 }
 
+// unwrapNull returns the value held by a sql.Null[T], or nil if it is not
+// valid. Its Value method converts the value to a driver.Value, which would
+// turn the SQL literal of an Array or a Date into a []byte taken for Binary.
+func unwrapNull(v any) (any, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil, false
+	}
+	t := rv.Type()
+	if t.Kind() != reflect.Struct || t.PkgPath() != "database/sql" || !strings.HasPrefix(t.Name(), "Null[") {
+		return nil, false
+	}
+	if !rv.FieldByName("Valid").Bool() {
+		return nil, true
+	}
+	return rv.FieldByName("V").Interface(), true
+}
+
+// callValuer calls v.Value, treating a nil pointer as NULL like database/sql
+// does, since Value methods with value receivers would panic on it.
+func callValuer(v driver.Valuer) (driver.Value, error) {
+	if vv := reflect.ValueOf(v); vv.Kind() == reflect.Ptr && vv.IsNil() {
+		return nil, nil
+	}
+	return v.Value()
+}
+
+// encodable reports whether Encode can turn the value into a literal, so that
+// database/sql does not need to convert it first.
+func encodable(value driver.Value) bool {
+	if value == nil {
+		return true
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return false
+	}
+	return true
+}
+
 func (e *textEncoder) encode(value driver.Value) string {
 	if value == nil {
 		return "NULL"
@@ -92,9 +217,47 @@ func (e *textEncoder) encode(value driver.Value) string {
 		return formatTime(v)
 	}
 
+	// named types such as `type Status string` fall through to their underlying kind
+	vv := reflect.ValueOf(value)
+	switch vv.Kind() {
+	case reflect.Bool:
+		return e.encode(vv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(vv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(vv.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(vv.Float(), 'f', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(vv.Float(), 'f', -1, 64)
+	case reflect.String:
+		return quote(escape(vv.String()))
+	}
 	return fmt.Sprint(value)
 }
 
+// encodeBinary encodes a byte slice as Binary literal
+func (e *textEncoder) encodeBinary(v []byte) []byte {
+	res := make([]byte, 0, len("FROM_HEX('')")+hex.EncodedLen(len(v)))
+	res = append(res, "FROM_HEX('"...)
+	res = hex.AppendEncode(res, v)
+	return append(res, "')"...)
+}
+
+// encodeFloat32s encodes a vector in the shortest form that parses back to
+// the same float32 values, without going through reflection.
+func (e *textEncoder) encodeFloat32s(values []float32) []byte {
+	res := make([]byte, 0, 2+len(values)*12)
+	res = append(res, '[')
+	for i, v := range values {
+		if i > 0 {
+			res = append(res, ',')
+		}
+		res = strconv.AppendFloat(res, float64(v), 'g', -1, 32)
+	}
+	return append(res, ']')
+}
+
 // EncodeArray encodes a go slice or array as Clickhouse Array
 func (e *textEncoder) encodeArray(value reflect.Value) ([]byte, error) {
 	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
@@ -167,34 +330,43 @@ func (e *textEncoder) encodeTuplePart(value reflect.Value) ([]byte, error) {
 	return res, nil
 }
 
+// encodeMap encodes a go map as Databend Map literal, with keys in sorted order
+// so the generated SQL is stable
 func (e *textEncoder) encodeMap(m reflect.Value) ([]byte, error) {
 	if m.Kind() != reflect.Map {
 		return nil, fmt.Errorf("expected map, got %s", m.Kind())
 	}
 
-	keys := m.MapKeys()
-	res := make([]byte, 0)
-	res = append(res, "map("...)
-	for i, key := range keys {
-		value := m.MapIndex(key)
-
-		tmpKey, err := e.Encode(key.Interface())
+	type entry struct {
+		key   []byte
+		value reflect.Value
+	}
+	entries := make([]entry, 0, m.Len())
+	iter := m.MapRange()
+	for iter.Next() {
+		key, err := e.Encode(iter.Key().Interface())
 		if err != nil {
 			return nil, err
 		}
-		res = append(res, tmpKey...)
-
-		res = append(res, ',')
+		entries = append(entries, entry{key: key, value: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return string(entries[i].key) < string(entries[j].key)
+	})
 
-		tmpValue, err := e.Encode(value.Interface())
+	res := make([]byte, 0)
+	res = append(res, '{')
+	for i, ent := range entries {
+		if i > 0 {
+			res = append(res, ',')
+		}
+		res = append(res, ent.key...)
+		res = append(res, ':')
+		value, err := e.Encode(ent.value.Interface())
 		if err != nil {
 			return nil, err
 		}
-		res = append(res, tmpValue...)
-
-		if i < len(keys)-1 {
-			res = append(res, ',')
-		}
+		res = append(res, value...)
 	}
-	return append(res, ')'), nil
+	return append(res, '}'), nil
 }
@@ -1,10 +1,16 @@
 package godatabend
 
 import (
-	"regexp"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/netip"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,6 +25,10 @@ type TestTuple struct {
 	TestEmbedTuple
 }
 
+type testStatus string
+
+type testLevel int
+
 type TestNestedTuple struct {
 	A *TestTuple
 	D int
@@ -47,19 +57,59 @@ func TestTextEncoder(t *testing.T) {
 		{dt, "'2011-03-06 06:20:00.000000+00:00'"},
 		{d, "'2012-05-31 00:00:00.000000+00:00'"},
 		{"hello", "'hello'"},
-		{[]byte("hello"), "hello"},
+		{[]byte("hello"), "FROM_HEX('68656c6c6f')"},
+		{[]byte{}, "FROM_HEX('')"},
+		{[]byte(nil), "NULL"},
+		{Raw("now()"), "now()"},
+		{[]interface{}{Raw("now()"), Date(d)}, "[now(),'2012-05-31']"},
 		{`\\'hello`, `'\\\\\'hello'`},
-		{[]byte(`\\'hello`), `\\'hello`},
+		{[]byte(`\\'hello`), "FROM_HEX('5c5c2768656c6c6f')"},
 		{[]int32{1, 2}, "[1,2]"},
 		{[]int32{}, "[]"},
-		{Array([]int8{1}), "[1]"},
-		{Array([]interface{}{Array([]int8{1})}), "[[1]]"},
+		{Array[int8]{1}, "[1]"},
+		{Array[any]{Array[int8]{1}}, "[[1]]"},
+		{Array[int8](nil), "[]"},
 		{[][]int16{{1}}, "[[1]]"},
 		{[]int16(nil), "[]"},
 		{(*int16)(nil), "NULL"},
-		{Tuple(TestTuple{A: 1, B: "2", TestEmbedTuple: TestEmbedTuple{C: true, private: 5}}), "(1,'2',1)"},
-		{Tuple(TestNestedTuple{A: &TestTuple{A: 1, B: "2", TestEmbedTuple: TestEmbedTuple{C: true}}, D: 4}), "((1,'2',1),4)"},
+		{TestTuple{A: 1, B: "2", TestEmbedTuple: TestEmbedTuple{C: true, private: 5}}, "(1,'2',1)"},
+		{TestNestedTuple{A: &TestTuple{A: 1, B: "2", TestEmbedTuple: TestEmbedTuple{C: true}}, D: 4}, "((1,'2',1),4)"},
+		{Tuple2[int, string]{V1: 1, V2: "a"}, "(1,'a')"},
+		{Tuple3[*int, Array[string], bool]{V2: Array[string]{"x"}, V3: true}, "(NULL,['x'],1)"},
 		{[]TestTuple{{A: 1, B: "2", TestEmbedTuple: TestEmbedTuple{C: true, private: 5}}}, "[(1,'2',1)]"},
+		{nil, "NULL"},
+		{[]string{"a", "b"}, "['a','b']"},
+		{map[string]int{"b": 2, "a": 1}, "{'a':1,'b':2}"},
+		{NewDecimalFromInt64(-12345, 3), "-12.345"},
+		{[]Decimal{NewDecimalFromInt64(1, 2)}, "[0.01]"},
+		{(*Decimal)(nil), "NULL"},
+		{NewBitmap(3, 1, 2), "TO_BITMAP('1,2,3')"},
+		{(*Bitmap)(nil), "NULL"},
+		{IntervalValue{Months: 1, Days: 2}, "'1 month 2 days'"},
+		{map[int][]string{1: {"x"}}, "{1:['x']}"},
+		{uuid.MustParse("9d4f6a3e-8c1b-4f0e-a3a1-0c2b5e8f7d61"), "'9d4f6a3e-8c1b-4f0e-a3a1-0c2b5e8f7d61'"},
+		{net.ParseIP("10.0.0.1"), "'10.0.0.1'"},
+		{netip.MustParseAddr("2001:db8::1"), "'2001:db8::1'"},
+		{netip.Addr{}, "NULL"},
+		{IPAddr{netip.MustParseAddr("10.0.0.2")}, "'10.0.0.2'"},
+		{new(big.Int).Lsh(big.NewInt(1), 100), "1267650600228229401496703205376"},
+		{(*big.Int)(nil), "NULL"},
+		{Int256{v: big.NewInt(-7)}, "-7"},
+		{json.RawMessage(`{"a":"it's"}`), `'{"a":"it\'s"}'`},
+		{1500 * time.Millisecond, "'1500000'"},
+		{Interval(90 * time.Minute), "'5400000000'"},
+		{[]float32{0.5, 1, -2.25}, "[0.5,1,-2.25]"},
+		{sql.Null[string]{V: "x", Valid: true}, "'x'"},
+		{sql.Null[int64]{}, "NULL"},
+		{sql.NullInt32{Int32: 3, Valid: true}, "3"},
+		{sql.Null[Array[int]]{V: Array[int]{1, 2}, Valid: true}, "[1,2]"},
+		{sql.Null[Map[string, int]]{V: Map[string, int]{"a": 1}, Valid: true}, "{'a':1}"},
+		{sql.Null[Tuple2[int, string]]{V: Tuple2[int, string]{V1: 1, V2: "a"}, Valid: true}, "(1,'a')"},
+		{sql.Null[driver.Valuer]{V: Date(d), Valid: true}, "'2012-05-31'"},
+		{sql.Null[driver.Valuer]{V: UInt64(1 << 63), Valid: true}, "9223372036854775808"},
+		{sql.Null[[]byte]{V: []byte("hi"), Valid: true}, "FROM_HEX('6869')"},
+		{testStatus("ok"), "'ok'"},
+		{testLevel(2), "2"},
 	}
 
 	enc := new(textEncoder)
@@ -71,21 +121,35 @@ func TestTextEncoder(t *testing.T) {
 	}
 }
 
+func TestTextEncoderRawBytes(t *testing.T) {
+	enc := &textEncoder{rawBytes: true}
+	v, err := enc.Encode([]byte(`\\'hello`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, `\\'hello`, string(v))
+	}
+	v, err = enc.Encode([][]byte{[]byte("now()")})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "[now()]", string(v))
+	}
+}
+
 func TestTextEncoder_Map(t *testing.T) {
 	testCases := []struct {
-		value interface{}
-		reg   *regexp.Regexp
+		value    interface{}
+		expected string
 	}{
-		{value: Map(map[string]string{"KEY1": "Value1", "Key2": "Value2"}), reg: regexp.MustCompile(`map\(('KEY1','Value1','Key2','Value2'|'Key2','Value2','KEY1','Value1')\)`)},
-		{value: Map(map[string]int{"KEY1": 1, "Key2": 2}), reg: regexp.MustCompile(`map\(('KEY1',1,'Key2',2|'Key2',2,'KEY1',1)\)`)},
-		{value: Map(map[string]bool{"KEY1": true, "Key2": false}), reg: regexp.MustCompile(`map\(('KEY1',1,'Key2',0|'Key2',0,'KEY1',1)\)`)},
+		{value: Map[string, string]{"KEY1": "Value1", "Key2": "Value2"}, expected: "{'KEY1':'Value1','Key2':'Value2'}"},
+		{value: Map[string, int]{"KEY1": 1, "Key2": 2}, expected: "{'KEY1':1,'Key2':2}"},
+		{value: Map[string, bool]{"KEY1": true, "Key2": false}, expected: "{'KEY1':1,'Key2':0}"},
+		{value: Map[int, Array[int]]{1: {2}}, expected: "{1:[2]}"},
+		{value: Map[string, int](nil), expected: "{}"},
 	}
 
 	enc := new(textEncoder)
 	for _, tc := range testCases {
 		v, err := enc.Encode(tc.value)
 		if assert.NoError(t, err) {
-			assert.True(t, tc.reg.Match(v))
+			assert.Equal(t, tc.expected, string(v))
 		}
 	}
 }
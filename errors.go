@@ -5,7 +5,10 @@ import (
 )
 
 var (
-	ErrPlaceholderCount = errors.New("databend: wrong placeholder count")
-	ErrNoLastInsertID   = errors.New("no LastInsertId available")
-	ErrNoRowsAffected   = errors.New("no RowsAffected available")
+	ErrPlaceholderCount  = errors.New("databend: wrong placeholder count")
+	ErrMixedPlaceholders = errors.New("databend: cannot mix ? and $N placeholders")
+	ErrMissingParameter  = errors.New("databend: missing parameter")
+	ErrUnusedParameter   = errors.New("databend: unused parameter")
+	ErrNoLastInsertID    = errors.New("no LastInsertId available")
+	ErrNoRowsAffected    = errors.New("no RowsAffected available")
 )
@@ -0,0 +1,280 @@
+package godatabend
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// FileFormatOptions is the format of staged files, one of CSVFormat, TSVFormat,
+// NDJSONFormat, ParquetFormat, ORCFormat and AvroFormat. Options validates it
+// and returns it as the FILE_FORMAT options taken by InsertWithStage and the
+// statement builders. Zero fields are left to the server defaults.
+type FileFormatOptions interface {
+	Options() (map[string]string, error)
+}
+
+var (
+	_ FileFormatOptions = CSVFormat{}
+	_ FileFormatOptions = TSVFormat{}
+	_ FileFormatOptions = NDJSONFormat{}
+	_ FileFormatOptions = ParquetFormat{}
+	_ FileFormatOptions = ORCFormat{}
+	_ FileFormatOptions = AvroFormat{}
+)
+
+// CSVFormat is the CSV file format.
+type CSVFormat struct {
+	FieldDelimiter  string
+	RecordDelimiter string
+	SkipHeader      int
+	// Quote is one of `"`, `'` and "`".
+	Quote string
+	// Escape is `\` or empty.
+	Escape      string
+	NullDisplay string
+	// EmptyFieldAs is "null", "string" or "field_default".
+	EmptyFieldAs string
+	// BinaryFormat is "hex" or "base64".
+	BinaryFormat string
+	Compression  string
+	// OutputHeader writes a header line when unloading.
+	OutputHeader bool
+	// AllowColumnCountMismatch loads records with a different number of fields
+	// than the table has columns.
+	AllowColumnCountMismatch bool
+}
+
+func (f CSVFormat) Options() (map[string]string, error) {
+	options := map[string]string{"type": "CSV"}
+	if err := setDelimiters(options, "csv format", f.FieldDelimiter, f.RecordDelimiter); err != nil {
+		return nil, err
+	}
+	if f.SkipHeader < 0 {
+		return nil, errors.Errorf("csv format: invalid skip_header %d", f.SkipHeader)
+	}
+	if f.SkipHeader > 0 {
+		options["skip_header"] = strconv.Itoa(f.SkipHeader)
+	}
+	if f.Quote != "" {
+		if f.Quote != `"` && f.Quote != `'` && f.Quote != "`" {
+			return nil, errors.Errorf("csv format: invalid quote %q", f.Quote)
+		}
+		options["quote"] = f.Quote
+	}
+	if f.Escape != "" {
+		if f.Escape != `\` {
+			return nil, errors.Errorf("csv format: invalid escape %q", f.Escape)
+		}
+		options["escape"] = f.Escape
+	}
+	if f.NullDisplay != "" {
+		options["null_display"] = f.NullDisplay
+	}
+	if err := setKeyword(options, "csv format", EMPTY_FIELD_AS, f.EmptyFieldAs, "null", "string", "field_default"); err != nil {
+		return nil, err
+	}
+	if err := setKeyword(options, "csv format", "binary_format", f.BinaryFormat, "hex", "base64"); err != nil {
+		return nil, err
+	}
+	if err := setCompression(options, "csv format", f.Compression); err != nil {
+		return nil, err
+	}
+	if f.OutputHeader {
+		options["output_header"] = "true"
+	}
+	if f.AllowColumnCountMismatch {
+		options["error_on_column_count_mismatch"] = "false"
+	}
+	return options, nil
+}
+
+// TSVFormat is the tab-separated file format.
+type TSVFormat struct {
+	FieldDelimiter  string
+	RecordDelimiter string
+	Compression     string
+}
+
+func (f TSVFormat) Options() (map[string]string, error) {
+	options := map[string]string{"type": "TSV"}
+	if err := setDelimiters(options, "tsv format", f.FieldDelimiter, f.RecordDelimiter); err != nil {
+		return nil, err
+	}
+	if err := setCompression(options, "tsv format", f.Compression); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// NDJSONFormat is the newline-delimited JSON file format.
+type NDJSONFormat struct {
+	// NullFieldAs is "null" or "field_default".
+	NullFieldAs string
+	// MissingFieldAs is "error", "null" or "field_default".
+	MissingFieldAs string
+	Compression    string
+}
+
+func (f NDJSONFormat) Options() (map[string]string, error) {
+	options := map[string]string{"type": "NDJSON"}
+	if err := setKeyword(options, "ndjson format", "null_field_as", f.NullFieldAs, "null", "field_default"); err != nil {
+		return nil, err
+	}
+	if err := setKeyword(options, "ndjson format", "missing_field_as", f.MissingFieldAs, "error", "null", "field_default"); err != nil {
+		return nil, err
+	}
+	if err := setCompression(options, "ndjson format", f.Compression); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// ParquetFormat is the Parquet file format.
+type ParquetFormat struct {
+	// MissingFieldAs is "error" or "field_default".
+	MissingFieldAs string
+}
+
+func (f ParquetFormat) Options() (map[string]string, error) {
+	return columnarFormatOptions("PARQUET", f.MissingFieldAs)
+}
+
+// ORCFormat is the ORC file format.
+type ORCFormat struct {
+	// MissingFieldAs is "error" or "field_default".
+	MissingFieldAs string
+}
+
+func (f ORCFormat) Options() (map[string]string, error) {
+	return columnarFormatOptions("ORC", f.MissingFieldAs)
+}
+
+// AvroFormat is the Avro file format.
+type AvroFormat struct {
+	// MissingFieldAs is "error" or "field_default".
+	MissingFieldAs string
+}
+
+func (f AvroFormat) Options() (map[string]string, error) {
+	return columnarFormatOptions("AVRO", f.MissingFieldAs)
+}
+
+func columnarFormatOptions(typ, missingFieldAs string) (map[string]string, error) {
+	options := map[string]string{"type": typ}
+	if err := setKeyword(options, strings.ToLower(typ)+" format", "missing_field_as", missingFieldAs, "error", "field_default"); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// CopyOptions are the options of loading staged files, with COPY INTO or
+// InsertWithStage. Zero fields are left to the server defaults.
+type CopyOptions struct {
+	// Purge removes the files once they are loaded.
+	Purge bool
+	// Force loads files that were loaded before.
+	Force bool
+	// OnError is "continue", "abort" or "abort_N".
+	OnError string
+	// SizeLimit is the maximum number of rows loaded.
+	SizeLimit int
+	// MaxFiles is the maximum number of files loaded.
+	MaxFiles            int
+	DisableVariantCheck bool
+	ReturnFailedOnly    bool
+	// ColumnMatchMode is "case_sensitive" or "case_insensitive", for Parquet,
+	// ORC and Avro files.
+	ColumnMatchMode string
+}
+
+func (o CopyOptions) Options() (map[string]string, error) {
+	options := map[string]string{}
+	if o.Purge {
+		options[PURGE] = "true"
+	}
+	if o.Force {
+		options["force"] = "true"
+	}
+	if o.OnError != "" {
+		onError := strings.ToLower(o.OnError)
+		if !isOnErrorMode(onError) {
+			return nil, errors.Errorf("copy options: invalid on_error %q", o.OnError)
+		}
+		options["on_error"] = onError
+	}
+	if o.SizeLimit < 0 {
+		return nil, errors.Errorf("copy options: invalid size_limit %d", o.SizeLimit)
+	}
+	if o.SizeLimit > 0 {
+		options["size_limit"] = strconv.Itoa(o.SizeLimit)
+	}
+	if o.MaxFiles < 0 {
+		return nil, errors.Errorf("copy options: invalid max_files %d", o.MaxFiles)
+	}
+	if o.MaxFiles > 0 {
+		options["max_files"] = strconv.Itoa(o.MaxFiles)
+	}
+	if o.DisableVariantCheck {
+		options["disable_variant_check"] = "true"
+	}
+	if o.ReturnFailedOnly {
+		options["return_failed_only"] = "true"
+	}
+	if err := setKeyword(options, "copy options", "column_match_mode", o.ColumnMatchMode, "case_sensitive", "case_insensitive"); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// isOnErrorMode reports whether v, in lower case, is a value of ON_ERROR.
+func isOnErrorMode(v string) bool {
+	switch v {
+	case "continue", "abort":
+		return true
+	}
+	n, ok := strings.CutPrefix(v, "abort_")
+	if !ok {
+		return false
+	}
+	i, err := strconv.Atoi(n)
+	return err == nil && i > 0
+}
+
+func setDelimiters(options map[string]string, what, field, record string) error {
+	if field != "" {
+		if utf8.RuneCountInString(field) != 1 {
+			return errors.Errorf("%s: invalid field_delimiter %q", what, field)
+		}
+		options["field_delimiter"] = field
+	}
+	if record != "" {
+		if utf8.RuneCountInString(record) != 1 && record != "\r\n" {
+			return errors.Errorf("%s: invalid record_delimiter %q", what, record)
+		}
+		options["record_delimiter"] = record
+	}
+	return nil
+}
+
+func setCompression(options map[string]string, what, compression string) error {
+	return setKeyword(options, what, "compression", compression,
+		"auto", "gzip", "bz2", "brotli", "zstd", "deflate", "raw_deflate", "xz", "none")
+}
+
+// setKeyword sets the option key to v, in lower case, if it is one of valid,
+// and reports an error for the options described by what otherwise.
+func setKeyword(options map[string]string, what, key, v string, valid ...string) error {
+	if v == "" {
+		return nil
+	}
+	for _, s := range valid {
+		if strings.EqualFold(v, s) {
+			options[key] = s
+			return nil
+		}
+	}
+	return errors.Errorf("%s: invalid %s %q", what, key, v)
+}
@@ -0,0 +1,86 @@
+package godatabend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileFormatOptions(t *testing.T) {
+	testCases := []struct {
+		format   FileFormatOptions
+		expected map[string]string
+	}{
+		{CSVFormat{}, map[string]string{"type": "CSV"}},
+		{
+			CSVFormat{
+				FieldDelimiter: "|", RecordDelimiter: "\r\n", SkipHeader: 1, Quote: `"`, Escape: `\`,
+				NullDisplay: `\N`, EmptyFieldAs: "NULL", BinaryFormat: "base64", Compression: "GZIP",
+				OutputHeader: true, AllowColumnCountMismatch: true,
+			},
+			map[string]string{
+				"type": "CSV", "field_delimiter": "|", "record_delimiter": "\r\n", "skip_header": "1",
+				"quote": `"`, "escape": `\`, "null_display": `\N`, "empty_field_as": "null",
+				"binary_format": "base64", "compression": "gzip", "output_header": "true",
+				"error_on_column_count_mismatch": "false",
+			},
+		},
+		{TSVFormat{FieldDelimiter: "\t", Compression: "zstd"}, map[string]string{"type": "TSV", "field_delimiter": "\t", "compression": "zstd"}},
+		{NDJSONFormat{NullFieldAs: "field_default", MissingFieldAs: "null"}, map[string]string{"type": "NDJSON", "null_field_as": "field_default", "missing_field_as": "null"}},
+		{ParquetFormat{MissingFieldAs: "field_default"}, map[string]string{"type": "PARQUET", "missing_field_as": "field_default"}},
+		{ORCFormat{}, map[string]string{"type": "ORC"}},
+		{AvroFormat{MissingFieldAs: "error"}, map[string]string{"type": "AVRO", "missing_field_as": "error"}},
+	}
+	for _, tc := range testCases {
+		options, err := tc.format.Options()
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, options)
+	}
+}
+
+func TestFileFormatOptionsInvalid(t *testing.T) {
+	testCases := []struct {
+		format FileFormatOptions
+		err    string
+	}{
+		{CSVFormat{FieldDelimiter: "||"}, `csv format: invalid field_delimiter "||"`},
+		{CSVFormat{RecordDelimiter: "ab"}, `csv format: invalid record_delimiter "ab"`},
+		{CSVFormat{SkipHeader: -1}, "csv format: invalid skip_header -1"},
+		{CSVFormat{Quote: "x"}, `csv format: invalid quote "x"`},
+		{CSVFormat{Escape: "/"}, `csv format: invalid escape "/"`},
+		{CSVFormat{EmptyFieldAs: "nul"}, `csv format: invalid empty_field_as "nul"`},
+		{TSVFormat{Compression: "zip"}, `tsv format: invalid compression "zip"`},
+		{NDJSONFormat{MissingFieldAs: "skip"}, `ndjson format: invalid missing_field_as "skip"`},
+		{ParquetFormat{MissingFieldAs: "null"}, `parquet format: invalid missing_field_as "null"`},
+	}
+	for _, tc := range testCases {
+		_, err := tc.format.Options()
+		assert.EqualError(t, err, tc.err)
+	}
+}
+
+func TestCopyOptions(t *testing.T) {
+	options, err := CopyOptions{}.Options()
+	require.NoError(t, err)
+	assert.Empty(t, options)
+
+	options, err = CopyOptions{
+		Purge: true, Force: true, OnError: "ABORT_5", SizeLimit: 100, MaxFiles: 10,
+		DisableVariantCheck: true, ReturnFailedOnly: true, ColumnMatchMode: "case_insensitive",
+	}.Options()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"purge": "true", "force": "true", "on_error": "abort_5", "size_limit": "100", "max_files": "10",
+		"disable_variant_check": "true", "return_failed_only": "true", "column_match_mode": "case_insensitive",
+	}, options)
+
+	_, err = CopyOptions{OnError: "skip_file"}.Options()
+	assert.EqualError(t, err, `copy options: invalid on_error "skip_file"`)
+	_, err = CopyOptions{OnError: "abort_0"}.Options()
+	assert.Error(t, err)
+	_, err = CopyOptions{SizeLimit: -1}.Options()
+	assert.EqualError(t, err, "copy options: invalid size_limit -1")
+	_, err = CopyOptions{ColumnMatchMode: "exact"}.Options()
+	assert.EqualError(t, err, `copy options: invalid column_match_mode "exact"`)
+}
@@ -0,0 +1,210 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates,omitempty"`
+	Geometries  []geoJSON       `json:"geometries,omitempty"`
+}
+
+// MarshalGeoJSON encodes g as a GeoJSON geometry object.
+func MarshalGeoJSON(g Geometry) ([]byte, error) {
+	obj, err := toGeoJSON(g)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(obj)
+}
+
+// UnmarshalGeoJSON decodes a GeoJSON geometry object. Features are not
+// supported.
+func UnmarshalGeoJSON(data []byte) (Geometry, error) {
+	var obj geoJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("geo: invalid GeoJSON: %w", err)
+	}
+	return fromGeoJSON(obj)
+}
+
+func toGeoJSON(g Geometry) (geoJSON, error) {
+	var coords any
+	switch g := g.(type) {
+	case Point:
+		coords = pointCoords(g)
+	case LineString:
+		coords = pointsCoords(g)
+	case Polygon:
+		coords = ringsCoords(g)
+	case MultiPoint:
+		coords = pointsCoords(g)
+	case MultiLineString:
+		coords = ringsCoords(g)
+	case MultiPolygon:
+		polygons := make([][][][]float64, len(g))
+		for i, polygon := range g {
+			polygons[i] = ringsCoords(polygon)
+		}
+		coords = polygons
+	case GeometryCollection:
+		obj := geoJSON{Type: g.GeometryType(), Geometries: make([]geoJSON, len(g))}
+		for i, child := range g {
+			var err error
+			if obj.Geometries[i], err = toGeoJSON(child); err != nil {
+				return geoJSON{}, err
+			}
+		}
+		return obj, nil
+	default:
+		return geoJSON{}, fmt.Errorf("geo: unsupported geometry %T", g)
+	}
+	data, err := json.Marshal(coords)
+	if err != nil {
+		return geoJSON{}, fmt.Errorf("geo: %w", err)
+	}
+	return geoJSON{Type: g.GeometryType(), Coordinates: data}, nil
+}
+
+func pointCoords(p Point) []float64 {
+	if p.IsEmpty() {
+		return []float64{}
+	}
+	return []float64{p.X, p.Y}
+}
+
+func pointsCoords(points []Point) [][]float64 {
+	coords := make([][]float64, len(points))
+	for i, p := range points {
+		coords[i] = pointCoords(p)
+	}
+	return coords
+}
+
+func ringsCoords(rings []LineString) [][][]float64 {
+	coords := make([][][]float64, len(rings))
+	for i, ring := range rings {
+		coords[i] = pointsCoords(ring)
+	}
+	return coords
+}
+
+func fromGeoJSON(obj geoJSON) (Geometry, error) {
+	if obj.Type == "GeometryCollection" {
+		var g GeometryCollection
+		for _, child := range obj.Geometries {
+			member, err := fromGeoJSON(child)
+			if err != nil {
+				return nil, err
+			}
+			g = append(g, member)
+		}
+		return g, nil
+	}
+
+	var (
+		g   Geometry
+		err error
+	)
+	switch obj.Type {
+	case "Point":
+		var coords []float64
+		if err = unmarshalCoords(obj, &coords); err == nil {
+			g, err = coordsPoint(coords)
+		}
+	case "LineString":
+		var coords [][]float64
+		if err = unmarshalCoords(obj, &coords); err == nil {
+			var points []Point
+			points, err = coordsPoints(coords)
+			g = LineString(points)
+		}
+	case "Polygon":
+		var coords [][][]float64
+		if err = unmarshalCoords(obj, &coords); err == nil {
+			var rings []LineString
+			rings, err = coordsRings(coords)
+			g = Polygon(rings)
+		}
+	case "MultiPoint":
+		var coords [][]float64
+		if err = unmarshalCoords(obj, &coords); err == nil {
+			var points []Point
+			points, err = coordsPoints(coords)
+			g = MultiPoint(points)
+		}
+	case "MultiLineString":
+		var coords [][][]float64
+		if err = unmarshalCoords(obj, &coords); err == nil {
+			var rings []LineString
+			rings, err = coordsRings(coords)
+			g = MultiLineString(rings)
+		}
+	case "MultiPolygon":
+		var coords [][][][]float64
+		if err = unmarshalCoords(obj, &coords); err == nil {
+			var polygons MultiPolygon
+			for _, c := range coords {
+				var rings []LineString
+				if rings, err = coordsRings(c); err != nil {
+					break
+				}
+				polygons = append(polygons, rings)
+			}
+			g = polygons
+		}
+	default:
+		return nil, fmt.Errorf("geo: unsupported GeoJSON type %q", obj.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func unmarshalCoords(obj geoJSON, dest any) error {
+	if obj.Coordinates == nil {
+		return fmt.Errorf("geo: GeoJSON %s has no coordinates", obj.Type)
+	}
+	if err := json.Unmarshal(obj.Coordinates, dest); err != nil {
+		return fmt.Errorf("geo: invalid GeoJSON %s coordinates: %w", obj.Type, err)
+	}
+	return nil
+}
+
+func coordsPoint(coords []float64) (Point, error) {
+	switch len(coords) {
+	case 0:
+		return EmptyPoint(), nil
+	case 2:
+		return Point{X: coords[0], Y: coords[1]}, nil
+	default:
+		return Point{}, fmt.Errorf("geo: GeoJSON position has %d coordinates, only 2D is supported", len(coords))
+	}
+}
+
+func coordsPoints(coords [][]float64) ([]Point, error) {
+	var points []Point
+	for _, c := range coords {
+		p, err := coordsPoint(c)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+func coordsRings(coords [][][]float64) ([]LineString, error) {
+	var rings []LineString
+	for _, c := range coords {
+		ring, err := coordsPoints(c)
+		if err != nil {
+			return nil, err
+		}
+		rings = append(rings, ring)
+	}
+	return rings, nil
+}
@@ -0,0 +1,45 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeoJSONRoundTrip(t *testing.T) {
+	for _, g := range testGeometries {
+		t.Run(g.GeometryType(), func(t *testing.T) {
+			data, err := MarshalGeoJSON(g)
+			require.NoError(t, err)
+			decoded, err := UnmarshalGeoJSON(data)
+			require.NoError(t, err)
+			assert.Equal(t, g, decoded)
+		})
+	}
+}
+
+func TestUnmarshalGeoJSON(t *testing.T) {
+	g, err := UnmarshalGeoJSON([]byte(`{"type": "Point", "coordinates": [1.0,2.0]}`))
+	require.NoError(t, err)
+	assert.Equal(t, Point{X: 1, Y: 2}, g)
+
+	data, err := MarshalGeoJSON(LineString{{X: 0, Y: 0}, {X: 1.5, Y: 2}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"LineString","coordinates":[[0,0],[1.5,2]]}`, string(data))
+
+	data, err = MarshalGeoJSON(EmptyPoint())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"Point","coordinates":[]}`, string(data))
+
+	for _, invalid := range []string{
+		`{"type":"Point"}`,
+		`{"type":"Point","coordinates":[1,2,3]}`,
+		`{"type":"LineString","coordinates":[1,2]}`,
+		`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}}`,
+		`[1,2]`,
+	} {
+		_, err := UnmarshalGeoJSON([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
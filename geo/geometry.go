@@ -0,0 +1,110 @@
+// Package geo provides Go types for the values of Databend Geometry and
+// Geography columns, and codecs for the formats Databend reads and writes them
+// in: WKB, EWKB, WKT, EWKT and GeoJSON.
+//
+// Scan a column into a Shape to get its geometry and SRID whatever the
+// geometry_output_format setting is:
+//
+//	var s geo.Shape
+//	err := db.QueryRow("SELECT geom FROM t").Scan(&s)
+//	p, ok := s.Geometry.(geo.Point)
+//
+// Geometries and shapes can be passed as query parameters, and are sent as
+// WKT or EWKT text.
+package geo
+
+import (
+	"database/sql/driver"
+	"math"
+)
+
+// Geometry is one of Point, LineString, Polygon, MultiPoint, MultiLineString,
+// MultiPolygon and GeometryCollection. Coordinates are two-dimensional, and
+// decoders return nil slices for empty geometries.
+type Geometry interface {
+	// GeometryType returns the type name, e.g. "Point".
+	GeometryType() string
+	// IsEmpty reports whether the geometry has no points.
+	IsEmpty() bool
+}
+
+// Point is a single position. The empty point has NaN coordinates.
+type Point struct {
+	X, Y float64
+}
+
+// EmptyPoint returns the empty point, `POINT EMPTY`.
+func EmptyPoint() Point {
+	return Point{X: math.NaN(), Y: math.NaN()}
+}
+
+// LineString is a sequence of points.
+type LineString []Point
+
+// Polygon is a list of linear rings: the exterior ring followed by the holes.
+type Polygon []LineString
+
+// MultiPoint is a collection of points.
+type MultiPoint []Point
+
+// MultiLineString is a collection of line strings.
+type MultiLineString []LineString
+
+// MultiPolygon is a collection of polygons.
+type MultiPolygon []Polygon
+
+// GeometryCollection is a collection of geometries of any type.
+type GeometryCollection []Geometry
+
+func (Point) GeometryType() string              { return "Point" }
+func (LineString) GeometryType() string         { return "LineString" }
+func (Polygon) GeometryType() string            { return "Polygon" }
+func (MultiPoint) GeometryType() string         { return "MultiPoint" }
+func (MultiLineString) GeometryType() string    { return "MultiLineString" }
+func (MultiPolygon) GeometryType() string       { return "MultiPolygon" }
+func (GeometryCollection) GeometryType() string { return "GeometryCollection" }
+
+func (p Point) IsEmpty() bool              { return math.IsNaN(p.X) && math.IsNaN(p.Y) }
+func (g LineString) IsEmpty() bool         { return len(g) == 0 }
+func (g Polygon) IsEmpty() bool            { return len(g) == 0 }
+func (g MultiPoint) IsEmpty() bool         { return len(g) == 0 }
+func (g MultiLineString) IsEmpty() bool    { return len(g) == 0 }
+func (g MultiPolygon) IsEmpty() bool       { return len(g) == 0 }
+func (g GeometryCollection) IsEmpty() bool { return len(g) == 0 }
+
+func (p Point) String() string              { return mustWKT(p) }
+func (g LineString) String() string         { return mustWKT(g) }
+func (g Polygon) String() string            { return mustWKT(g) }
+func (g MultiPoint) String() string         { return mustWKT(g) }
+func (g MultiLineString) String() string    { return mustWKT(g) }
+func (g MultiPolygon) String() string       { return mustWKT(g) }
+func (g GeometryCollection) String() string { return mustWKT(g) }
+
+// Value implements driver.Valuer, sending the point as WKT.
+func (p Point) Value() (driver.Value, error) { return MarshalWKT(p) }
+
+// Value implements driver.Valuer, sending the line string as WKT.
+func (g LineString) Value() (driver.Value, error) { return MarshalWKT(g) }
+
+// Value implements driver.Valuer, sending the polygon as WKT.
+func (g Polygon) Value() (driver.Value, error) { return MarshalWKT(g) }
+
+// Value implements driver.Valuer, sending the points as WKT.
+func (g MultiPoint) Value() (driver.Value, error) { return MarshalWKT(g) }
+
+// Value implements driver.Valuer, sending the line strings as WKT.
+func (g MultiLineString) Value() (driver.Value, error) { return MarshalWKT(g) }
+
+// Value implements driver.Valuer, sending the polygons as WKT.
+func (g MultiPolygon) Value() (driver.Value, error) { return MarshalWKT(g) }
+
+// Value implements driver.Valuer, sending the collection as WKT.
+func (g GeometryCollection) Value() (driver.Value, error) { return MarshalWKT(g) }
+
+func mustWKT(g Geometry) string {
+	s, err := MarshalWKT(g)
+	if err != nil {
+		return "<invalid geometry: " + err.Error() + ">"
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package geo
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Shape is a geometry with its spatial reference system identifier. It
+// implements sql.Scanner for Geometry and Geography columns in any
+// geometry_output_format, and driver.Valuer for parameters.
+//
+// A nil Geometry is SQL NULL. An SRID of 0 means the SRID is unknown.
+type Shape struct {
+	Geometry Geometry
+	SRID     int
+}
+
+// Parse decodes a geometry in any of the formats Databend returns: WKB or
+// EWKB as bytes or hex, WKT, EWKT or GeoJSON.
+func Parse(data []byte) (Shape, error) {
+	if len(data) > 0 && (data[0] == 0 || data[0] == 1) {
+		return UnmarshalWKB(data)
+	}
+	text := strings.TrimSpace(string(data))
+	switch {
+	case text == "":
+		return Shape{}, fmt.Errorf("geo: empty geometry")
+	case text[0] == '{':
+		g, err := UnmarshalGeoJSON([]byte(text))
+		if err != nil {
+			return Shape{}, err
+		}
+		return Shape{Geometry: g}, nil
+	case isHexWKB(text):
+		raw, err := hex.DecodeString(text)
+		if err != nil {
+			return Shape{}, fmt.Errorf("geo: invalid hex WKB: %w", err)
+		}
+		return UnmarshalWKB(raw)
+	default:
+		return UnmarshalWKT(text)
+	}
+}
+
+// isHexWKB reports whether s looks like hex encoded WKB, which starts with the
+// byte order 00 or 01. WKT always starts with a letter.
+func isHexWKB(s string) bool {
+	if len(s) < 10 || s[0] != '0' || (s[1] != '0' && s[1] != '1') {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the EWKT of the shape, or "NULL".
+func (s Shape) String() string {
+	if s.Geometry == nil {
+		return "NULL"
+	}
+	text, err := MarshalEWKT(s.Geometry, s.SRID)
+	if err != nil {
+		return "<invalid geometry: " + err.Error() + ">"
+	}
+	return text
+}
+
+// Value implements driver.Valuer, sending the shape as EWKT, or as WKT if
+// the SRID is 0.
+func (s Shape) Value() (driver.Value, error) {
+	if s.Geometry == nil {
+		return nil, nil
+	}
+	return MarshalEWKT(s.Geometry, s.SRID)
+}
+
+// Scan implements sql.Scanner
+func (s *Shape) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*s = Shape{}
+		return nil
+	case []byte:
+		return s.scan(v)
+	case string:
+		return s.scan([]byte(v))
+	case Shape:
+		*s = v
+		return nil
+	default:
+		return fmt.Errorf("geo: cannot scan %T into Shape", src)
+	}
+}
+
+func (s *Shape) scan(data []byte) error {
+	shape, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	*s = shape
+	return nil
+}
@@ -0,0 +1,58 @@
+package geo
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShapeScan(t *testing.T) {
+	want := Shape{Geometry: Point{X: 1, Y: 2}, SRID: 4326}
+	ewkb, err := MarshalEWKB(want.Geometry, want.SRID)
+	require.NoError(t, err)
+
+	for _, src := range []any{
+		ewkb,
+		"0101000020E6100000000000000000F03F0000000000000040",
+		"SRID=4326;POINT(1 2)",
+		want,
+	} {
+		var s Shape
+		require.NoError(t, s.Scan(src))
+		assert.Equal(t, want, s)
+	}
+
+	var s Shape
+	require.NoError(t, s.Scan("POINT(1 2)"))
+	assert.Equal(t, Shape{Geometry: Point{X: 1, Y: 2}}, s)
+
+	require.NoError(t, s.Scan(`{"type":"LineString","coordinates":[[0,0],[1,1]]}`))
+	assert.Equal(t, Shape{Geometry: LineString{{X: 0, Y: 0}, {X: 1, Y: 1}}}, s)
+
+	require.NoError(t, s.Scan(nil))
+	assert.Equal(t, Shape{}, s)
+
+	assert.Error(t, s.Scan("not a geometry"))
+	assert.Error(t, s.Scan(42))
+}
+
+func TestShapeValue(t *testing.T) {
+	v, err := Shape{Geometry: Point{X: 1, Y: 2}, SRID: 4326}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "SRID=4326;POINT(1 2)", v)
+
+	v, err = Shape{Geometry: Point{X: 1, Y: 2}}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "POINT(1 2)", v)
+
+	v, err = Shape{}.Value()
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	var valuer driver.Valuer = Polygon{{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 0}}}
+	v, err = valuer.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "POLYGON((0 0,1 0,0 0))", v)
+}
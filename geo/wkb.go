@@ -0,0 +1,317 @@
+package geo
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+const (
+	wkbPoint              uint32 = 1
+	wkbLineString         uint32 = 2
+	wkbPolygon            uint32 = 3
+	wkbMultiPoint         uint32 = 4
+	wkbMultiLineString    uint32 = 5
+	wkbMultiPolygon       uint32 = 6
+	wkbGeometryCollection uint32 = 7
+
+	ewkbZ    uint32 = 0x80000000
+	ewkbM    uint32 = 0x40000000
+	ewkbSRID uint32 = 0x20000000
+)
+
+// MarshalWKB encodes g as little-endian WKB.
+func MarshalWKB(g Geometry) ([]byte, error) {
+	return appendWKB(nil, g, 0, false)
+}
+
+// MarshalEWKB encodes g as little-endian EWKB, which is WKB with the SRID.
+// An SRID of 0 is omitted.
+func MarshalEWKB(g Geometry, srid int) ([]byte, error) {
+	return appendWKB(nil, g, srid, srid != 0)
+}
+
+// UnmarshalWKB decodes WKB or EWKB. The SRID is 0 for plain WKB.
+func UnmarshalWKB(data []byte) (Shape, error) {
+	r := &wkbReader{data: data}
+	g, srid, err := r.geometry(true)
+	if err != nil {
+		return Shape{}, err
+	}
+	if r.pos != len(data) {
+		return Shape{}, fmt.Errorf("geo: %d trailing bytes after WKB geometry", len(data)-r.pos)
+	}
+	return Shape{Geometry: g, SRID: srid}, nil
+}
+
+func wkbType(g Geometry) (uint32, error) {
+	switch g.(type) {
+	case Point:
+		return wkbPoint, nil
+	case LineString:
+		return wkbLineString, nil
+	case Polygon:
+		return wkbPolygon, nil
+	case MultiPoint:
+		return wkbMultiPoint, nil
+	case MultiLineString:
+		return wkbMultiLineString, nil
+	case MultiPolygon:
+		return wkbMultiPolygon, nil
+	case GeometryCollection:
+		return wkbGeometryCollection, nil
+	default:
+		return 0, fmt.Errorf("geo: unsupported geometry %T", g)
+	}
+}
+
+func appendWKB(buf []byte, g Geometry, srid int, withSRID bool) ([]byte, error) {
+	typ, err := wkbType(g)
+	if err != nil {
+		return nil, err
+	}
+	buf = append(buf, 1) // little endian
+	if withSRID {
+		buf = binary.LittleEndian.AppendUint32(buf, typ|ewkbSRID)
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(srid))
+	} else {
+		buf = binary.LittleEndian.AppendUint32(buf, typ)
+	}
+
+	switch g := g.(type) {
+	case Point:
+		buf = appendWKBPoint(buf, g)
+	case LineString:
+		buf = appendWKBPoints(buf, g)
+	case Polygon:
+		buf = appendWKBRings(buf, g)
+	case MultiPoint:
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(g)))
+		for _, p := range g {
+			buf, _ = appendWKB(buf, p, 0, false)
+		}
+	case MultiLineString:
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(g)))
+		for _, l := range g {
+			buf, _ = appendWKB(buf, l, 0, false)
+		}
+	case MultiPolygon:
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(g)))
+		for _, p := range g {
+			buf, _ = appendWKB(buf, p, 0, false)
+		}
+	case GeometryCollection:
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(len(g)))
+		for _, child := range g {
+			if buf, err = appendWKB(buf, child, 0, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buf, nil
+}
+
+func appendWKBPoint(buf []byte, p Point) []byte {
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(p.X))
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(p.Y))
+}
+
+func appendWKBPoints(buf []byte, points []Point) []byte {
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(points)))
+	for _, p := range points {
+		buf = appendWKBPoint(buf, p)
+	}
+	return buf
+}
+
+func appendWKBRings(buf []byte, rings []LineString) []byte {
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(rings)))
+	for _, ring := range rings {
+		buf = appendWKBPoints(buf, ring)
+	}
+	return buf
+}
+
+type wkbReader struct {
+	data  []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+func (r *wkbReader) read(n int) ([]byte, error) {
+	if len(r.data)-r.pos < n {
+		return nil, fmt.Errorf("geo: truncated WKB at offset %d", r.pos)
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *wkbReader) uint32() (uint32, error) {
+	b, err := r.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return r.order.Uint32(b), nil
+}
+
+// count reads an element count, checking it against the remaining bytes so
+// that corrupt input cannot cause a huge allocation.
+func (r *wkbReader) count(minSize int) (int, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return 0, err
+	}
+	if int64(n)*int64(minSize) > int64(len(r.data)-r.pos) {
+		return 0, fmt.Errorf("geo: truncated WKB at offset %d", r.pos)
+	}
+	return int(n), nil
+}
+
+func (r *wkbReader) point() (Point, error) {
+	b, err := r.read(16)
+	if err != nil {
+		return Point{}, err
+	}
+	return Point{
+		X: math.Float64frombits(r.order.Uint64(b)),
+		Y: math.Float64frombits(r.order.Uint64(b[8:])),
+	}, nil
+}
+
+func (r *wkbReader) points() ([]Point, error) {
+	n, err := r.count(16)
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	points := make([]Point, n)
+	for i := range points {
+		if points[i], err = r.point(); err != nil {
+			return nil, err
+		}
+	}
+	return points, nil
+}
+
+func (r *wkbReader) rings() ([]LineString, error) {
+	n, err := r.count(4)
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	rings := make([]LineString, n)
+	for i := range rings {
+		if rings[i], err = r.points(); err != nil {
+			return nil, err
+		}
+	}
+	return rings, nil
+}
+
+func (r *wkbReader) geometry(top bool) (Geometry, int, error) {
+	b, err := r.read(1)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch b[0] {
+	case 0:
+		r.order = binary.BigEndian
+	case 1:
+		r.order = binary.LittleEndian
+	default:
+		return nil, 0, fmt.Errorf("geo: invalid WKB byte order %d", b[0])
+	}
+	typ, err := r.uint32()
+	if err != nil {
+		return nil, 0, err
+	}
+	srid := 0
+	if typ&ewkbSRID != 0 {
+		if !top {
+			return nil, 0, fmt.Errorf("geo: SRID on a nested WKB geometry")
+		}
+		s, err := r.uint32()
+		if err != nil {
+			return nil, 0, err
+		}
+		srid = int(int32(s))
+	}
+	if typ&(ewkbZ|ewkbM) != 0 || typ&0x0fffffff >= 1000 {
+		return nil, 0, fmt.Errorf("geo: unsupported WKB geometry type %#x, only 2D geometries are supported", typ)
+	}
+
+	var g Geometry
+	switch typ &^ ewkbSRID {
+	case wkbPoint:
+		g, err = r.point()
+	case wkbLineString:
+		var points []Point
+		points, err = r.points()
+		g = LineString(points)
+	case wkbPolygon:
+		var rings []LineString
+		rings, err = r.rings()
+		g = Polygon(rings)
+	case wkbMultiPoint, wkbMultiLineString, wkbMultiPolygon, wkbGeometryCollection:
+		g, err = r.collection(typ &^ ewkbSRID)
+	default:
+		return nil, 0, fmt.Errorf("geo: unsupported WKB geometry type %d", typ)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return g, srid, nil
+}
+
+func (r *wkbReader) collection(typ uint32) (Geometry, error) {
+	// each member is at least a byte order, a type and a count or a point
+	n, err := r.count(9)
+	if err != nil {
+		return nil, err
+	}
+	var members []Geometry
+	if n > 0 {
+		members = make([]Geometry, n)
+	}
+	for i := range members {
+		order := r.order
+		if members[i], _, err = r.geometry(false); err != nil {
+			return nil, err
+		}
+		r.order = order
+	}
+
+	switch typ {
+	case wkbMultiPoint:
+		var g MultiPoint
+		for _, m := range members {
+			p, ok := m.(Point)
+			if !ok {
+				return nil, fmt.Errorf("geo: %s in WKB MultiPoint", m.GeometryType())
+			}
+			g = append(g, p)
+		}
+		return g, nil
+	case wkbMultiLineString:
+		var g MultiLineString
+		for _, m := range members {
+			l, ok := m.(LineString)
+			if !ok {
+				return nil, fmt.Errorf("geo: %s in WKB MultiLineString", m.GeometryType())
+			}
+			g = append(g, l)
+		}
+		return g, nil
+	case wkbMultiPolygon:
+		var g MultiPolygon
+		for _, m := range members {
+			p, ok := m.(Polygon)
+			if !ok {
+				return nil, fmt.Errorf("geo: %s in WKB MultiPolygon", m.GeometryType())
+			}
+			g = append(g, p)
+		}
+		return g, nil
+	default:
+		return GeometryCollection(members), nil
+	}
+}
@@ -0,0 +1,86 @@
+package geo
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testGeometries = []Geometry{
+	Point{X: 1, Y: 2},
+	LineString{{X: 0, Y: 0}, {X: 1.5, Y: -2.25}},
+	Polygon{
+		{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 4}, {X: 0, Y: 0}},
+		{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 2, Y: 2}, {X: 1, Y: 1}},
+	},
+	MultiPoint{{X: 1, Y: 2}, {X: 3, Y: 4}},
+	MultiLineString{{{X: 0, Y: 0}, {X: 1, Y: 1}}, {{X: 2, Y: 2}, {X: 3, Y: 3}}},
+	MultiPolygon{
+		{{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 0}}},
+		{{{X: 5, Y: 5}, {X: 6, Y: 5}, {X: 6, Y: 6}, {X: 5, Y: 5}}},
+	},
+	GeometryCollection{Point{X: 1, Y: 2}, LineString{{X: 0, Y: 0}, {X: 1, Y: 1}}},
+	LineString(nil),
+	GeometryCollection(nil),
+}
+
+func TestWKBRoundTrip(t *testing.T) {
+	for _, g := range testGeometries {
+		t.Run(g.GeometryType(), func(t *testing.T) {
+			data, err := MarshalWKB(g)
+			require.NoError(t, err)
+			shape, err := UnmarshalWKB(data)
+			require.NoError(t, err)
+			assert.Equal(t, g, shape.Geometry)
+			assert.Equal(t, 0, shape.SRID)
+
+			data, err = MarshalEWKB(g, 4326)
+			require.NoError(t, err)
+			shape, err = UnmarshalWKB(data)
+			require.NoError(t, err)
+			assert.Equal(t, g, shape.Geometry)
+			assert.Equal(t, 4326, shape.SRID)
+		})
+	}
+}
+
+func TestUnmarshalWKB(t *testing.T) {
+	decode := func(s string) (Shape, error) {
+		data, err := hex.DecodeString(s)
+		require.NoError(t, err)
+		return UnmarshalWKB(data)
+	}
+
+	shape, err := decode("0101000000000000000000F03F0000000000000040")
+	require.NoError(t, err)
+	assert.Equal(t, Shape{Geometry: Point{X: 1, Y: 2}}, shape)
+
+	// big endian EWKB
+	shape, err = decode("0020000001000010E63FF00000000000004000000000000000")
+	require.NoError(t, err)
+	assert.Equal(t, Shape{Geometry: Point{X: 1, Y: 2}, SRID: 4326}, shape)
+
+	shape, err = decode("0101000000000000000000F87F000000000000F87F")
+	require.NoError(t, err)
+	assert.True(t, shape.Geometry.IsEmpty())
+
+	data, err := MarshalEWKB(Point{X: 1, Y: 2}, 4326)
+	require.NoError(t, err)
+	assert.Equal(t, "0101000020E6100000000000000000F03F0000000000000040", strings.ToUpper(hex.EncodeToString(data)))
+
+	for _, invalid := range []string{
+		"",
+		"02",
+		"0101000000000000000000F03F",
+		"0101000000000000000000F03F000000000000004000",
+		"01E9030000000000000000F03F00000000000000400000000000000840",
+		"0108000000",
+		"010200000000FFFFFF",
+	} {
+		_, err := decode(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,371 @@
+package geo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MarshalWKT formats g as WKT, e.g. `POINT(1 2)`.
+func MarshalWKT(g Geometry) (string, error) {
+	var sb strings.Builder
+	if err := writeWKT(&sb, g); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// MarshalEWKT formats g as EWKT, e.g. `SRID=4326;POINT(1 2)`. An SRID of 0 is
+// omitted.
+func MarshalEWKT(g Geometry, srid int) (string, error) {
+	s, err := MarshalWKT(g)
+	if err != nil || srid == 0 {
+		return s, err
+	}
+	return "SRID=" + strconv.Itoa(srid) + ";" + s, nil
+}
+
+// UnmarshalWKT parses WKT or EWKT. The SRID is 0 for plain WKT.
+func UnmarshalWKT(s string) (Shape, error) {
+	var shape Shape
+	text := strings.TrimSpace(s)
+	if len(text) >= 5 && strings.EqualFold(text[:5], "SRID=") {
+		prefix, rest, ok := strings.Cut(text[5:], ";")
+		srid, err := strconv.Atoi(strings.TrimSpace(prefix))
+		if !ok || err != nil {
+			return Shape{}, fmt.Errorf("geo: invalid EWKT SRID in %q", s)
+		}
+		shape.SRID = srid
+		text = rest
+	}
+
+	p := &wktParser{s: text}
+	g, err := p.geometry()
+	if err == nil && p.next() != "" {
+		err = fmt.Errorf("unexpected %q", p.tok)
+	}
+	if err != nil {
+		return Shape{}, fmt.Errorf("geo: invalid WKT %q: %w", s, err)
+	}
+	shape.Geometry = g
+	return shape, nil
+}
+
+func writeWKT(sb *strings.Builder, g Geometry) error {
+	name, err := wktName(g)
+	if err != nil {
+		return err
+	}
+	sb.WriteString(name)
+	if g.IsEmpty() {
+		sb.WriteString(" EMPTY")
+		return nil
+	}
+
+	switch g := g.(type) {
+	case Point:
+		sb.WriteByte('(')
+		writeWKTCoord(sb, g)
+		sb.WriteByte(')')
+	case LineString:
+		writeWKTPoints(sb, g)
+	case Polygon:
+		writeWKTRings(sb, g)
+	case MultiPoint:
+		sb.WriteByte('(')
+		for i, p := range g {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			if p.IsEmpty() {
+				sb.WriteString("EMPTY")
+			} else {
+				writeWKTCoord(sb, p)
+			}
+		}
+		sb.WriteByte(')')
+	case MultiLineString:
+		writeWKTRings(sb, g)
+	case MultiPolygon:
+		sb.WriteByte('(')
+		for i, polygon := range g {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			writeWKTRings(sb, polygon)
+		}
+		sb.WriteByte(')')
+	case GeometryCollection:
+		sb.WriteByte('(')
+		for i, child := range g {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			if err := writeWKT(sb, child); err != nil {
+				return err
+			}
+		}
+		sb.WriteByte(')')
+	}
+	return nil
+}
+
+func wktName(g Geometry) (string, error) {
+	switch g.(type) {
+	case Point, LineString, Polygon, MultiPoint, MultiLineString, MultiPolygon, GeometryCollection:
+		return strings.ToUpper(g.GeometryType()), nil
+	default:
+		return "", fmt.Errorf("geo: unsupported geometry %T", g)
+	}
+}
+
+func writeWKTCoord(sb *strings.Builder, p Point) {
+	sb.WriteString(formatCoord(p.X))
+	sb.WriteByte(' ')
+	sb.WriteString(formatCoord(p.Y))
+}
+
+func writeWKTPoints(sb *strings.Builder, points []Point) {
+	if len(points) == 0 {
+		sb.WriteString("EMPTY")
+		return
+	}
+	sb.WriteByte('(')
+	for i, p := range points {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		writeWKTCoord(sb, p)
+	}
+	sb.WriteByte(')')
+}
+
+func writeWKTRings(sb *strings.Builder, rings []LineString) {
+	if len(rings) == 0 {
+		sb.WriteString("EMPTY")
+		return
+	}
+	sb.WriteByte('(')
+	for i, ring := range rings {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		writeWKTPoints(sb, ring)
+	}
+	sb.WriteByte(')')
+}
+
+func formatCoord(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// wktParser is a recursive-descent parser over the tokens of a WKT string:
+// words, numbers, parentheses and commas.
+type wktParser struct {
+	s   string
+	pos int
+	tok string
+	// peeked is set when tok has been read but not consumed.
+	peeked bool
+}
+
+func (p *wktParser) next() string {
+	if p.peeked {
+		p.peeked = false
+		return p.tok
+	}
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	if p.pos == len(p.s) {
+		p.tok = ""
+		return p.tok
+	}
+	start := p.pos
+	if c := p.s[p.pos]; c == '(' || c == ')' || c == ',' {
+		p.pos++
+	} else {
+		for p.pos < len(p.s) && strings.IndexByte(" \t\r\n(),", p.s[p.pos]) < 0 {
+			p.pos++
+		}
+	}
+	p.tok = p.s[start:p.pos]
+	return p.tok
+}
+
+func (p *wktParser) peek() string {
+	tok := p.next()
+	p.peeked = true
+	return tok
+}
+
+func (p *wktParser) expect(tok string) error {
+	if got := p.next(); got != tok {
+		if got == "" {
+			return fmt.Errorf("expected %q, got end of input", tok)
+		}
+		return fmt.Errorf("expected %q, got %q", tok, got)
+	}
+	return nil
+}
+
+// empty consumes EMPTY or an opening parenthesis, reporting which it was.
+func (p *wktParser) empty() (bool, error) {
+	if strings.EqualFold(p.peek(), "EMPTY") {
+		p.next()
+		return true, nil
+	}
+	return false, p.expect("(")
+}
+
+// list parses the comma-separated items up to the closing parenthesis.
+func (p *wktParser) list(item func() error) error {
+	for {
+		if err := item(); err != nil {
+			return err
+		}
+		switch tok := p.next(); tok {
+		case ",":
+		case ")":
+			return nil
+		default:
+			return fmt.Errorf("expected \",\" or \")\", got %q", tok)
+		}
+	}
+}
+
+func (p *wktParser) geometry() (Geometry, error) {
+	name := strings.ToUpper(p.next())
+	switch tok := strings.ToUpper(p.peek()); tok {
+	case "Z", "M", "ZM":
+		return nil, fmt.Errorf("%s %s geometries are not supported, only 2D", name, tok)
+	}
+
+	switch name {
+	case "POINT":
+		if empty, err := p.empty(); err != nil || empty {
+			return EmptyPoint(), err
+		}
+		pt, err := p.coord()
+		if err != nil {
+			return nil, err
+		}
+		return pt, p.expect(")")
+	case "LINESTRING":
+		points, err := p.points()
+		return LineString(points), err
+	case "POLYGON":
+		rings, err := p.rings()
+		return Polygon(rings), err
+	case "MULTIPOINT":
+		return p.multiPoint()
+	case "MULTILINESTRING":
+		rings, err := p.rings()
+		return MultiLineString(rings), err
+	case "MULTIPOLYGON":
+		var g MultiPolygon
+		if empty, err := p.empty(); err != nil || empty {
+			return g, err
+		}
+		err := p.list(func() error {
+			rings, err := p.rings()
+			g = append(g, rings)
+			return err
+		})
+		return g, err
+	case "GEOMETRYCOLLECTION":
+		var g GeometryCollection
+		if empty, err := p.empty(); err != nil || empty {
+			return g, err
+		}
+		err := p.list(func() error {
+			child, err := p.geometry()
+			g = append(g, child)
+			return err
+		})
+		return g, err
+	case "":
+		return nil, fmt.Errorf("empty geometry")
+	default:
+		return nil, fmt.Errorf("unknown geometry type %q", name)
+	}
+}
+
+func (p *wktParser) number() (float64, error) {
+	tok := p.next()
+	v, err := strconv.ParseFloat(tok, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid coordinate %q", tok)
+	}
+	return v, nil
+}
+
+func (p *wktParser) coord() (Point, error) {
+	x, err := p.number()
+	if err != nil {
+		return Point{}, err
+	}
+	y, err := p.number()
+	if err != nil {
+		return Point{}, err
+	}
+	if tok := p.peek(); tok != "," && tok != ")" {
+		return Point{}, fmt.Errorf("only 2D coordinates are supported")
+	}
+	return Point{X: x, Y: y}, nil
+}
+
+func (p *wktParser) points() ([]Point, error) {
+	if empty, err := p.empty(); err != nil || empty {
+		return nil, err
+	}
+	var points []Point
+	err := p.list(func() error {
+		pt, err := p.coord()
+		points = append(points, pt)
+		return err
+	})
+	return points, err
+}
+
+func (p *wktParser) rings() ([]LineString, error) {
+	if empty, err := p.empty(); err != nil || empty {
+		return nil, err
+	}
+	var rings []LineString
+	err := p.list(func() error {
+		points, err := p.points()
+		rings = append(rings, points)
+		return err
+	})
+	return rings, err
+}
+
+// multiPoint accepts both `MULTIPOINT((1 2),(3 4))` and `MULTIPOINT(1 2,3 4)`.
+func (p *wktParser) multiPoint() (MultiPoint, error) {
+	var g MultiPoint
+	if empty, err := p.empty(); err != nil || empty {
+		return g, err
+	}
+	err := p.list(func() error {
+		switch tok := p.peek(); {
+		case strings.EqualFold(tok, "EMPTY"):
+			p.next()
+			g = append(g, EmptyPoint())
+			return nil
+		case tok == "(":
+			p.next()
+			pt, err := p.coord()
+			if err != nil {
+				return err
+			}
+			g = append(g, pt)
+			return p.expect(")")
+		default:
+			pt, err := p.coord()
+			g = append(g, pt)
+			return err
+		}
+	})
+	return g, err
+}
@@ -0,0 +1,92 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalWKT(t *testing.T) {
+	tests := []struct {
+		geometry Geometry
+		wkt      string
+	}{
+		{Point{X: 1, Y: 2}, "POINT(1 2)"},
+		{Point{X: -0.5, Y: 1e21}, "POINT(-0.5 1000000000000000000000)"},
+		{EmptyPoint(), "POINT EMPTY"},
+		{LineString{{X: 0, Y: 0}, {X: 1.5, Y: 2}}, "LINESTRING(0 0,1.5 2)"},
+		{LineString(nil), "LINESTRING EMPTY"},
+		{Polygon{{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 0}}}, "POLYGON((0 0,1 0,1 1,0 0))"},
+		{MultiPoint{{X: 1, Y: 2}, EmptyPoint()}, "MULTIPOINT(1 2,EMPTY)"},
+		{MultiLineString{{{X: 0, Y: 0}, {X: 1, Y: 1}}, nil}, "MULTILINESTRING((0 0,1 1),EMPTY)"},
+		{MultiPolygon{{{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 0}}}}, "MULTIPOLYGON(((0 0,1 0,0 0)))"},
+		{GeometryCollection{Point{X: 1, Y: 2}, MultiPoint(nil)}, "GEOMETRYCOLLECTION(POINT(1 2),MULTIPOINT EMPTY)"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.wkt, func(t *testing.T) {
+			s, err := MarshalWKT(tc.geometry)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wkt, s)
+			assert.Equal(t, tc.wkt, tc.geometry.(interface{ String() string }).String())
+
+			shape, err := UnmarshalWKT(s)
+			require.NoError(t, err)
+			again, err := MarshalWKT(shape.Geometry)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wkt, again)
+		})
+	}
+
+	s, err := MarshalEWKT(Point{X: 1, Y: 2}, 4326)
+	require.NoError(t, err)
+	assert.Equal(t, "SRID=4326;POINT(1 2)", s)
+
+	_, err = MarshalWKT(GeometryCollection{nil})
+	assert.Error(t, err)
+}
+
+func TestUnmarshalWKT(t *testing.T) {
+	tests := []struct {
+		input string
+		shape Shape
+	}{
+		{"POINT(1 2)", Shape{Geometry: Point{X: 1, Y: 2}}},
+		{"  point ( 1.5  -2e3 ) ", Shape{Geometry: Point{X: 1.5, Y: -2000}}},
+		{"SRID=4326;POINT(1 2)", Shape{Geometry: Point{X: 1, Y: 2}, SRID: 4326}},
+		{"srid=3857; LINESTRING(0 0, 1 1)", Shape{Geometry: LineString{{X: 0, Y: 0}, {X: 1, Y: 1}}, SRID: 3857}},
+		{"MULTIPOINT((1 2),(3 4))", Shape{Geometry: MultiPoint{{X: 1, Y: 2}, {X: 3, Y: 4}}}},
+		{"MULTIPOINT(1 2, 3 4)", Shape{Geometry: MultiPoint{{X: 1, Y: 2}, {X: 3, Y: 4}}}},
+		{"POLYGON EMPTY", Shape{Geometry: Polygon(nil)}},
+		{
+			"GEOMETRYCOLLECTION(POINT(1 2), GEOMETRYCOLLECTION(LINESTRING(0 0,1 1)))",
+			Shape{Geometry: GeometryCollection{
+				Point{X: 1, Y: 2},
+				GeometryCollection{LineString{{X: 0, Y: 0}, {X: 1, Y: 1}}},
+			}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			shape, err := UnmarshalWKT(tc.input)
+			require.NoError(t, err)
+			assert.Equal(t, tc.shape, shape)
+		})
+	}
+
+	for _, invalid := range []string{
+		"",
+		"POINT",
+		"POINT(1)",
+		"POINT(1 2 3)",
+		"POINT Z (1 2 3)",
+		"POINT(1 2",
+		"POINT(1 2) x",
+		"LINESTRING(0 0;1 1)",
+		"CIRCLE(0 0)",
+		"SRID=x;POINT(1 2)",
+	} {
+		_, err := UnmarshalWKT(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
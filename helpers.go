@@ -1,7 +1,6 @@
 package godatabend
 
 import (
-	"fmt"
 	"strings"
 	"time"
 
@@ -32,20 +31,42 @@ func formatDate(value time.Time) string {
 }
 
 func getTableFromInsertQuery(query string) (string, error) {
-	if !strings.Contains(query, "insert") && !strings.Contains(query, "INSERT") {
+	table, _, ok := parseSQLStatement(query).insertTarget()
+	if !ok {
 		return "", errors.New("wrong insert statement")
 	}
-	splitQuery := strings.Split(query, " ")
-	if len(splitQuery) > 2 {
-		return strings.TrimSpace(splitQuery[2]), nil
+	return table, nil
+}
+
+// getTableNameFromInsertQuery returns the database, if given, and the table of
+// an insert statement, unquoted.
+func getTableNameFromInsertQuery(query string) (string, string, error) {
+	table, err := getTableFromInsertQuery(query)
+	if err != nil {
+		return "", "", err
 	}
-	return "", errors.New("wrong insert")
+	return splitTableName(table)
+}
+
+// splitTableName splits a table name written as in SQL, e.g. `db.t`, into its
+// database, if given, and table, unquoted.
+func splitTableName(name string) (string, string, error) {
+	parts, ok := splitQualifiedName(name)
+	switch {
+	case !ok:
+		return "", "", errors.Errorf("invalid table name %s", name)
+	case len(parts) == 1:
+		return "", parts[0], nil
+	case len(parts) == 2:
+		return parts[0], parts[1], nil
+	}
+	return "", "", errors.Errorf("unsupported table name %s", name)
 }
 
 func generateDescTable(query string) (string, error) {
-	table, err := getTableFromInsertQuery(query)
+	database, table, err := getTableNameFromInsertQuery(query)
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("DESC %s", table), nil
+	return "DESC " + QuoteQualifiedName(database, table), nil
 }
@@ -10,6 +10,9 @@ func TestGetTableFromInsertQuery(t *testing.T) {
 	var args = []string{
 		"insert into example",
 		"INSERT INTO example",
+		"INSERT  INTO\texample VALUES",
+		"INSERT INTO example(a, b) VALUES",
+		"insert overwrite into example select 1",
 	}
 	for i := range args {
 		table, err := getTableFromInsertQuery(args[i])
@@ -19,6 +22,7 @@ func TestGetTableFromInsertQuery(t *testing.T) {
 	var wrongArgs = []string{
 		"create table example",
 		"inssert int example",
+		"select 'insert into example'",
 	}
 	for i := range wrongArgs {
 		table, err := getTableFromInsertQuery(wrongArgs[i])
@@ -35,6 +39,40 @@ func TestGenerateDescTable(t *testing.T) {
 	for i := range args {
 		desc, err := generateDescTable(args[i])
 		assert.NoError(t, err)
-		assert.Equal(t, "DESC example", desc)
+		assert.Equal(t, "DESC `example`", desc)
+	}
+}
+
+func TestGetQuotedTableFromInsertQuery(t *testing.T) {
+	table, err := getTableFromInsertQuery("INSERT INTO `my db`.\"Order\" VALUES")
+	assert.NoError(t, err)
+	assert.Equal(t, "`my db`.\"Order\"", table)
+}
+
+func TestGenerateDescQuotedTable(t *testing.T) {
+	testCases := []struct {
+		query string
+		want  string
+	}{
+		{"INSERT INTO Db.Example VALUES", "DESC `db`.`example`"},
+		{"INSERT INTO `my db`.\"Order\" VALUES", "DESC `my db`.`Order`"},
+		{"INSERT INTO `a``b; DROP TABLE t` VALUES", "DESC `a``b; DROP TABLE t`"},
+	}
+	for _, tc := range testCases {
+		desc, err := generateDescTable(tc.query)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.want, desc)
+	}
+}
+
+func TestSplitTableName(t *testing.T) {
+	database, table, err := splitTableName("`my db`.T")
+	assert.NoError(t, err)
+	assert.Equal(t, "my db", database)
+	assert.Equal(t, "t", table)
+
+	for _, name := range []string{"", "t;", "a.b.c", "t x"} {
+		_, _, err := splitTableName(name)
+		assert.Error(t, err, name)
 	}
 }
@@ -2,108 +2,241 @@ package godatabend
 
 import (
 	"database/sql/driver"
-	"github.com/pkg/errors"
-	"reflect"
+	"fmt"
+	"strconv"
+	"strings"
 )
 
-func buildQuery(query string, args []driver.Value, placeholders *[]int) (string, error) {
-	var q string
-	var err error
-	if placeholders != nil {
-		if len(*placeholders) != len(args) {
-			return "", errors.Errorf("expect %v args, got %v", len(*placeholders), len(args))
-		}
-		q, err = interpolateParams2(query, args, *placeholders)
-		if err != nil {
-			return "", err
+type placeholderKind uint8
+
+const (
+	// placeholderPositional is a bare `?`, bound to the next positional argument.
+	placeholderPositional placeholderKind = iota
+	// placeholderNumbered is `$N`, bound to the N-th (1-based) positional argument.
+	placeholderNumbered
+	// placeholderNamed is `:name` or `@name`, bound to a sql.Named argument.
+	placeholderNamed
+)
+
+// placeholder is a parameter reference found in a query, with its byte range.
+type placeholder struct {
+	kind    placeholderKind
+	start   int
+	end     int
+	ordinal int
+	name    string
+}
+
+// numInput reports the number of arguments the placeholders expect, or -1
+// when it cannot be known ahead of time (named parameters). Variant paths and
+// stage references are not placeholders, so they do not affect the count.
+func numInput(params []placeholder) int {
+	n := 0
+	for _, p := range params {
+		switch p.kind {
+		case placeholderPositional:
+			n++
+		case placeholderNumbered:
+			if p.ordinal > n {
+				n = p.ordinal
+			}
+		case placeholderNamed:
+			return -1
 		}
-		return q, nil
 	}
-	if len(args) > 0 && args[0] != nil {
-		result, err := interpolateParams(query, args)
-		if err != nil {
-			return result, errors.Wrap(err, "buildRequest: failed to interpolate params")
-		}
-		return result, nil
+	return n
+}
+
+func buildQuery(enc encoder, query string, args []driver.NamedValue, params []placeholder) (string, error) {
+	if len(args) == 0 {
+		return query, nil
 	}
-	return query, nil
+	if params == nil {
+		params = placeholders(query)
+	}
+	return bindParams(enc, query, params, args)
 }
 
-func placeholders(query string) []int {
-	n := 0
-	quote := false
-	first := -1
-	for i := 0; i < len(query); i++ {
-		switch query[i] {
-		case '\\':
-			i++
-		case '\'':
-			quote = !quote
+func placeholders(query string) []placeholder {
+	var params []placeholder
+	tokens := tokenizeSQL(query)
+	for i, tok := range tokens {
+		if tok.kind != sqlTokenPlaceholder {
+			continue
+		}
+		p := placeholder{start: tok.start, end: tok.end}
+		switch text := tok.text(query); text[0] {
 		case '?':
-			if !quote {
-				n++
-				if first == -1 {
-					first = i
-				}
+			p.kind = placeholderPositional
+		case '$':
+			ordinal, err := strconv.Atoi(text[1:])
+			if err != nil || ordinal == 0 {
+				continue
+			}
+			p.kind = placeholderNumbered
+			p.ordinal = ordinal
+		default:
+			if !isNamedParameter(query, tokens, i) {
+				continue
 			}
+			p.kind = placeholderNamed
+			p.name = text[1:]
 		}
+		params = append(params, p)
 	}
-	if n == 0 {
-		return nil
+	return params
+}
+
+// stageKeywords are the keywords a stage reference such as `@my_stage` follows.
+var stageKeywords = map[string]bool{
+	"FROM":   true,
+	"INTO":   true,
+	"LIST":   true,
+	"LS":     true,
+	"REMOVE": true,
+	"RM":     true,
+	"GET":    true,
+}
+
+// isNamedParameter reports whether the `:name` or `@name` token at i is a
+// parameter, rather than a variant path such as `v:k` or `v['a']:k`, which
+// follows its value directly, or a stage reference such as `FROM @my_stage`
+// or `@my_stage/path`.
+func isNamedParameter(query string, tokens []sqlToken, i int) bool {
+	tok := tokens[i]
+	if query[tok.start] == ':' {
+		if i == 0 {
+			return true
+		}
+		prev := tokens[i-1]
+		switch prev.kind {
+		case sqlTokenWord, sqlTokenQuotedIdent, sqlTokenPlaceholder:
+			return false
+		case sqlTokenOperator:
+			text := prev.text(query)
+			return text != "]" && text != ")"
+		}
+		return true
 	}
-	quote = false
-	index := make([]int, n)
-	n = 0
-	for i, ch := range query[first:] {
-		switch ch {
-		case '\'':
-			quote = !quote
-		case '?':
-			if !quote {
-				index[n] = first + i
-				n++
-			}
+	if tok.end < len(query) && query[tok.end] == '/' {
+		return false
+	}
+	for j := i - 1; j >= 0; j-- {
+		if tokens[j].significant() {
+			return tokens[j].kind != sqlTokenWord || !stageKeywords[strings.ToUpper(tokens[j].text(query))]
 		}
 	}
-	return index
+	return true
 }
 
 func interpolateParams(query string, params []driver.Value) (string, error) {
-	return interpolateParams2(query, params, placeholders(query))
+	return bindParams(textEncode, query, placeholders(query), namedValues(params))
 }
 
-func interpolateParams2(query string, params []driver.Value, index []int) (string, error) {
-	if len(params) == 0 {
-		return query, nil
+// bindParams replaces the placeholders in query with the encoded arguments.
+//
+// Positional arguments are bound to `?` in order, or to `$N` by position; the two
+// styles cannot be mixed in one query. Named arguments (sql.Named) are bound to
+// `:name` and `@name`, except for variant paths and stage references that use the
+// same syntax, which are left untouched.
+func bindParams(enc encoder, query string, params []placeholder, args []driver.NamedValue) (string, error) {
+	var (
+		positional []driver.Value
+		named      map[string]driver.Value
+	)
+	for _, arg := range args {
+		if arg.Name == "" {
+			positional = append(positional, arg.Value)
+			continue
+		}
+		if named == nil {
+			named = make(map[string]driver.Value)
+		}
+		if _, ok := named[arg.Name]; ok {
+			return "", fmt.Errorf("databend: duplicate parameter name %q", arg.Name)
+		}
+		named[arg.Name] = arg.Value
 	}
-	if reflect.TypeOf(params[0]).Kind() == reflect.Slice {
-		if reflect.ValueOf(params[0]).Len() == 0 {
-			return query, nil
+
+	var (
+		nPositional int
+		hasNumbered bool
+		usedOrdinal = make([]bool, len(positional))
+		usedName    = make(map[string]bool, len(named))
+		bound       = make([]placeholder, 0, len(params))
+		values      = make([]driver.Value, 0, len(params))
+	)
+	for _, p := range params {
+		switch p.kind {
+		case placeholderPositional:
+			if nPositional < len(positional) {
+				values = append(values, positional[nPositional])
+				usedOrdinal[nPositional] = true
+			}
+			nPositional++
+		case placeholderNumbered:
+			hasNumbered = true
+			if p.ordinal > len(positional) {
+				return "", fmt.Errorf("%w: $%d", ErrMissingParameter, p.ordinal)
+			}
+			values = append(values, positional[p.ordinal-1])
+			usedOrdinal[p.ordinal-1] = true
+		case placeholderNamed:
+			v, ok := named[p.name]
+			if !ok {
+				return "", fmt.Errorf("%w: %s", ErrMissingParameter, p.name)
+			}
+			values = append(values, v)
+			usedName[p.name] = true
 		}
+		bound = append(bound, p)
+	}
+	if nPositional > 0 && hasNumbered {
+		return "", ErrMixedPlaceholders
 	}
-	if len(index) != len(params) {
+	if nPositional != 0 && nPositional != len(positional) {
 		return "", ErrPlaceholderCount
 	}
+	for i, used := range usedOrdinal {
+		if !used {
+			if !hasNumbered {
+				return "", ErrPlaceholderCount
+			}
+			return "", fmt.Errorf("%w: $%d", ErrUnusedParameter, i+1)
+		}
+	}
+	for name := range named {
+		if !usedName[name] {
+			return "", fmt.Errorf("%w: %s", ErrUnusedParameter, name)
+		}
+	}
+	return interpolateValues(enc, query, bound, values)
+}
+
+func interpolateValues(enc encoder, query string, params []placeholder, values []driver.Value) (string, error) {
+	if len(params) == 0 {
+		return query, nil
+	}
 
 	var (
-		queryRaw      = []byte(query)
-		paramsEncoded = make([][]byte, len(params))
-		n             = len(queryRaw) - len(index) // do not count number of placeholders
+		paramsEncoded = make([][]byte, len(values))
+		n             = len(query)
 	)
-	for i, v := range params {
-		paramsEncoded[i], _ = textEncode.Encode(v)
-		n += len(paramsEncoded[i])
+	for i, v := range values {
+		encoded, err := enc.Encode(v)
+		if err != nil {
+			return "", fmt.Errorf("databend: failed to encode parameter %d: %w", i+1, err)
+		}
+		paramsEncoded[i] = encoded
+		n += len(encoded) - (params[i].end - params[i].start)
 	}
-	buf := make([]byte, n)
+	buf := make([]byte, 0, n)
 	i := 0
-	k := 0
-	for j, idx := range index {
-		copy(buf[k:], queryRaw[i:idx])
-		k += idx - i
-		copy(buf[k:], paramsEncoded[j])
-		i = idx + 1
-		k += len(paramsEncoded[j])
-	}
-	copy(buf[k:], query[i:])
+	for j, p := range params {
+		buf = append(buf, query[i:p.start]...)
+		buf = append(buf, paramsEncoded[j]...)
+		i = p.end
+	}
+	buf = append(buf, query[i:]...)
 	return string(buf), nil
 }
@@ -20,6 +20,9 @@ func TestInterpolate(t *testing.T) {
 		{"SELECT a=? AND b='?'", []driver.Value{"1"}, "SELECT a='1' AND b='?'"},
 		{"SELECT '\\'', ?", []driver.Value{"1"}, "SELECT '\\'', '1'"},
 		{"SELECT 1", []driver.Value{}, "SELECT 1"},
+		{`SELECT "a?", ?`, []driver.Value{1}, `SELECT "a?", 1`},
+		{"SELECT ? -- ?", []driver.Value{1}, "SELECT 1 -- ?"},
+		{"SELECT v ?| ['a'] WHERE x = ?", []driver.Value{1}, "SELECT v ?| ['a'] WHERE x = 1"},
 	}
 
 	for _, tc := range testCases {
@@ -31,3 +34,63 @@ func TestInterpolate(t *testing.T) {
 	_, err := interpolateParams("SELECT ?, ?", []driver.Value{1})
 	assert.Equal(t, ErrPlaceholderCount, err)
 }
+
+func TestInterpolateNamedAndNumbered(t *testing.T) {
+	testCases := []struct {
+		query    string
+		args     []driver.NamedValue
+		expected string
+	}{
+		{"SELECT $1, $2", []driver.NamedValue{{Ordinal: 1, Value: 1}, {Ordinal: 2, Value: "a"}}, "SELECT 1, 'a'"},
+		{"SELECT $1 + $1, $2", []driver.NamedValue{{Ordinal: 1, Value: 2}, {Ordinal: 2, Value: 3}}, "SELECT 2 + 2, 3"},
+		{"SELECT $2, $1", []driver.NamedValue{{Ordinal: 1, Value: 1}, {Ordinal: 2, Value: 2}}, "SELECT 2, 1"},
+		{"SELECT :a, @b", []driver.NamedValue{{Name: "a", Value: 1}, {Name: "b", Value: "x"}}, "SELECT 1, 'x'"},
+		{"SELECT :a + :a", []driver.NamedValue{{Name: "a", Value: 1}}, "SELECT 1 + 1"},
+		{"SELECT ?, :name", []driver.NamedValue{{Ordinal: 1, Value: 1}, {Name: "name", Value: "n"}}, "SELECT 1, 'n'"},
+		{"SELECT '$1', ':a', $1", []driver.NamedValue{{Ordinal: 1, Value: 1}}, "SELECT '$1', ':a', 1"},
+		{"SELECT a::String, :a", []driver.NamedValue{{Name: "a", Value: 1}}, "SELECT a::String, 1"},
+		{"COPY INTO t FROM @stage WHERE x = :x", []driver.NamedValue{{Name: "x", Value: 1}}, "COPY INTO t FROM @stage WHERE x = 1"},
+		{"SELECT v:k FROM t WHERE id = :id", []driver.NamedValue{{Name: "id", Value: 7}}, "SELECT v:k FROM t WHERE id = 7"},
+		{"SELECT v['a']:k, f(v):k FROM t WHERE id IN (:id)", []driver.NamedValue{{Name: "id", Value: 7}}, "SELECT v['a']:k, f(v):k FROM t WHERE id IN (7)"},
+		{"SELECT * FROM @s WHERE x = ?", []driver.NamedValue{{Ordinal: 1, Value: 2}}, "SELECT * FROM @s WHERE x = 2"},
+		{"LIST @s PATTERN = :p", []driver.NamedValue{{Name: "p", Value: ".*"}}, "LIST @s PATTERN = '.*'"},
+	}
+
+	for _, tc := range testCases {
+		v, err := buildQuery(textEncode, tc.query, tc.args, nil)
+		if assert.NoError(t, err, tc.query) {
+			assert.Equal(t, tc.expected, v)
+		}
+	}
+}
+
+func TestInterpolateParameterErrors(t *testing.T) {
+	testCases := []struct {
+		query string
+		args  []driver.NamedValue
+		err   error
+	}{
+		{"SELECT $1, $3", []driver.NamedValue{{Ordinal: 1, Value: 1}, {Ordinal: 2, Value: 2}}, ErrMissingParameter},
+		{"SELECT $1", []driver.NamedValue{{Ordinal: 1, Value: 1}, {Ordinal: 2, Value: 2}}, ErrUnusedParameter},
+		{"SELECT :a", []driver.NamedValue{{Name: "a", Value: 1}, {Name: "b", Value: 2}}, ErrUnusedParameter},
+		{"SELECT :a, :b", []driver.NamedValue{{Name: "a", Value: 1}}, ErrMissingParameter},
+		{"SELECT @a WHERE x = ?", []driver.NamedValue{{Ordinal: 1, Value: 1}}, ErrMissingParameter},
+		{"SELECT ?, $1", []driver.NamedValue{{Ordinal: 1, Value: 1}}, ErrMixedPlaceholders},
+		{"SELECT ?", []driver.NamedValue{{Ordinal: 1, Value: 1}, {Ordinal: 2, Value: 2}}, ErrPlaceholderCount},
+	}
+
+	for _, tc := range testCases {
+		_, err := buildQuery(textEncode, tc.query, tc.args, nil)
+		assert.ErrorIs(t, err, tc.err, tc.query)
+	}
+}
+
+func TestNumInput(t *testing.T) {
+	assert.Equal(t, 0, numInput(placeholders("SELECT 1")))
+	assert.Equal(t, 2, numInput(placeholders("SELECT ?, ?")))
+	assert.Equal(t, 3, numInput(placeholders("SELECT $3, $1")))
+	assert.Equal(t, -1, numInput(placeholders("SELECT :a")))
+	assert.Equal(t, 1, numInput(placeholders("COPY INTO t FROM @my_stage WHERE x = ?")))
+	assert.Equal(t, 1, numInput(placeholders("SELECT * FROM @my_stage/path/ WHERE x = ?")))
+	assert.Equal(t, 2, numInput(placeholders("SELECT v:k, ? FROM t WHERE x = ?")))
+}
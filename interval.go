@@ -0,0 +1,318 @@
+package godatabend
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	arrowarray "github.com/apache/arrow-go/v18/arrow/array"
+)
+
+var reflectTypeInterval = reflect.TypeOf(IntervalValue{})
+
+// IntervalValue is the value of an Interval column. Months and days are kept
+// apart from the microseconds, since their length depends on the date they are
+// added to, so `1 month 2 days 03:00:00` is {Months: 1, Days: 2, Micros: 3 * 3600e6}.
+type IntervalValue struct {
+	Months int32
+	Days   int32
+	Micros int64
+}
+
+// NewIntervalFromDuration returns the interval of d, truncated to microseconds.
+func NewIntervalFromDuration(d time.Duration) IntervalValue {
+	return IntervalValue{Micros: d.Microseconds()}
+}
+
+var intervalUnits = map[string]IntervalValue{
+	"year": {Months: 12}, "years": {Months: 12}, "y": {Months: 12}, "yr": {Months: 12}, "yrs": {Months: 12},
+	"month": {Months: 1}, "months": {Months: 1}, "mon": {Months: 1}, "mons": {Months: 1},
+	"week": {Days: 7}, "weeks": {Days: 7}, "w": {Days: 7},
+	"day": {Days: 1}, "days": {Days: 1}, "d": {Days: 1},
+	"hour": {Micros: 3600e6}, "hours": {Micros: 3600e6}, "h": {Micros: 3600e6}, "hr": {Micros: 3600e6}, "hrs": {Micros: 3600e6},
+	"minute": {Micros: 60e6}, "minutes": {Micros: 60e6}, "min": {Micros: 60e6}, "mins": {Micros: 60e6}, "m": {Micros: 60e6},
+	"second": {Micros: 1e6}, "seconds": {Micros: 1e6}, "sec": {Micros: 1e6}, "secs": {Micros: 1e6}, "s": {Micros: 1e6},
+	"millisecond": {Micros: 1e3}, "milliseconds": {Micros: 1e3}, "ms": {Micros: 1e3}, "msec": {Micros: 1e3},
+	"microsecond": {Micros: 1}, "microseconds": {Micros: 1}, "us": {Micros: 1}, "usec": {Micros: 1},
+}
+
+// ParseInterval parses the text form of an interval, such as
+// `1 year 2 months 3 days 04:05:06.7`, `-90 minutes` or `2 days ago`.
+func ParseInterval(s string) (IntervalValue, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) == 0 {
+		return IntervalValue{}, fmt.Errorf("invalid interval %q", s)
+	}
+	ago := false
+	if fields[len(fields)-1] == "ago" {
+		ago = true
+		fields = fields[:len(fields)-1]
+	}
+
+	var months, days, micros int64
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if strings.Contains(field, ":") {
+			v, err := parseIntervalClock(field)
+			if err != nil {
+				return IntervalValue{}, fmt.Errorf("invalid interval %q", s)
+			}
+			micros += v
+			continue
+		}
+		if i+1 == len(fields) {
+			return IntervalValue{}, fmt.Errorf("invalid interval %q: missing unit", s)
+		}
+		unit, ok := intervalUnits[fields[i+1]]
+		if !ok {
+			return IntervalValue{}, fmt.Errorf("invalid interval %q: unknown unit %q", s, fields[i+1])
+		}
+		i++
+		if unit.Micros == 0 {
+			n, err := strconv.ParseInt(field, 10, 32)
+			if err != nil {
+				return IntervalValue{}, fmt.Errorf("invalid interval %q", s)
+			}
+			months += n * int64(unit.Months)
+			days += n * int64(unit.Days)
+			continue
+		}
+		n, err := strconv.ParseFloat(field, 64)
+		if err != nil || math.Abs(n*float64(unit.Micros)) >= math.MaxInt64 {
+			return IntervalValue{}, fmt.Errorf("invalid interval %q", s)
+		}
+		micros += int64(math.Round(n * float64(unit.Micros)))
+	}
+	if ago {
+		months, days, micros = -months, -days, -micros
+	}
+	if months < math.MinInt32 || months > math.MaxInt32 || days < math.MinInt32 || days > math.MaxInt32 {
+		return IntervalValue{}, fmt.Errorf("interval %q out of range", s)
+	}
+	return IntervalValue{Months: int32(months), Days: int32(days), Micros: micros}, nil
+}
+
+// parseIntervalClock parses `[-]HH:MM[:SS[.ffffff]]` into microseconds.
+func parseIntervalClock(s string) (int64, error) {
+	sign := int64(1)
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		if s[0] == '-' {
+			sign = -1
+		}
+		s = s[1:]
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	var micros int64
+	scales := []int64{3600e6, 60e6}
+	for i, part := range parts {
+		if i == 2 {
+			secs, frac, _ := strings.Cut(part, ".")
+			if !isDigits(secs) || !isDigits(frac) || secs == "" || len(frac) > 6 {
+				return 0, fmt.Errorf("invalid time %q", s)
+			}
+			n, _ := strconv.ParseInt(secs, 10, 64)
+			f, _ := strconv.ParseInt((frac + "000000")[:6], 10, 64)
+			micros += n*1e6 + f
+			continue
+		}
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+		micros += n * scales[i]
+	}
+	return sign * micros, nil
+}
+
+// Duration returns the interval as a time.Duration, and whether that is
+// lossless: it is not when the interval has months or days, or overflows.
+func (iv IntervalValue) Duration() (time.Duration, bool) {
+	if iv.Months != 0 || iv.Days != 0 || iv.Micros > math.MaxInt64/1000 || iv.Micros < math.MinInt64/1000 {
+		return 0, false
+	}
+	return time.Duration(iv.Micros) * time.Microsecond, true
+}
+
+// String formats the interval the way Databend does, e.g.
+// `1 year 2 months 3 days 04:05:06.000007`.
+func (iv IntervalValue) String() string {
+	var parts []string
+	if years := iv.Months / 12; years != 0 {
+		parts = append(parts, intervalPart(int64(years), "year"))
+	}
+	if months := iv.Months % 12; months != 0 {
+		parts = append(parts, intervalPart(int64(months), "month"))
+	}
+	if iv.Days != 0 {
+		parts = append(parts, intervalPart(int64(iv.Days), "day"))
+	}
+	if iv.Micros != 0 || len(parts) == 0 {
+		parts = append(parts, formatIntervalClock(iv.Micros))
+	}
+	return strings.Join(parts, " ")
+}
+
+func intervalPart(n int64, unit string) string {
+	if n == 1 || n == -1 {
+		return strconv.FormatInt(n, 10) + " " + unit
+	}
+	return strconv.FormatInt(n, 10) + " " + unit + "s"
+}
+
+func formatIntervalClock(micros int64) string {
+	sign := ""
+	abs := uint64(micros)
+	if micros < 0 {
+		sign = "-"
+		abs = -abs
+	}
+	secs, frac := abs/1e6, abs%1e6
+	s := fmt.Sprintf("%s%02d:%02d:%02d", sign, secs/3600, secs/60%60, secs%60)
+	if frac != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%06d", frac), "0")
+	}
+	return s
+}
+
+// Value implements driver.Valuer. Intervals of a time of day only are sent as
+// their number of microseconds, like Interval(d) always has been; others in
+// the text form, which is the one that keeps months and days.
+func (iv IntervalValue) Value() (driver.Value, error) {
+	if iv.Months == 0 && iv.Days == 0 {
+		return strconv.FormatInt(iv.Micros, 10), nil
+	}
+	return iv.String(), nil
+}
+
+// Scan implements sql.Scanner
+func (iv *IntervalValue) Scan(src any) error {
+	switch v := src.(type) {
+	case IntervalValue:
+		*iv = v
+	case time.Duration:
+		*iv = NewIntervalFromDuration(v)
+	case int64:
+		*iv = IntervalValue{Micros: v}
+	case string:
+		return iv.scanText(v)
+	case []byte:
+		return iv.scanText(string(v))
+	case nil:
+		return fmt.Errorf("cannot scan NULL into IntervalValue, use sql.Null[IntervalValue]")
+	default:
+		return fmt.Errorf("cannot scan %T into Interval", src)
+	}
+	return nil
+}
+
+func (iv *IntervalValue) scanText(s string) error {
+	if micros, err := strconv.ParseInt(s, 10, 64); err == nil {
+		// as sent by Value
+		*iv = IntervalValue{Micros: micros}
+		return nil
+	}
+	v, err := ParseInterval(s)
+	if err != nil {
+		return err
+	}
+	*iv = v
+	return nil
+}
+
+// ScanDuration returns a sql.Scanner that stores an Interval column in d. It
+// reports an error for intervals that are not a lossless time.Duration, such
+// as those with months or days, and for NULL.
+//
+//	var d time.Duration
+//	err := row.Scan(godatabend.ScanDuration(&d))
+func ScanDuration(d *time.Duration) sql.Scanner {
+	return (*durationScanner)(d)
+}
+
+type durationScanner time.Duration
+
+// Scan implements sql.Scanner
+func (d *durationScanner) Scan(src any) error {
+	var iv IntervalValue
+	if err := iv.Scan(src); err != nil {
+		return err
+	}
+	v, ok := iv.Duration()
+	if !ok {
+		return fmt.Errorf("interval %s cannot be scanned into time.Duration", iv)
+	}
+	*d = durationScanner(v)
+	return nil
+}
+
+// arrowInterval reads an interval from an Arrow column. Databend sends them
+// as 128-bit integers packing the months, days and microseconds.
+func arrowInterval(column arrow.Array, rowIdx int) (IntervalValue, error) {
+	switch arr := column.(type) {
+	case *arrowarray.Decimal128:
+		v := arr.Value(rowIdx)
+		return intervalFromInt128(uint64(v.HighBits()), v.LowBits()), nil
+	case *arrowarray.FixedSizeBinary:
+		b := arr.Value(rowIdx)
+		if len(b) != 16 {
+			return IntervalValue{}, fmt.Errorf("invalid arrow interval of %d bytes", len(b))
+		}
+		return intervalFromInt128(binary.LittleEndian.Uint64(b[8:]), binary.LittleEndian.Uint64(b)), nil
+	case *arrowarray.MonthDayNanoInterval:
+		v := arr.Value(rowIdx)
+		return IntervalValue{Months: v.Months, Days: v.Days, Micros: v.Nanoseconds / 1000}, nil
+	case *arrowarray.String:
+		return ParseInterval(arr.Value(rowIdx))
+	case *arrowarray.LargeString:
+		return ParseInterval(arr.Value(rowIdx))
+	default:
+		return IntervalValue{}, fmt.Errorf("unsupported arrow interval column %T", column)
+	}
+}
+
+func intervalFromInt128(high, low uint64) IntervalValue {
+	return IntervalValue{Months: int32(high >> 32), Days: int32(uint32(high)), Micros: int64(low)}
+}
+
+func materializeArrowIntervalDriverValue(column arrow.Array, rowIdx int) (driver.Value, error) {
+	iv, err := arrowInterval(column, rowIdx)
+	if err != nil {
+		return nil, err
+	}
+	// as text, like the JSON format
+	return iv.String(), nil
+}
+
+type intervalColumnType struct {
+	columnTypeDefault
+	isNullable
+}
+
+func (c intervalColumnType) Parse(s string) (driver.Value, error) {
+	if c.checkNull(s) {
+		return nil, nil
+	}
+	return s, nil
+}
+
+func (intervalColumnType) ScanType() reflect.Type {
+	return reflectTypeInterval
+}
+
+func (c intervalColumnType) DatabaseTypeName() string {
+	return c.wrapName("Interval")
+}
+
+func (c intervalColumnType) Desc() *TypeDesc {
+	return &TypeDesc{Name: "Interval", Nullable: bool(c.isNullable)}
+}
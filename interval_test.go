@@ -0,0 +1,188 @@
+package godatabend
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	arrowarray "github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		input string
+		want  IntervalValue
+	}{
+		{input: "00:00:00", want: IntervalValue{}},
+		{input: "1 year 2 months 3 days 04:05:06.000007", want: IntervalValue{Months: 14, Days: 3, Micros: 14706000007}},
+		{input: "-1 month", want: IntervalValue{Months: -1}},
+		{input: "2 weeks 1 day", want: IntervalValue{Days: 15}},
+		{input: "-90 minutes", want: IntervalValue{Micros: -5400e6}},
+		{input: "1.5 seconds 10 us", want: IntervalValue{Micros: 1500010}},
+		{input: "-01:30:00", want: IntervalValue{Micros: -5400e6}},
+		{input: "10:30", want: IntervalValue{Micros: 37800e6}},
+		{input: "00:00:01.5", want: IntervalValue{Micros: 1500000}},
+		{input: "2 Days Ago", want: IntervalValue{Days: -2}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			iv, err := ParseInterval(tc.input)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, iv)
+		})
+	}
+
+	for _, input := range []string{"", "1", "1 fortnight", "1.5 months", "1:2:3:4", "00:00:01.1234567", "x days"} {
+		_, err := ParseInterval(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestIntervalString(t *testing.T) {
+	tests := []struct {
+		iv   IntervalValue
+		want string
+	}{
+		{iv: IntervalValue{}, want: "00:00:00"},
+		{iv: IntervalValue{Months: 14, Days: 3, Micros: 14706000007}, want: "1 year 2 months 3 days 04:05:06.000007"},
+		{iv: IntervalValue{Months: -1, Days: 1}, want: "-1 month 1 day"},
+		{iv: IntervalValue{Micros: -5400e6}, want: "-01:30:00"},
+		{iv: IntervalValue{Micros: 100 * 3600e6}, want: "100:00:00"},
+		{iv: IntervalValue{Micros: 1500000}, want: "00:00:01.5"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, tc.iv.String())
+		parsed, err := ParseInterval(tc.want)
+		require.NoError(t, err)
+		assert.Equal(t, tc.iv, parsed)
+	}
+
+	v, err := IntervalValue{Days: 2}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "2 days", v)
+	v, err = IntervalValue{Micros: 1500000}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "1500000", v)
+	var iv IntervalValue
+	require.NoError(t, iv.Scan(v))
+	assert.Equal(t, IntervalValue{Micros: 1500000}, iv)
+}
+
+func TestIntervalDuration(t *testing.T) {
+	d, ok := IntervalValue{Micros: 1500}.Duration()
+	assert.True(t, ok)
+	assert.Equal(t, 1500*time.Microsecond, d)
+
+	_, ok = IntervalValue{Days: 1}.Duration()
+	assert.False(t, ok)
+	_, ok = IntervalValue{Micros: 1 << 62}.Duration()
+	assert.False(t, ok)
+
+	assert.Equal(t, IntervalValue{Micros: 90e6}, NewIntervalFromDuration(90*time.Second))
+}
+
+func TestIntervalScan(t *testing.T) {
+	var iv IntervalValue
+	require.NoError(t, iv.Scan("1 day 00:00:01"))
+	assert.Equal(t, IntervalValue{Days: 1, Micros: 1e6}, iv)
+	require.NoError(t, iv.Scan(time.Second))
+	assert.Equal(t, IntervalValue{Micros: 1e6}, iv)
+	require.NoError(t, iv.Scan(IntervalValue{Months: 1}))
+	assert.Equal(t, IntervalValue{Months: 1}, iv)
+	assert.Error(t, iv.Scan(nil))
+	assert.Error(t, iv.Scan(1.5))
+}
+
+func TestIntervalColumnType(t *testing.T) {
+	db := sql.OpenDB(&fakeConnector{
+		resp: &QueryResponse{
+			Schema: &[]DataField{{Name: "x", Type: "Nullable(Interval)"}},
+			Data:   [][]*string{{strPtr("01:30:00")}, {strPtr("1 month 00:00:01")}, {nil}},
+		},
+	})
+
+	rows, err := db.Query("x")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	assert.Equal(t, reflectTypeInterval, types[0].ScanType())
+	assert.Equal(t, "Interval NULL", types[0].DatabaseTypeName())
+
+	require.True(t, rows.Next())
+	var (
+		text string
+		iv   IntervalValue
+	)
+	require.NoError(t, rows.Scan(&text))
+	assert.Equal(t, "01:30:00", text)
+	require.NoError(t, rows.Scan(&iv))
+	d, ok := iv.Duration()
+	assert.True(t, ok)
+	assert.Equal(t, 90*time.Minute, d)
+	d = 0
+	require.NoError(t, rows.Scan(ScanDuration(&d)))
+	assert.Equal(t, 90*time.Minute, d)
+
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&text))
+	assert.Equal(t, "1 month 00:00:01", text)
+	require.NoError(t, rows.Scan(&iv))
+	assert.Equal(t, IntervalValue{Months: 1, Micros: 1e6}, iv)
+	assert.ErrorContains(t, rows.Scan(ScanDuration(&d)), "cannot be scanned into time.Duration")
+
+	require.True(t, rows.Next())
+	var null sql.Null[IntervalValue]
+	require.NoError(t, rows.Scan(&null))
+	assert.False(t, null.Valid)
+	assert.Error(t, rows.Scan(ScanDuration(&d)))
+}
+
+func TestDecodeArrowResponseMaterializesInterval(t *testing.T) {
+	resp := QueryResponse{
+		ID:     "query-interval",
+		Schema: &[]DataField{{Name: "i", Type: "Interval"}, {Name: "a", Type: "Array(Interval)"}},
+	}
+	packed := func(months, days int32, micros int64) decimal128.Num {
+		return decimal128.New(int64(months)<<32|int64(uint32(days)), uint64(micros))
+	}
+
+	payload := buildArrowPayload(t, resp, []arrow.Field{
+		{
+			Name:     "i",
+			Type:     &arrow.Decimal128Type{Precision: 38, Scale: 0},
+			Metadata: arrow.NewMetadata([]string{arrowExtensionKey}, []string{arrowExtensionInterval}),
+		},
+		{
+			Name: "a",
+			Type: arrow.ListOf(&arrow.Decimal128Type{Precision: 38, Scale: 0}),
+		},
+	}, func(builder *arrowarray.RecordBuilder) {
+		b := builder.Field(0).(*arrowarray.Decimal128Builder)
+		b.Append(packed(0, 0, 5e6))
+		b.Append(packed(-1, 2, -3))
+
+		list := builder.Field(1).(*arrowarray.ListBuilder)
+		values := list.ValueBuilder().(*arrowarray.Decimal128Builder)
+		list.Append(true)
+		values.Append(packed(1, 0, 0))
+		list.Append(true)
+	})
+
+	decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
+		headers: http.Header{contentType: []string{arrowStreamContentType}},
+		body:    payload,
+	})
+	require.NoError(t, err)
+	require.Len(t, decoded.typedRows, 2)
+	assert.Equal(t, "00:00:05", decoded.typedRows[0][0])
+	assert.Equal(t, "-1 month 2 days -00:00:00.000003", decoded.typedRows[1][0])
+	assert.Equal(t, []string{"1 month"}, decoded.typedRows[0][1])
+	assert.Equal(t, []string{}, decoded.typedRows[1][1])
+}
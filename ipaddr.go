@@ -0,0 +1,85 @@
+package godatabend
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/netip"
+	"reflect"
+)
+
+var reflectTypeIPAddr = reflect.TypeOf(IPAddr{})
+
+// IPAddr is the value of IPv4 and IPv6 columns. It wraps netip.Addr so that it
+// can be scanned from and bound to queries. The zero IPAddr is invalid, and is
+// used for NULL.
+type IPAddr struct {
+	netip.Addr
+}
+
+// Value implements driver.Valuer
+func (a IPAddr) Value() (driver.Value, error) {
+	if !a.IsValid() {
+		return nil, nil
+	}
+	return a.String(), nil
+}
+
+// Scan implements sql.Scanner
+func (a *IPAddr) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = IPAddr{}
+	case string:
+		return a.scanText(v)
+	case []byte:
+		return a.scanText(string(v))
+	case netip.Addr:
+		a.Addr = v
+	case IPAddr:
+		*a = v
+	case int64:
+		if v < 0 || v > 0xFFFFFFFF {
+			return fmt.Errorf("cannot scan %d into IPAddr", v)
+		}
+		a.Addr = netip.AddrFrom4([4]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
+	default:
+		return fmt.Errorf("cannot scan %T into IPAddr", src)
+	}
+	return nil
+}
+
+func (a *IPAddr) scanText(s string) error {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return err
+	}
+	a.Addr = addr
+	return nil
+}
+
+type enumColumnType struct {
+	desc *TypeDesc
+	columnTypeDefault
+	isNullable
+}
+
+func (c enumColumnType) Parse(s string) (driver.Value, error) {
+	if c.checkNull(s) {
+		return nil, nil
+	}
+	return s, nil
+}
+
+func (enumColumnType) ScanType() reflect.Type {
+	return reflectTypeString
+}
+
+func (c enumColumnType) DatabaseTypeName() string {
+	return c.desc.String()
+}
+
+// Desc returns the type with one argument per enum value, named after the
+// value and holding its number as argument.
+func (c enumColumnType) Desc() *TypeDesc {
+	return c.desc
+}
@@ -0,0 +1,32 @@
+package godatabend
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPAddrScan(t *testing.T) {
+	var a IPAddr
+	require.NoError(t, a.Scan("192.168.1.1"))
+	assert.Equal(t, netip.MustParseAddr("192.168.1.1"), a.Addr)
+	require.NoError(t, a.Scan([]byte("::1")))
+	assert.True(t, a.Is6())
+	require.NoError(t, a.Scan(int64(0x0A000001)))
+	assert.Equal(t, "10.0.0.1", a.String())
+	require.NoError(t, a.Scan(nil))
+	assert.False(t, a.IsValid())
+
+	assert.Error(t, a.Scan("not an ip"))
+	assert.Error(t, a.Scan(int64(-1)))
+	assert.Error(t, a.Scan(1.5))
+
+	v, err := IPAddr{}.Value()
+	require.NoError(t, err)
+	assert.Nil(t, v)
+	v, err = IPAddr{netip.MustParseAddr("::1")}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "::1", v)
+}
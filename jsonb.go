@@ -0,0 +1,214 @@
+package godatabend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Databend stores Variant values in its binary JSONB format, which is what the
+// Arrow transport returns. A value is a container: a big-endian uint32 header
+// holding the container type and element count, one uint32 JEntry per element
+// (type and length), and then the element data in order. Objects list all key
+// entries before the value entries.
+const (
+	jsonbArrayContainer  uint32 = 0x80000000
+	jsonbObjectContainer uint32 = 0x40000000
+	jsonbScalarContainer uint32 = 0x20000000
+	jsonbContainerMask   uint32 = 0xE0000000
+	jsonbCountMask       uint32 = 0x1FFFFFFF
+
+	jsonbNull      uint32 = 0x00000000
+	jsonbString    uint32 = 0x10000000
+	jsonbNumber    uint32 = 0x20000000
+	jsonbFalse     uint32 = 0x30000000
+	jsonbTrue      uint32 = 0x40000000
+	jsonbContainer uint32 = 0x50000000
+	jsonbTypeMask  uint32 = 0x70000000
+	jsonbIsOffset  uint32 = 0x80000000
+	jsonbLenMask   uint32 = 0x0FFFFFFF
+
+	jsonbNumberZero   byte = 0x00
+	jsonbNumberNaN    byte = 0x10
+	jsonbNumberInf    byte = 0x20
+	jsonbNumberNegInf byte = 0x30
+	jsonbNumberInt    byte = 0x40
+	jsonbNumberUint   byte = 0x50
+	jsonbNumberFloat  byte = 0x60
+)
+
+// variantText returns the JSON text of a Variant value, which is either JSONB
+// or already JSON text.
+func variantText(data []byte) (string, error) {
+	if len(data) >= 4 && binary.BigEndian.Uint32(data)&jsonbContainerMask != 0 && !json.Valid(data) {
+		var buf bytes.Buffer
+		if err := decodeJSONB(&buf, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	return string(data), nil
+}
+
+func decodeJSONB(buf *bytes.Buffer, data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("invalid jsonb: short container")
+	}
+	header := binary.BigEndian.Uint32(data)
+	n := int(header & jsonbCountMask)
+	switch header & jsonbContainerMask {
+	case jsonbScalarContainer:
+		entries, values, err := jsonbEntries(data, 1)
+		if err != nil {
+			return err
+		}
+		return decodeJSONBValue(buf, entries[0], values[0])
+	case jsonbArrayContainer:
+		entries, values, err := jsonbEntries(data, n)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('[')
+		for i := range entries {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := decodeJSONBValue(buf, entries[i], values[i]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case jsonbObjectContainer:
+		entries, values, err := jsonbEntries(data, 2*n)
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('{')
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if entries[i]&jsonbTypeMask != jsonbString {
+				return fmt.Errorf("invalid jsonb: object key is not a string")
+			}
+			writeJSONString(buf, values[i])
+			buf.WriteByte(':')
+			if err := decodeJSONBValue(buf, entries[n+i], values[n+i]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	default:
+		return fmt.Errorf("invalid jsonb container header %#x", header)
+	}
+}
+
+// jsonbEntries splits a container into its n JEntries and the data of each.
+func jsonbEntries(data []byte, n int) ([]uint32, [][]byte, error) {
+	start := 4 + 4*n
+	if n < 0 || len(data) < start {
+		return nil, nil, fmt.Errorf("invalid jsonb: truncated entries")
+	}
+	entries := make([]uint32, n)
+	values := make([][]byte, n)
+	offset := start
+	for i := range entries {
+		entry := binary.BigEndian.Uint32(data[4+4*i:])
+		end := offset + int(entry&jsonbLenMask)
+		if entry&jsonbIsOffset != 0 {
+			end = start + int(entry&jsonbLenMask)
+		}
+		if end < offset || end > len(data) {
+			return nil, nil, fmt.Errorf("invalid jsonb: entry out of range")
+		}
+		entries[i] = entry
+		values[i] = data[offset:end]
+		offset = end
+	}
+	return entries, values, nil
+}
+
+func decodeJSONBValue(buf *bytes.Buffer, entry uint32, data []byte) error {
+	switch entry & jsonbTypeMask {
+	case jsonbNull:
+		buf.WriteString("null")
+	case jsonbFalse:
+		buf.WriteString("false")
+	case jsonbTrue:
+		buf.WriteString("true")
+	case jsonbString:
+		writeJSONString(buf, data)
+	case jsonbNumber:
+		return decodeJSONBNumber(buf, data)
+	case jsonbContainer:
+		return decodeJSONB(buf, data)
+	default:
+		return fmt.Errorf("unsupported jsonb entry type %#x", entry&jsonbTypeMask)
+	}
+	return nil
+}
+
+func decodeJSONBNumber(buf *bytes.Buffer, data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("invalid jsonb: empty number")
+	}
+	tag, v := data[0], data[1:]
+	switch tag {
+	case jsonbNumberZero:
+		buf.WriteByte('0')
+	case jsonbNumberNaN:
+		buf.WriteString(`"NaN"`)
+	case jsonbNumberInf:
+		buf.WriteString(`"Infinity"`)
+	case jsonbNumberNegInf:
+		buf.WriteString(`"-Infinity"`)
+	case jsonbNumberInt:
+		var n int64
+		switch len(v) {
+		case 1:
+			n = int64(int8(v[0]))
+		case 2:
+			n = int64(int16(binary.BigEndian.Uint16(v)))
+		case 4:
+			n = int64(int32(binary.BigEndian.Uint32(v)))
+		case 8:
+			n = int64(binary.BigEndian.Uint64(v))
+		default:
+			return fmt.Errorf("invalid jsonb: %d byte integer", len(v))
+		}
+		buf.WriteString(strconv.FormatInt(n, 10))
+	case jsonbNumberUint:
+		var n uint64
+		switch len(v) {
+		case 1:
+			n = uint64(v[0])
+		case 2:
+			n = uint64(binary.BigEndian.Uint16(v))
+		case 4:
+			n = uint64(binary.BigEndian.Uint32(v))
+		case 8:
+			n = binary.BigEndian.Uint64(v)
+		default:
+			return fmt.Errorf("invalid jsonb: %d byte integer", len(v))
+		}
+		buf.WriteString(strconv.FormatUint(n, 10))
+	case jsonbNumberFloat:
+		if len(v) != 8 {
+			return fmt.Errorf("invalid jsonb: %d byte float", len(v))
+		}
+		buf.WriteString(strconv.FormatFloat(math.Float64frombits(binary.BigEndian.Uint64(v)), 'g', -1, 64))
+	default:
+		return fmt.Errorf("unsupported jsonb number type %#x", tag)
+	}
+	return nil
+}
+
+func writeJSONString(buf *bytes.Buffer, s []byte) {
+	encoded, _ := json.Marshal(string(s))
+	buf.Write(encoded)
+}
@@ -0,0 +1,615 @@
+package godatabend
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	arrowarray "github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/google/uuid"
+)
+
+var (
+	reflectTypeAny      = reflect.TypeOf((*any)(nil)).Elem()
+	reflectTypeAnySlice = reflect.TypeOf([]any(nil))
+	reflectTypeAnyMap   = reflect.TypeOf(map[string]any(nil))
+	reflectTypeBytes    = reflect.TypeOf([]byte(nil))
+)
+
+type nestedKind uint8
+
+const (
+	nestedScalar nestedKind = iota
+	nestedArray
+	nestedMap
+	nestedTuple
+)
+
+func isNestedTypeName(name string) bool {
+	switch name {
+	case "Array", "Map", "Tuple", "EmptyArray", "EmptyMap":
+		return true
+	}
+	return false
+}
+
+// nestedType materializes Array, Map and Tuple values, and the values nested in
+// them, into Go values:
+//
+//   - Array(T) becomes []T
+//   - Map(K, V) becomes map[K]V
+//   - Tuple(T1, T2, ...) becomes []any
+//
+// Nullable elements become pointers, unless the Go type can already hold nil
+// (slices, maps, []byte). The same tree reads the text values of JSON results
+// and the arrays of Arrow results, so both transports produce identical values.
+type nestedType struct {
+	kind     nestedKind
+	name     string
+	nullable bool
+	goType   reflect.Type
+	elems    []*nestedType
+	opts     *ColumnTypeOptions
+}
+
+func newNestedType(desc *TypeDesc, opts *ColumnTypeOptions) (*nestedType, error) {
+	// unwrap Nullable by hand, as Normalize modifies the arguments in place
+	t := &nestedType{name: desc.Name, nullable: desc.Nullable, opts: opts}
+	for t.name == "Nullable" && len(desc.Args) == 1 {
+		desc = desc.Args[0]
+		t.name = desc.Name
+		t.nullable = true
+	}
+	if t.name == "DateTime" {
+		t.name = "Timestamp"
+	}
+	switch t.name {
+	case "Array":
+		if len(desc.Args) != 1 {
+			return nil, fmt.Errorf("element type not specified for Array")
+		}
+		elem, err := newNestedType(desc.Args[0], opts)
+		if err != nil {
+			return nil, err
+		}
+		t.kind = nestedArray
+		t.elems = []*nestedType{elem}
+		t.goType = reflect.SliceOf(elem.goType)
+	case "EmptyArray":
+		t.kind = nestedArray
+		t.goType = reflectTypeAnySlice
+	case "Map":
+		if len(desc.Args) != 2 {
+			return nil, fmt.Errorf("incorrect number of arguments for Map")
+		}
+		key, err := newNestedType(desc.Args[0], opts)
+		if err != nil {
+			return nil, err
+		}
+		if !key.goType.Comparable() {
+			return nil, fmt.Errorf("unsupported map key type %s", desc.Args[0].String())
+		}
+		value, err := newNestedType(desc.Args[1], opts)
+		if err != nil {
+			return nil, err
+		}
+		t.kind = nestedMap
+		t.elems = []*nestedType{key, value}
+		t.goType = reflect.MapOf(key.goType, value.goType)
+	case "EmptyMap":
+		t.kind = nestedMap
+		t.goType = reflectTypeAnyMap
+	case "Tuple":
+		if len(desc.Args) == 0 {
+			return nil, fmt.Errorf("element types not specified for Tuple")
+		}
+		t.kind = nestedTuple
+		for _, arg := range desc.Args {
+			elem, err := newNestedType(arg, opts)
+			if err != nil {
+				return nil, err
+			}
+			t.elems = append(t.elems, elem)
+		}
+		t.goType = reflectTypeAnySlice
+	default:
+		t.kind = nestedScalar
+		t.goType = scalarGoType(t.name)
+		if t.nullable && !canBeNil(t.goType) {
+			t.goType = reflect.PointerTo(t.goType)
+		}
+	}
+	return t, nil
+}
+
+func scalarGoType(name string) reflect.Type {
+	switch name {
+	case "Null", "Nothing":
+		return reflectTypeAny
+	case "Boolean":
+		return reflectTypeBool
+	case "Int8":
+		return reflectTypeInt8
+	case "Int16":
+		return reflectTypeInt16
+	case "Int32":
+		return reflectTypeInt32
+	case "Int64":
+		return reflectTypeInt64
+	case "UInt8":
+		return reflectTypeUInt8
+	case "UInt16":
+		return reflectTypeUInt16
+	case "UInt32":
+		return reflectTypeUInt32
+	case "UInt64":
+		return reflectTypeUInt64
+	case "Float32":
+		return reflectTypeFloat32
+	case "Float64":
+		return reflectTypeFloat64
+	case "Date", "Timestamp", "Timestamp_Tz":
+		return reflectTypeTime
+	case "Binary":
+		return reflectTypeBytes
+	case "UUID":
+		return reflectTypeUUID
+	case "IPv4", "IPv6":
+		return reflectTypeIPAddr
+	default:
+		return reflectTypeString
+	}
+}
+
+func canBeNil(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice:
+		return true
+	}
+	return false
+}
+
+// parseText parses a value in the text form used by JSON results, e.g.
+// `[1,2]`, `{'k':1}` or `(1,'a')`.
+func (t *nestedType) parseText(s string) (any, error) {
+	r := &nestedReader{s: s}
+	v, err := t.parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s value %q: %w", t.name, s, err)
+	}
+	if r.skipSpace(); r.pos != len(r.s) {
+		return nil, fmt.Errorf("failed to parse %s value %q: unexpected %q at offset %d", t.name, s, r.s[r.pos:], r.pos)
+	}
+	return v.Interface(), nil
+}
+
+func (t *nestedType) parse(r *nestedReader) (reflect.Value, error) {
+	r.skipSpace()
+	if (t.nullable || t.kind == nestedScalar && t.goType == reflectTypeAny) && r.consumeNull() {
+		return reflect.Zero(t.goType), nil
+	}
+
+	switch t.kind {
+	case nestedArray:
+		if err := r.expect('['); err != nil {
+			return reflect.Value{}, err
+		}
+		slice := reflect.MakeSlice(t.goType, 0, 0)
+		for !r.consume(']') {
+			if len(t.elems) == 0 {
+				return reflect.Value{}, fmt.Errorf("unexpected element in empty array")
+			}
+			if slice.Len() > 0 {
+				if err := r.expect(','); err != nil {
+					return reflect.Value{}, err
+				}
+			}
+			v, err := t.elems[0].parse(r)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			slice = reflect.Append(slice, v)
+		}
+		return slice, nil
+	case nestedMap:
+		if err := r.expect('{'); err != nil {
+			return reflect.Value{}, err
+		}
+		m := reflect.MakeMap(t.goType)
+		for n := 0; !r.consume('}'); n++ {
+			if len(t.elems) == 0 {
+				return reflect.Value{}, fmt.Errorf("unexpected entry in empty map")
+			}
+			if n > 0 {
+				if err := r.expect(','); err != nil {
+					return reflect.Value{}, err
+				}
+			}
+			k, err := t.elems[0].parse(r)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			if err := r.expect(':'); err != nil {
+				return reflect.Value{}, err
+			}
+			v, err := t.elems[1].parse(r)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			m.SetMapIndex(k, v)
+		}
+		return m, nil
+	case nestedTuple:
+		if err := r.expect('('); err != nil {
+			return reflect.Value{}, err
+		}
+		values := make([]any, len(t.elems))
+		for i, elem := range t.elems {
+			if i > 0 {
+				if err := r.expect(','); err != nil {
+					return reflect.Value{}, err
+				}
+			}
+			v, err := elem.parse(r)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			values[i] = tupleElement(v)
+		}
+		if err := r.expect(')'); err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(values), nil
+	default:
+		text, err := r.scalar()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return t.scalarValue(text)
+	}
+}
+
+// tupleElement unwraps nullable elements, since []any can hold nil directly.
+func tupleElement(v reflect.Value) any {
+	switch {
+	case canBeNil(v.Type()) && v.IsNil():
+		return nil
+	case v.Kind() == reflect.Pointer:
+		return v.Elem().Interface()
+	default:
+		return v.Interface()
+	}
+}
+
+func (t *nestedType) scalarValue(text string) (reflect.Value, error) {
+	if t.goType == reflectTypeAny {
+		return reflect.Zero(t.goType), nil
+	}
+	v, err := parseScalarText(t.name, text, t.opts)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return t.wrap(reflect.ValueOf(v))
+}
+
+// wrap converts a scalar to the element type, taking its address if the element
+// is nullable.
+func (t *nestedType) wrap(v reflect.Value) (reflect.Value, error) {
+	if v.Type() == t.goType {
+		return v, nil
+	}
+	base := t.goType
+	if base.Kind() == reflect.Pointer {
+		base = base.Elem()
+	}
+	if v.Type() != base {
+		if !v.CanConvert(base) {
+			return reflect.Value{}, fmt.Errorf("cannot convert %s to %s", v.Type(), base)
+		}
+		v = v.Convert(base)
+	}
+	if t.goType.Kind() == reflect.Pointer {
+		p := reflect.New(base)
+		p.Elem().Set(v)
+		return p, nil
+	}
+	return v, nil
+}
+
+func parseScalarText(name string, text string, opts *ColumnTypeOptions) (any, error) {
+	switch name {
+	case "Boolean":
+		switch text {
+		case "true", "1":
+			return true, nil
+		case "false", "0":
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid Boolean value %q", text)
+	case "Int8":
+		v, err := strconv.ParseInt(text, 10, 8)
+		return int8(v), err
+	case "Int16":
+		v, err := strconv.ParseInt(text, 10, 16)
+		return int16(v), err
+	case "Int32":
+		v, err := strconv.ParseInt(text, 10, 32)
+		return int32(v), err
+	case "Int64":
+		return strconv.ParseInt(text, 10, 64)
+	case "UInt8":
+		v, err := strconv.ParseUint(text, 10, 8)
+		return uint8(v), err
+	case "UInt16":
+		v, err := strconv.ParseUint(text, 10, 16)
+		return uint16(v), err
+	case "UInt32":
+		v, err := strconv.ParseUint(text, 10, 32)
+		return uint32(v), err
+	case "UInt64":
+		return strconv.ParseUint(text, 10, 64)
+	case "Float32":
+		v, err := strconv.ParseFloat(text, 32)
+		return float32(v), err
+	case "Float64":
+		return strconv.ParseFloat(text, 64)
+	case "Date":
+		return time.Parse("2006-01-02", text)
+	case "Timestamp":
+		return time.ParseInLocation("2006-01-02 15:04:05.999999", text, opts.timezone)
+	case "Timestamp_Tz":
+		return time.Parse("2006-01-02 15:04:05.999999 -0700", text)
+	case "Binary":
+		return materializeBinaryFromString(text, opts.binaryOutputFormat, opts.httpJSONResultMode)
+	case "UUID":
+		return uuid.Parse(text)
+	case "IPv4", "IPv6":
+		addr, err := netip.ParseAddr(text)
+		if err != nil {
+			return nil, err
+		}
+		return IPAddr{addr}, nil
+	default:
+		return text, nil
+	}
+}
+
+// fromArrow reads the value at row i of an Arrow array.
+func (t *nestedType) fromArrow(column arrow.Array, i int) (reflect.Value, error) {
+	if column.IsNull(i) || t.kind == nestedScalar && t.goType == reflectTypeAny {
+		return reflect.Zero(t.goType), nil
+	}
+
+	switch t.kind {
+	case nestedArray:
+		slice := reflect.MakeSlice(t.goType, 0, 0)
+		if len(t.elems) == 0 {
+			return slice, nil
+		}
+		list, ok := column.(arrowarray.ListLike)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("unsupported arrow array column %T", column)
+		}
+		start, end := list.ValueOffsets(i)
+		values := list.ListValues()
+		for j := start; j < end; j++ {
+			v, err := t.elems[0].fromArrow(values, int(j))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			slice = reflect.Append(slice, v)
+		}
+		return slice, nil
+	case nestedMap:
+		m := reflect.MakeMap(t.goType)
+		if len(t.elems) == 0 {
+			return m, nil
+		}
+		arr, ok := column.(*arrowarray.Map)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("unsupported arrow map column %T", column)
+		}
+		start, end := arr.ValueOffsets(i)
+		keys, items := arr.Keys(), arr.Items()
+		for j := start; j < end; j++ {
+			k, err := t.elems[0].fromArrow(keys, int(j))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v, err := t.elems[1].fromArrow(items, int(j))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			m.SetMapIndex(k, v)
+		}
+		return m, nil
+	case nestedTuple:
+		arr, ok := column.(*arrowarray.Struct)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("unsupported arrow tuple column %T", column)
+		}
+		if arr.NumField() != len(t.elems) {
+			return reflect.Value{}, fmt.Errorf("arrow tuple has %d fields, expected %d", arr.NumField(), len(t.elems))
+		}
+		values := make([]any, len(t.elems))
+		for j, elem := range t.elems {
+			v, err := elem.fromArrow(arr.Field(j), i)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			values[j] = tupleElement(v)
+		}
+		return reflect.ValueOf(values), nil
+	default:
+		var v any
+		switch arr := column.(type) {
+		case *arrowarray.Boolean:
+			v = arr.Value(i)
+		case *arrowarray.Int8:
+			v = arr.Value(i)
+		case *arrowarray.Int16:
+			v = arr.Value(i)
+		case *arrowarray.Int32:
+			v = arr.Value(i)
+		case *arrowarray.Int64:
+			v = arr.Value(i)
+		case *arrowarray.Uint8:
+			v = arr.Value(i)
+		case *arrowarray.Uint16:
+			v = arr.Value(i)
+		case *arrowarray.Uint32:
+			v = arr.Value(i)
+		case *arrowarray.Uint64:
+			v = arr.Value(i)
+		case *arrowarray.Float32:
+			v = arr.Value(i)
+		case *arrowarray.Float64:
+			v = arr.Value(i)
+		default:
+			value, err := materializeArrowDriverValue(&TypeDesc{Name: t.name}, column, i, t.opts)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			if text, ok := value.(string); ok {
+				return t.scalarValue(text)
+			}
+			v = value
+		}
+		return t.wrap(reflect.ValueOf(v))
+	}
+}
+
+// nestedReader scans the text form of nested values. Strings, dates and other
+// textual scalars are single-quoted with backslash escapes; numbers, booleans
+// and NULL are bare.
+type nestedReader struct {
+	s   string
+	pos int
+}
+
+func (r *nestedReader) skipSpace() {
+	for r.pos < len(r.s) && isSpace(r.s[r.pos]) {
+		r.pos++
+	}
+}
+
+func (r *nestedReader) consume(c byte) bool {
+	r.skipSpace()
+	if r.pos < len(r.s) && r.s[r.pos] == c {
+		r.pos++
+		return true
+	}
+	return false
+}
+
+func (r *nestedReader) expect(c byte) error {
+	if r.consume(c) {
+		return nil
+	}
+	if r.pos == len(r.s) {
+		return fmt.Errorf("unexpected end of input, expected '%c'", c)
+	}
+	return fmt.Errorf("unexpected character '%c' at offset %d, expected '%c'", r.s[r.pos], r.pos, c)
+}
+
+func (r *nestedReader) consumeNull() bool {
+	if !strings.HasPrefix(r.s[r.pos:], "NULL") {
+		return false
+	}
+	end := r.pos + len("NULL")
+	if end < len(r.s) && isIdentPart(r.s[end]) {
+		return false
+	}
+	r.pos = end
+	return true
+}
+
+func (r *nestedReader) scalar() (string, error) {
+	r.skipSpace()
+	if r.pos == len(r.s) {
+		return "", fmt.Errorf("unexpected end of input, expected a value")
+	}
+	if quote := r.s[r.pos]; quote == '\'' || quote == '"' {
+		return r.quoted(quote)
+	}
+	start := r.pos
+	for r.pos < len(r.s) && !strings.ContainsRune(",:]})", rune(r.s[r.pos])) {
+		r.pos++
+	}
+	text := strings.TrimSpace(r.s[start:r.pos])
+	if text == "" {
+		return "", fmt.Errorf("missing value at offset %d", start)
+	}
+	return text, nil
+}
+
+func (r *nestedReader) quoted(quote byte) (string, error) {
+	start := r.pos
+	r.pos++
+	var sb strings.Builder
+	for r.pos < len(r.s) {
+		c := r.s[r.pos]
+		r.pos++
+		switch {
+		case c == '\\' && r.pos < len(r.s):
+			c = r.s[r.pos]
+			r.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 't':
+				c = '\t'
+			case 'r':
+				c = '\r'
+			case '0':
+				c = 0
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			}
+			sb.WriteByte(c)
+		case c == quote:
+			if r.pos < len(r.s) && r.s[r.pos] == quote {
+				sb.WriteByte(c)
+				r.pos++
+				continue
+			}
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string starting at offset %d", start)
+}
+
+type nestedColumnType struct {
+	desc *TypeDesc
+	typ  *nestedType
+	columnTypeDefault
+	isNullable
+}
+
+func (c nestedColumnType) Parse(s string) (driver.Value, error) {
+	if c.checkNull(s) {
+		return nil, nil
+	}
+	return c.typ.parseText(s)
+}
+
+func (c nestedColumnType) ScanType() reflect.Type {
+	return c.typ.goType
+}
+
+func (c nestedColumnType) DatabaseTypeName() string {
+	return c.desc.String()
+}
+
+func (c nestedColumnType) Desc() *TypeDesc {
+	return c.desc
+}
@@ -0,0 +1,373 @@
+package godatabend
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// nestedValue is implemented by Array, Map and the Tuple types, which the
+// encoder writes as literals instead of going through their Value method.
+type nestedValue interface {
+	encodeNested(e *textEncoder) ([]byte, error)
+}
+
+// typeDescer lets the Tuple types describe their text form, which cannot be
+// derived from the struct alone.
+type typeDescer interface {
+	nestedTypeDesc() *TypeDesc
+}
+
+// Array is a typed Array value. It can be scanned from Array columns, converting
+// the elements to T, e.g. an Array(Int32) column into an Array[int64], and bound
+// as a parameter. Use a pointer element type such as Array[*int32] for arrays of
+// nullable values. A NULL array is scanned as nil.
+type Array[T any] []T
+
+// Value implements driver.Valuer
+func (a Array[T]) Value() (driver.Value, error) {
+	return textEncode.Encode(a)
+}
+
+// Scan implements sql.Scanner
+func (a *Array[T]) Scan(src any) error {
+	return scanNested(reflect.ValueOf(a).Elem(), src)
+}
+
+func (a Array[T]) encodeNested(e *textEncoder) ([]byte, error) {
+	return e.encodeArray(reflect.ValueOf([]T(a)))
+}
+
+// Map is a typed Map value. It can be scanned from Map columns, converting keys
+// and values to K and V, and bound as a parameter. A NULL map is scanned as nil.
+type Map[K comparable, V any] map[K]V
+
+// Value implements driver.Valuer
+func (m Map[K, V]) Value() (driver.Value, error) {
+	return textEncode.Encode(m)
+}
+
+// Scan implements sql.Scanner
+func (m *Map[K, V]) Scan(src any) error {
+	return scanNested(reflect.ValueOf(m).Elem(), src)
+}
+
+func (m Map[K, V]) encodeNested(e *textEncoder) ([]byte, error) {
+	return e.encodeMap(reflect.ValueOf(map[K]V(m)))
+}
+
+// Tuple2 is a typed Tuple of two elements.
+type Tuple2[A, B any] struct {
+	V1 A
+	V2 B
+}
+
+// Value implements driver.Valuer
+func (t Tuple2[A, B]) Value() (driver.Value, error) {
+	return textEncode.Encode(t)
+}
+
+// Scan implements sql.Scanner
+func (t *Tuple2[A, B]) Scan(src any) error {
+	return scanTuple(t, src, &t.V1, &t.V2)
+}
+
+func (t Tuple2[A, B]) encodeNested(e *textEncoder) ([]byte, error) {
+	return e.encodeTuple(reflect.ValueOf(t))
+}
+
+func (Tuple2[A, B]) nestedTypeDesc() *TypeDesc {
+	return tupleTypeDesc(reflect.TypeFor[A](), reflect.TypeFor[B]())
+}
+
+// Tuple3 is a typed Tuple of three elements.
+type Tuple3[A, B, C any] struct {
+	V1 A
+	V2 B
+	V3 C
+}
+
+// Value implements driver.Valuer
+func (t Tuple3[A, B, C]) Value() (driver.Value, error) {
+	return textEncode.Encode(t)
+}
+
+// Scan implements sql.Scanner
+func (t *Tuple3[A, B, C]) Scan(src any) error {
+	return scanTuple(t, src, &t.V1, &t.V2, &t.V3)
+}
+
+func (t Tuple3[A, B, C]) encodeNested(e *textEncoder) ([]byte, error) {
+	return e.encodeTuple(reflect.ValueOf(t))
+}
+
+func (Tuple3[A, B, C]) nestedTypeDesc() *TypeDesc {
+	return tupleTypeDesc(reflect.TypeFor[A](), reflect.TypeFor[B](), reflect.TypeFor[C]())
+}
+
+// scanNested assigns a nested value, or its text form, to dst.
+func scanNested(dst reflect.Value, src any) error {
+	if b, ok := src.([]byte); ok {
+		src = string(b)
+	}
+	if s, ok := src.(string); ok {
+		v, err := parseNestedText(dst.Type(), s)
+		if err != nil {
+			return err
+		}
+		src = v
+	}
+	return assignValue(dst, src, false)
+}
+
+// parseNestedText parses the text form of a value of type t.
+func parseNestedText(t reflect.Type, s string) (any, error) {
+	nt, err := newNestedType(nestedTypeDescOf(t), defaultColumnTypeOptions())
+	if err != nil {
+		return nil, err
+	}
+	return nt.parseText(s)
+}
+
+// scanTuple assigns the elements of a Tuple value to fields.
+func scanTuple(t any, src any, fields ...any) error {
+	switch s := src.(type) {
+	case nil:
+		return fmt.Errorf("cannot scan NULL into %T, use sql.Null", t)
+	case string:
+		v, err := parseNestedText(reflect.TypeOf(t).Elem(), s)
+		if err != nil {
+			return err
+		}
+		return scanTuple(t, v, fields...)
+	case []byte:
+		return scanTuple(t, string(s), fields...)
+	case []any:
+		if len(s) != len(fields) {
+			return fmt.Errorf("cannot scan a tuple of %d elements into %T", len(s), t)
+		}
+		for i, field := range fields {
+			if err := assignNested(reflect.ValueOf(field).Elem(), s[i]); err != nil {
+				return fmt.Errorf("tuple element %d: %w", i, err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into %T", src, t)
+	}
+}
+
+// nestedTypeDescOf returns the type whose text form is parsed for a Go type.
+// Scalars other than timestamps and binaries are read as strings and converted
+// by assignNested, and every element is nullable so that NULL is only rejected
+// where the Go type cannot hold it.
+func nestedTypeDescOf(t reflect.Type) *TypeDesc {
+	if t.Kind() == reflect.Pointer {
+		return nestedTypeDescOf(t.Elem())
+	}
+	if d, ok := reflect.Zero(t).Interface().(typeDescer); ok {
+		return d.nestedTypeDesc()
+	}
+	switch t {
+	case reflectTypeTime:
+		return &TypeDesc{Name: "Timestamp"}
+	case reflectTypeBytes:
+		return &TypeDesc{Name: "Binary"}
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		return &TypeDesc{Name: "Array", Args: []*TypeDesc{nullableDesc(nestedTypeDescOf(t.Elem()))}}
+	case reflect.Map:
+		return &TypeDesc{Name: "Map", Args: []*TypeDesc{nestedTypeDescOf(t.Key()), nullableDesc(nestedTypeDescOf(t.Elem()))}}
+	default:
+		return &TypeDesc{Name: "String"}
+	}
+}
+
+func tupleTypeDesc(types ...reflect.Type) *TypeDesc {
+	desc := &TypeDesc{Name: "Tuple"}
+	for _, t := range types {
+		desc.Args = append(desc.Args, nullableDesc(nestedTypeDescOf(t)))
+	}
+	return desc
+}
+
+func nullableDesc(desc *TypeDesc) *TypeDesc {
+	desc.Nullable = true
+	return desc
+}
+
+// assignNested stores src in dst, converting slices, maps and scalars element
+// by element, and using sql.Scanner and encoding.TextUnmarshaler where the
+// destination implements them.
+func assignNested(dst reflect.Value, src any) error {
+	return assignValue(dst, src, true)
+}
+
+// assignValue is assignNested, with scan false when dst is the Scanner that is
+// being called, which must not call itself again.
+func assignValue(dst reflect.Value, src any, scan bool) error {
+	sv := reflect.ValueOf(src)
+	for sv.Kind() == reflect.Pointer && !sv.IsNil() && !sv.Type().AssignableTo(dst.Type()) {
+		sv = sv.Elem()
+	}
+	if !sv.IsValid() || sv.Kind() == reflect.Pointer && sv.IsNil() {
+		switch dst.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+			dst.SetZero()
+			return nil
+		}
+		if scanner, ok := dst.Addr().Interface().(sql.Scanner); ok && scan {
+			return scanner.Scan(nil)
+		}
+		return fmt.Errorf("cannot scan NULL into %s", dst.Type())
+	}
+	src = sv.Interface()
+	if sv.Type().AssignableTo(dst.Type()) {
+		dst.Set(sv)
+		return nil
+	}
+	if scanner, ok := dst.Addr().Interface().(sql.Scanner); ok && scan {
+		return scanner.Scan(src)
+	}
+
+	switch dst.Kind() {
+	case reflect.Pointer:
+		p := reflect.New(dst.Type().Elem())
+		if err := assignNested(p.Elem(), src); err != nil {
+			return err
+		}
+		dst.Set(p)
+		return nil
+	case reflect.Slice:
+		if sv.Kind() != reflect.Slice && sv.Kind() != reflect.Array {
+			break
+		}
+		if sv.Kind() == reflect.Slice && sv.IsNil() {
+			dst.SetZero()
+			return nil
+		}
+		out := reflect.MakeSlice(dst.Type(), sv.Len(), sv.Len())
+		for i := range sv.Len() {
+			if err := assignNested(out.Index(i), sv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		dst.Set(out)
+		return nil
+	case reflect.Map:
+		if sv.Kind() != reflect.Map {
+			break
+		}
+		if sv.IsNil() {
+			dst.SetZero()
+			return nil
+		}
+		out := reflect.MakeMapWithSize(dst.Type(), sv.Len())
+		iter := sv.MapRange()
+		for iter.Next() {
+			k := reflect.New(dst.Type().Key()).Elem()
+			if err := assignNested(k, iter.Key().Interface()); err != nil {
+				return err
+			}
+			v := reflect.New(dst.Type().Elem()).Elem()
+			if err := assignNested(v, iter.Value().Interface()); err != nil {
+				return err
+			}
+			out.SetMapIndex(k, v)
+		}
+		dst.Set(out)
+		return nil
+	}
+
+	return assignScalar(dst, sv)
+}
+
+// assignScalar converts between numbers, booleans and their text forms with
+// the same range checks as database/sql.
+func assignScalar(dst, sv reflect.Value) error {
+	text, isText := scalarText(sv)
+	if u, ok := dst.Addr().Interface().(encoding.TextUnmarshaler); ok && isText {
+		return u.UnmarshalText([]byte(text))
+	}
+	var err error
+	switch dst.Kind() {
+	case reflect.String:
+		if isText {
+			dst.SetString(text)
+			return nil
+		}
+	case reflect.Bool:
+		if isText {
+			var b bool
+			if b, err = strconv.ParseBool(text); err == nil {
+				dst.SetBool(b)
+				return nil
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if isText {
+			var i int64
+			if i, err = strconv.ParseInt(text, 10, dst.Type().Bits()); err == nil {
+				dst.SetInt(i)
+				return nil
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if isText {
+			var u uint64
+			if u, err = strconv.ParseUint(text, 10, dst.Type().Bits()); err == nil {
+				dst.SetUint(u)
+				return nil
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		if isText {
+			var f float64
+			if f, err = strconv.ParseFloat(text, dst.Type().Bits()); err == nil {
+				dst.SetFloat(f)
+				return nil
+			}
+		}
+	case reflect.Interface:
+		if sv.Type().Implements(dst.Type()) {
+			dst.Set(sv)
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("cannot convert %v to %s: %w", sv.Interface(), dst.Type(), err)
+	}
+	if sv.Kind() == dst.Kind() && sv.Type().ConvertibleTo(dst.Type()) {
+		dst.Set(sv.Convert(dst.Type()))
+		return nil
+	}
+	return fmt.Errorf("cannot convert %T to %s", sv.Interface(), dst.Type())
+}
+
+// scalarText returns the text form of numbers, booleans, strings and values
+// with a String method.
+func scalarText(sv reflect.Value) (string, bool) {
+	switch sv.Kind() {
+	case reflect.String:
+		return sv.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(sv.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(sv.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(sv.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(sv.Float(), 'g', -1, sv.Type().Bits()), true
+	}
+	if b, ok := sv.Interface().([]byte); ok {
+		return string(b), true
+	}
+	if s, ok := sv.Interface().(fmt.Stringer); ok {
+		return s.String(), true
+	}
+	return "", false
+}
@@ -0,0 +1,97 @@
+package godatabend
+
+import (
+	"database/sql"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenericNestedScan(t *testing.T) {
+	db := sql.OpenDB(&fakeConnector{
+		resp: &QueryResponse{
+			Schema: &[]DataField{
+				{Name: "a", Type: "Nullable(Array(Int32 NULL))"},
+				{Name: "m", Type: "Map(String, Array(Int64))"},
+				{Name: "t", Type: "Tuple(String, Decimal(10, 2) NULL)"},
+			},
+			Data: [][]*string{
+				{strPtr("[1,NULL,3]"), strPtr("{'a':[1,2],'b':[]}"), strPtr("('x',1.50)")},
+				{nil, strPtr("{}"), strPtr("('y',NULL)")},
+			},
+		},
+	})
+
+	rows, err := db.Query("x")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	require.True(t, rows.Next())
+	var (
+		a  Array[*int64]
+		m  Map[string, Array[uint8]]
+		tp Tuple2[string, *Decimal]
+	)
+	require.NoError(t, rows.Scan(&a, &m, &tp))
+	one, three := int64(1), int64(3)
+	assert.Equal(t, Array[*int64]{&one, nil, &three}, a)
+	assert.Equal(t, Map[string, Array[uint8]]{"a": {1, 2}, "b": {}}, m)
+	assert.Equal(t, "x", tp.V1)
+	require.NotNil(t, tp.V2)
+	assert.Equal(t, "1.50", tp.V2.String())
+
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&a, &m, &tp))
+	assert.Nil(t, a)
+	assert.Empty(t, m)
+	assert.Equal(t, Tuple2[string, *Decimal]{V1: "y"}, tp)
+
+	var ints Array[int32]
+	assert.ErrorContains(t, ints.Scan([]*int32{nil}), "cannot scan NULL into int32")
+	var small Array[int8]
+	assert.Error(t, small.Scan([]int64{300}))
+}
+
+func TestGenericNestedScanIPs(t *testing.T) {
+	var addrs Array[netip.Addr]
+	require.NoError(t, addrs.Scan([]IPAddr{{netip.MustParseAddr("::1")}}))
+	assert.Equal(t, Array[netip.Addr]{netip.MustParseAddr("::1")}, addrs)
+	var ips Array[IPAddr]
+	require.NoError(t, ips.Scan([]IPAddr{{netip.MustParseAddr("10.0.0.1")}}))
+	assert.Equal(t, Array[IPAddr]{{netip.MustParseAddr("10.0.0.1")}}, ips)
+}
+
+func TestGenericNestedScanText(t *testing.T) {
+	var a Array[Array[string]]
+	require.NoError(t, a.Scan(`[['a','b\'c'],[]]`))
+	assert.Equal(t, Array[Array[string]]{{"a", "b'c"}, {}}, a)
+
+	var m Map[int, *float64]
+	require.NoError(t, m.Scan([]byte("{1:0.5,2:NULL}")))
+	half := 0.5
+	assert.Equal(t, Map[int, *float64]{1: &half, 2: nil}, m)
+
+	var tp Tuple3[time.Time, bool, Array[int]]
+	require.NoError(t, tp.Scan("('2024-01-02 03:04:05.000000',true,[1,2])"))
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), tp.V1)
+	assert.True(t, tp.V2)
+	assert.Equal(t, Array[int]{1, 2}, tp.V3)
+
+	assert.Error(t, tp.Scan(nil))
+	assert.Error(t, tp.Scan([]any{1}))
+	assert.Error(t, a.Scan("[1"))
+}
+
+func TestGenericNestedRoundTrip(t *testing.T) {
+	in := Map[string, Tuple2[int, Array[*string]]]{"k": {V1: 1, V2: Array[*string]{strPtr("v"), nil}}}
+	dv, err := in.Value()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("{'k':(1,['v',NULL])}"), dv)
+
+	var out Map[string, Tuple2[int, Array[*string]]]
+	require.NoError(t, out.Scan(dv))
+	assert.Equal(t, in, out)
+}
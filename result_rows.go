@@ -7,8 +7,9 @@ import (
 	"github.com/pkg/errors"
 )
 
-func queryResponseColumnTypeOptions(settings *Settings) (*ColumnTypeOptions, error) {
+func queryResponseColumnTypeOptions(settings *Settings, types *TypeRegistry) (*ColumnTypeOptions, error) {
 	opts := defaultColumnTypeOptions()
+	opts.SetTypeRegistry(types)
 	if settings == nil {
 		return opts, nil
 	}
@@ -27,7 +28,7 @@ func queryResponseColumnTypeOptions(settings *Settings) (*ColumnTypeOptions, err
 	return opts, nil
 }
 
-func materializeJSONQueryRows(resp *QueryResponse) error {
+func materializeJSONQueryRows(resp *QueryResponse, types *TypeRegistry) error {
 	if resp == nil || resp.typedRows != nil || len(resp.Data) == 0 {
 		return nil
 	}
@@ -35,7 +36,7 @@ func materializeJSONQueryRows(resp *QueryResponse) error {
 		return errors.New("query rows and schema do not match")
 	}
 
-	opts, err := queryResponseColumnTypeOptions(resp.Settings)
+	opts, err := queryResponseColumnTypeOptions(resp.Settings, types)
 	if err != nil {
 		return err
 	}
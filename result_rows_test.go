@@ -32,7 +32,7 @@ func TestDecodeJSONResponseMaterializesTypedRows(t *testing.T) {
 	body, err := json.Marshal(resp)
 	require.NoError(t, err)
 
-	decoded, err := decodeQueryResponse(&rawHTTPResponse{
+	decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
 		headers: http.Header{contentType: []string{jsonContentType}},
 		body:    body,
 	})
@@ -89,7 +89,7 @@ func TestDecodeJSONResponseMaterializesGeoRows(t *testing.T) {
 			body, err := json.Marshal(resp)
 			require.NoError(t, err)
 
-			decoded, err := decodeQueryResponse(&rawHTTPResponse{
+			decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
 				headers: http.Header{contentType: []string{jsonContentType}},
 				body:    body,
 			})
@@ -140,7 +140,7 @@ func TestDecodeJSONResponseMaterializesBinaryRows(t *testing.T) {
 			body, err := json.Marshal(resp)
 			require.NoError(t, err)
 
-			decoded, err := decodeQueryResponse(&rawHTTPResponse{
+			decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
 				headers: http.Header{contentType: []string{jsonContentType}},
 				body:    body,
 			})
@@ -77,7 +77,7 @@ func (dc *DatabendConn) newNextRows(ctx context.Context, resp *QueryResponse) (r
 		return nil, errors.New("newNextRows: internal error, data and schema not match")
 	}
 	if resp.typedRows == nil {
-		if err := materializeJSONQueryRows(resp); err != nil {
+		if err := materializeJSONQueryRows(resp, dc.typeRegistry()); err != nil {
 			return nil, err
 		}
 	}
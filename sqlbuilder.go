@@ -0,0 +1,254 @@
+package godatabend
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// QuoteIdentifier quotes a database, table or column name so that it may be a
+// reserved word or contain any character, and is matched case-sensitively.
+func QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// QuoteQualifiedName quotes each part of a dotted name, e.g. QuoteQualifiedName("db", "t")
+// returns `db`.`t`. Empty parts are skipped, so an unset database can be passed as is.
+func QuoteQualifiedName(parts ...string) string {
+	quoted := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			quoted = append(quoted, QuoteIdentifier(part))
+		}
+	}
+	return strings.Join(quoted, ".")
+}
+
+// QuoteLiteral quotes and escapes s as a SQL string literal.
+func QuoteLiteral(s string) string {
+	return quote(escape(s))
+}
+
+func quoteIdentifiers(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = QuoteIdentifier(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// isPlainIdentifier reports whether s can be used unquoted, such as a stage name
+// in an `@stage` reference.
+func isPlainIdentifier(s string) bool {
+	if s == "" || !isIdentStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isIdentPart(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatOptions renders `KEY = value` pairs in key order. Booleans and numbers
+// are emitted unquoted; other values are string literals, unless keywords is set
+// and the value is a bare word (as `ON_ERROR = continue` requires).
+func formatOptions(options map[string]string, keywords bool) string {
+	keys := make([]string, 0, len(options))
+	for k := range options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v := options[k]
+		switch {
+		case strings.EqualFold(v, "true"), strings.EqualFold(v, "false"):
+			v = strings.ToLower(v)
+		case isInteger(v):
+		case keywords && isPlainIdentifier(v):
+		default:
+			v = QuoteLiteral(v)
+		}
+		parts = append(parts, strings.ToUpper(k)+" = "+v)
+	}
+	return strings.Join(parts, " ")
+}
+
+func isInteger(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+// InsertBuilder builds an `INSERT INTO <table> [(<columns>)] VALUES` statement,
+// the form accepted by batch inserts and stage attachments.
+type InsertBuilder struct {
+	Database string
+	Table    string
+	Columns  []string
+}
+
+func (b InsertBuilder) Build() (string, error) {
+	if b.Table == "" {
+		return "", errors.New("insert: table name required")
+	}
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO ")
+	sb.WriteString(QuoteQualifiedName(b.Database, b.Table))
+	if len(b.Columns) > 0 {
+		sb.WriteString(" (")
+		sb.WriteString(quoteIdentifiers(b.Columns))
+		sb.WriteString(")")
+	}
+	sb.WriteString(" VALUES")
+	return sb.String(), nil
+}
+
+// CopyIntoBuilder builds a `COPY INTO <table> FROM <stage>` statement.
+type CopyIntoBuilder struct {
+	Database string
+	Table    string
+	Columns  []string
+	From     *StageLocation
+	// Files limits the load to the given files, relative to From
+	Files []string
+	// Pattern limits the load to the files matching the regular expression
+	Pattern     string
+	FileFormat  map[string]string
+	CopyOptions map[string]string
+	// Format and Copy are typed alternatives to FileFormat and CopyOptions,
+	// validated by Build.
+	Format FileFormatOptions
+	Copy   *CopyOptions
+}
+
+func (b CopyIntoBuilder) Build() (string, error) {
+	if b.Table == "" {
+		return "", errors.New("copy into: table name required")
+	}
+	if b.From == nil {
+		return "", errors.New("copy into: stage location required")
+	}
+	if b.From.Name != "~" && !isPlainIdentifier(b.From.Name) {
+		return "", errors.Errorf("copy into: invalid stage name %q", b.From.Name)
+	}
+	if !isPlainStagePath(b.From.Path) {
+		return "", errors.Errorf("copy into: invalid stage path %q", b.From.Path)
+	}
+	fileFormat, err := builderFileFormat("copy into", b.FileFormat, b.Format)
+	if err != nil {
+		return "", err
+	}
+	copyOptions := b.CopyOptions
+	if b.Copy != nil {
+		if len(copyOptions) > 0 {
+			return "", errors.New("copy into: CopyOptions and Copy are mutually exclusive")
+		}
+		if copyOptions, err = b.Copy.Options(); err != nil {
+			return "", err
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString("COPY INTO ")
+	sb.WriteString(QuoteQualifiedName(b.Database, b.Table))
+	if len(b.Columns) > 0 {
+		sb.WriteString(" (")
+		sb.WriteString(quoteIdentifiers(b.Columns))
+		sb.WriteString(")")
+	}
+	sb.WriteString(" FROM ")
+	sb.WriteString(b.From.String())
+	if len(b.Files) > 0 {
+		files := make([]string, len(b.Files))
+		for i, f := range b.Files {
+			files[i] = QuoteLiteral(f)
+		}
+		sb.WriteString(" FILES = (")
+		sb.WriteString(strings.Join(files, ", "))
+		sb.WriteString(")")
+	}
+	if b.Pattern != "" {
+		sb.WriteString(" PATTERN = ")
+		sb.WriteString(QuoteLiteral(b.Pattern))
+	}
+	if len(fileFormat) > 0 {
+		sb.WriteString(" FILE_FORMAT = (")
+		sb.WriteString(formatOptions(fileFormat, false))
+		sb.WriteString(")")
+	}
+	if len(copyOptions) > 0 {
+		sb.WriteString(" ")
+		sb.WriteString(formatOptions(copyOptions, true))
+	}
+	return sb.String(), nil
+}
+
+// CreateStageBuilder builds a `CREATE STAGE` statement. Without URL an internal
+// stage is created.
+type CreateStageBuilder struct {
+	Name        string
+	OrReplace   bool
+	IfNotExists bool
+	URL         string
+	Connection  map[string]string
+	FileFormat  map[string]string
+	// Format is a typed alternative to FileFormat, validated by Build.
+	Format FileFormatOptions
+}
+
+func (b CreateStageBuilder) Build() (string, error) {
+	// stage names cannot be quoted in `@stage` references
+	if !isPlainIdentifier(b.Name) {
+		return "", errors.Errorf("create stage: invalid stage name %q", b.Name)
+	}
+	if b.OrReplace && b.IfNotExists {
+		return "", errors.New("create stage: OR REPLACE and IF NOT EXISTS are mutually exclusive")
+	}
+	fileFormat, err := builderFileFormat("create stage", b.FileFormat, b.Format)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString("CREATE ")
+	if b.OrReplace {
+		sb.WriteString("OR REPLACE ")
+	}
+	sb.WriteString("STAGE ")
+	if b.IfNotExists {
+		sb.WriteString("IF NOT EXISTS ")
+	}
+	sb.WriteString(b.Name)
+	if b.URL != "" {
+		sb.WriteString(" URL = ")
+		sb.WriteString(QuoteLiteral(b.URL))
+		if len(b.Connection) > 0 {
+			sb.WriteString(" CONNECTION = (")
+			sb.WriteString(formatOptions(b.Connection, false))
+			sb.WriteString(")")
+		}
+	} else if len(b.Connection) > 0 {
+		return "", errors.New("create stage: connection requires a URL")
+	}
+	if len(fileFormat) > 0 {
+		sb.WriteString(" FILE_FORMAT = (")
+		sb.WriteString(formatOptions(fileFormat, false))
+		sb.WriteString(")")
+	}
+	return sb.String(), nil
+}
+
+// builderFileFormat returns the FILE_FORMAT options of a statement, given as a
+// map or as a FileFormatOptions.
+func builderFileFormat(stmt string, options map[string]string, format FileFormatOptions) (map[string]string, error) {
+	if format == nil {
+		return options, nil
+	}
+	if len(options) > 0 {
+		return nil, errors.Errorf("%s: FileFormat and Format are mutually exclusive", stmt)
+	}
+	return format.Options()
+}
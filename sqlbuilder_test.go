@@ -0,0 +1,103 @@
+package godatabend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, "`t`", QuoteIdentifier("t"))
+	assert.Equal(t, "`MyTable`", QuoteIdentifier("MyTable"))
+	assert.Equal(t, "`select`", QuoteIdentifier("select"))
+	assert.Equal(t, "`a``b`", QuoteIdentifier("a`b"))
+	assert.Equal(t, "`db`.`t`", QuoteQualifiedName("db", "t"))
+	assert.Equal(t, "`t`", QuoteQualifiedName("", "t"))
+	assert.Equal(t, `'it\'s'`, QuoteLiteral("it's"))
+}
+
+func TestInsertBuilder(t *testing.T) {
+	q, err := InsertBuilder{Database: "db", Table: "Order", Columns: []string{"id", "user name"}}.Build()
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO `db`.`Order` (`id`, `user name`) VALUES", q)
+
+	q, err = InsertBuilder{Table: "t"}.Build()
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO `t` VALUES", q)
+	table, ok := parseInsertTable(q)
+	assert.True(t, ok)
+	assert.Equal(t, "`t`", table)
+
+	_, err = InsertBuilder{}.Build()
+	assert.Error(t, err)
+}
+
+func TestCopyIntoBuilder(t *testing.T) {
+	q, err := CopyIntoBuilder{
+		Table:       "t",
+		Columns:     []string{"a"},
+		From:        &StageLocation{Name: "~", Path: "batch/"},
+		Files:       []string{"1.csv", "2.csv"},
+		FileFormat:  map[string]string{"type": "CSV", "field_delimiter": ",", "skip_header": "0"},
+		CopyOptions: map[string]string{"purge": "true", "on_error": "continue"},
+	}.Build()
+	require.NoError(t, err)
+	assert.Equal(t, "COPY INTO `t` (`a`) FROM @~/batch/ FILES = ('1.csv', '2.csv') "+
+		"FILE_FORMAT = (FIELD_DELIMITER = ',' SKIP_HEADER = 0 TYPE = 'CSV') ON_ERROR = continue PURGE = true", q)
+
+	q, err = CopyIntoBuilder{Table: "t", From: &StageLocation{Name: "s1"}, Pattern: `.*\.csv`}.Build()
+	require.NoError(t, err)
+	assert.Equal(t, `COPY INTO `+"`t`"+` FROM @s1/ PATTERN = '.*\\.csv'`, q)
+
+	q, err = CopyIntoBuilder{
+		Table:  "t",
+		From:   &StageLocation{Name: "s1", Path: "data/"},
+		Format: NDJSONFormat{MissingFieldAs: "field_default"},
+		Copy:   &CopyOptions{OnError: "abort_3", Force: true},
+	}.Build()
+	require.NoError(t, err)
+	assert.Equal(t, "COPY INTO `t` FROM @s1/data/ FILE_FORMAT = (MISSING_FIELD_AS = 'field_default' TYPE = 'NDJSON') "+
+		"FORCE = true ON_ERROR = abort_3", q)
+
+	_, err = CopyIntoBuilder{Table: "t", From: &StageLocation{Name: "s1"}, Format: CSVFormat{Quote: "x"}}.Build()
+	assert.Error(t, err)
+	_, err = CopyIntoBuilder{Table: "t", From: &StageLocation{Name: "s1"}, Copy: &CopyOptions{OnError: "skip"}}.Build()
+	assert.Error(t, err)
+	_, err = CopyIntoBuilder{
+		Table: "t", From: &StageLocation{Name: "s1"},
+		FileFormat: map[string]string{"type": "CSV"}, Format: CSVFormat{},
+	}.Build()
+	assert.Error(t, err)
+	_, err = CopyIntoBuilder{Table: "t", From: &StageLocation{Name: "s1; DROP TABLE t"}}.Build()
+	assert.Error(t, err)
+	_, err = CopyIntoBuilder{Table: "t", From: &StageLocation{Name: "s1", Path: "a b/"}}.Build()
+	assert.Error(t, err)
+	_, err = CopyIntoBuilder{Table: "t"}.Build()
+	assert.Error(t, err)
+}
+
+func TestCreateStageBuilder(t *testing.T) {
+	q, err := CreateStageBuilder{Name: "s1", IfNotExists: true}.Build()
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE STAGE IF NOT EXISTS s1", q)
+
+	q, err = CreateStageBuilder{
+		Name:       "s2",
+		OrReplace:  true,
+		URL:        "s3://bucket/path/",
+		Connection: map[string]string{"access_key_id": "ak", "secret_access_key": "it's"},
+		FileFormat: map[string]string{"type": "PARQUET"},
+	}.Build()
+	require.NoError(t, err)
+	assert.Equal(t, `CREATE OR REPLACE STAGE s2 URL = 's3://bucket/path/' CONNECTION = (ACCESS_KEY_ID = 'ak' SECRET_ACCESS_KEY = 'it\'s') FILE_FORMAT = (TYPE = 'PARQUET')`, q)
+
+	q, err = CreateStageBuilder{Name: "s3", Format: CSVFormat{SkipHeader: 1}}.Build()
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE STAGE s3 FILE_FORMAT = (SKIP_HEADER = 1 TYPE = 'CSV')", q)
+
+	_, err = CreateStageBuilder{Name: "bad name"}.Build()
+	assert.Error(t, err)
+	_, err = CreateStageBuilder{Name: "s", Connection: map[string]string{"a": "b"}}.Build()
+	assert.Error(t, err)
+}
@@ -0,0 +1,203 @@
+package godatabend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// StageFile is a file listed by ListStage.
+type StageFile struct {
+	// Name is the path of the file in the stage.
+	Name         string
+	Size         int64
+	MD5          string
+	LastModified time.Time
+}
+
+// stageLastModifiedLayouts are the layouts of the last_modified column of LIST.
+var stageLastModifiedLayouts = []string{
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+// ListStage lists the files under stage, e.g. `@s1/path/`, keeping those
+// whose path matches the regular expression pattern if it is not empty.
+func (c *APIClient) ListStage(ctx context.Context, stage *StageLocation, pattern string) ([]StageFile, error) {
+	if err := checkStageLocation(stage); err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("LIST %s", stage)
+	if pattern != "" {
+		query += " PATTERN = " + QuoteLiteral(pattern)
+	}
+	resp, err := c.querySyncJSON(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, "list stage failed")
+	}
+	return parseStageFiles(resp.Data)
+}
+
+// parseStageFiles reads the name, size, md5 and last_modified columns of the
+// result of LIST.
+func parseStageFiles(data [][]*string) ([]StageFile, error) {
+	files := make([]StageFile, 0, len(data))
+	for _, row := range data {
+		if len(row) < 4 || row[0] == nil || row[1] == nil {
+			return nil, errors.New("unexpected result of LIST")
+		}
+		file := StageFile{Name: *row[0]}
+		var err error
+		if file.Size, err = strconv.ParseInt(*row[1], 10, 64); err != nil {
+			return nil, errors.Wrapf(err, "file %s: invalid size", file.Name)
+		}
+		if row[2] != nil {
+			// object stores return the ETag, quoted
+			file.MD5 = strings.Trim(*row[2], `"`)
+		}
+		if row[3] != nil {
+			if file.LastModified, err = parseStageLastModified(*row[3]); err != nil {
+				return nil, errors.Wrapf(err, "file %s", file.Name)
+			}
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func parseStageLastModified(s string) (time.Time, error) {
+	for _, layout := range stageLastModifiedLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, errors.Errorf("invalid last_modified %q", s)
+}
+
+// DownloadFromStage writes the file at stage to w, from a URL presigned with
+// PRESIGN DOWNLOAD. A failed download is retried once with a new URL, as the
+// first may have expired; downloads need presigned URLs, so they fail with
+// PresignedURLDisabled.
+func (c *APIClient) DownloadFromStage(ctx context.Context, stage *StageLocation, w io.Writer) error {
+	if err := checkStageLocation(stage); err != nil {
+		return err
+	}
+	if c.PresignedURLDisabled {
+		return errors.New("download from stage requires presigned urls")
+	}
+	written, err := c.downloadByPresignURL(ctx, stage, w)
+	// retry unless part of the file was written, which cannot be taken back
+	if err != nil && written == 0 && ctx.Err() == nil {
+		_, err = c.downloadByPresignURL(ctx, stage, w)
+	}
+	return err
+}
+
+func (c *APIClient) downloadByPresignURL(ctx context.Context, stage *StageLocation, w io.Writer) (int64, error) {
+	presigned, err := c.GetPresignedDownloadURL(ctx, stage)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get presigned url")
+	}
+	req, err := newPresignedRequest(ctx, presigned, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to download from stage by presigned url")
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return 0, errors.Errorf("failed to download from stage by presigned url, status code: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+	written, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return written, errors.Wrap(err, "failed to download from stage by presigned url")
+	}
+	return written, nil
+}
+
+// RemoveFromStage removes the files under stage, keeping those whose path does
+// not match the regular expression pattern if it is not empty.
+func (c *APIClient) RemoveFromStage(ctx context.Context, stage *StageLocation, pattern string) error {
+	if err := checkStageLocation(stage); err != nil {
+		return err
+	}
+	query := fmt.Sprintf("REMOVE %s", stage)
+	if pattern != "" {
+		query += " PATTERN = " + QuoteLiteral(pattern)
+	}
+	if _, err := c.querySyncJSON(ctx, query); err != nil {
+		return errors.Wrap(err, "remove from stage failed")
+	}
+	return nil
+}
+
+// CreateStage creates the stage described by b.
+func (c *APIClient) CreateStage(ctx context.Context, b CreateStageBuilder) error {
+	query, err := b.Build()
+	if err != nil {
+		return err
+	}
+	if _, err := c.querySyncJSON(ctx, query); err != nil {
+		return errors.Wrap(err, "create stage failed")
+	}
+	return nil
+}
+
+// DropStage drops the stage name, and with ifExists does nothing if there is no
+// such stage.
+func (c *APIClient) DropStage(ctx context.Context, name string, ifExists bool) error {
+	if !isPlainIdentifier(name) {
+		return errors.Errorf("drop stage: invalid stage name %q", name)
+	}
+	query := "DROP STAGE " + name
+	if ifExists {
+		query = "DROP STAGE IF EXISTS " + name
+	}
+	if _, err := c.querySyncJSON(ctx, query); err != nil {
+		return errors.Wrap(err, "drop stage failed")
+	}
+	return nil
+}
+
+// checkStageLocation reports an error unless stage is the user stage `~` or a
+// stage name that can be used in an `@stage/path` reference, with a path that
+// can be written unquoted in it.
+func checkStageLocation(stage *StageLocation) error {
+	if stage == nil {
+		return errors.New("stage location required")
+	}
+	if stage.Name != "~" && !isPlainIdentifier(stage.Name) {
+		return errors.Errorf("invalid stage name %q", stage.Name)
+	}
+	if !isPlainStagePath(stage.Path) {
+		return errors.Errorf("invalid stage path %q", stage.Path)
+	}
+	return nil
+}
+
+// isPlainStagePath reports whether path can be written in an `@stage/path`
+// reference as is, without characters such as spaces, quotes or `;` that
+// would end the reference.
+func isPlainStagePath(path string) bool {
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if isIdentPart(c) || strings.IndexByte("/.-=+%~", c) >= 0 {
+			continue
+		}
+		return false
+	}
+	return true
+}
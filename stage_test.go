@@ -0,0 +1,138 @@
+package godatabend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStageServer starts a server that answers LIST and PRESIGN DOWNLOAD, serves
+// the presigned file, failing its first downloadFailures downloads, and records
+// the SQL it received.
+func newStageServer(t *testing.T, downloadFailures int) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		queries []string
+		server  *httptest.Server
+	)
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/query":
+			var req QueryRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			mu.Lock()
+			queries = append(queries, req.SQL)
+			mu.Unlock()
+			resp := QueryResponse{ID: "q", State: "Succeeded", Schema: &[]DataField{}}
+			switch {
+			case strings.HasPrefix(req.SQL, "LIST"):
+				resp.Schema = &[]DataField{
+					{Name: "name", Type: "String"}, {Name: "size", Type: "UInt64"}, {Name: "md5", Type: "Nullable(String)"},
+					{Name: "last_modified", Type: "Nullable(String)"}, {Name: "creator", Type: "Nullable(String)"},
+				}
+				resp.Data = [][]*string{
+					{strPtr("data/a.csv"), strPtr("12"), strPtr(`"5d41402abc4b2a76b9719d911017c592"`), strPtr("2024-01-02 03:04:05.123 +0000"), strPtr("NULL")},
+					{strPtr("data/b.csv"), strPtr("0"), nil, nil, nil},
+				}
+			case strings.HasPrefix(req.SQL, "PRESIGN DOWNLOAD"):
+				resp.Schema = &[]DataField{{Name: "method", Type: "String"}, {Name: "headers", Type: "Variant"}, {Name: "url", Type: "String"}}
+				resp.Data = [][]*string{{strPtr("GET"), strPtr(`{"x-test":"1"}`), strPtr(server.URL + "/files/a.csv")}}
+			}
+			w.Header().Set(contentType, jsonMediaType)
+			require.NoError(t, json.NewEncoder(w).Encode(resp))
+		case "/files/a.csv":
+			mu.Lock()
+			fail := downloadFailures > 0
+			downloadFailures--
+			mu.Unlock()
+			if fail || r.Header.Get("x-test") != "1" {
+				http.Error(w, "expired", http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte("hello,world\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), queries...)
+	}
+}
+
+func TestListStage(t *testing.T) {
+	server, recorded := newStageServer(t, 0)
+	c := NewAPIClientFromConfig(testHTTPConfig(t, server.URL))
+
+	files, err := c.ListStage(context.Background(), &StageLocation{Name: "s1", Path: "data/"}, `.*\.csv`)
+	require.NoError(t, err)
+	assert.Equal(t, []StageFile{
+		{
+			Name:         "data/a.csv",
+			Size:         12,
+			MD5:          "5d41402abc4b2a76b9719d911017c592",
+			LastModified: time.Date(2024, 1, 2, 3, 4, 5, 123000000, time.UTC),
+		},
+		{Name: "data/b.csv"},
+	}, files)
+	assert.Equal(t, []string{`LIST @s1/data/ PATTERN = '.*\\.csv'`}, recorded())
+
+	_, err = c.ListStage(context.Background(), &StageLocation{Name: "s1 x"}, "")
+	assert.Error(t, err)
+	for _, path := range []string{"my data/", "it's/", "data/; DROP TABLE t"} {
+		_, err = c.ListStage(context.Background(), &StageLocation{Name: "s1", Path: path}, "")
+		assert.ErrorContains(t, err, "invalid stage path", path)
+	}
+	assert.Len(t, recorded(), 1)
+}
+
+func TestDownloadFromStage(t *testing.T) {
+	server, recorded := newStageServer(t, 1)
+	c := NewAPIClientFromConfig(testHTTPConfig(t, server.URL))
+
+	var buf bytes.Buffer
+	require.NoError(t, c.DownloadFromStage(context.Background(), &StageLocation{Name: "~", Path: "data/a.csv"}, &buf))
+	assert.Equal(t, "hello,world\n", buf.String())
+	// the first URL failed and a new one was presigned
+	assert.Equal(t, []string{"PRESIGN DOWNLOAD @~/data/a.csv", "PRESIGN DOWNLOAD @~/data/a.csv"}, recorded())
+
+	c.PresignedURLDisabled = true
+	assert.Error(t, c.DownloadFromStage(context.Background(), &StageLocation{Name: "~", Path: "data/a.csv"}, &buf))
+}
+
+func TestManageStage(t *testing.T) {
+	server, recorded := newStageServer(t, 0)
+	c := NewAPIClientFromConfig(testHTTPConfig(t, server.URL))
+	ctx := context.Background()
+
+	require.NoError(t, c.CreateStage(ctx, CreateStageBuilder{Name: "s1", IfNotExists: true, Format: ParquetFormat{}}))
+	require.NoError(t, c.RemoveFromStage(ctx, &StageLocation{Name: "s1", Path: "tmp/"}, ".*"))
+	require.NoError(t, c.RemoveFromStage(ctx, &StageLocation{Name: "s1", Path: "a.csv"}, ""))
+	require.NoError(t, c.DropStage(ctx, "s1", true))
+	require.NoError(t, c.DropStage(ctx, "s2", false))
+	assert.Error(t, c.DropStage(ctx, "s1; DROP TABLE t", false))
+	assert.Error(t, c.RemoveFromStage(ctx, &StageLocation{Name: "s1", Path: "a.csv; DROP TABLE t"}, ""))
+	assert.Error(t, c.RemoveFromStage(ctx, &StageLocation{Name: "s1", Path: "my 'file'.csv"}, ""))
+
+	assert.Equal(t, []string{
+		"CREATE STAGE IF NOT EXISTS s1 FILE_FORMAT = (TYPE = 'PARQUET')",
+		"REMOVE @s1/tmp/ PATTERN = '.*'",
+		"REMOVE @s1/a.csv",
+		"DROP STAGE IF EXISTS s1",
+		"DROP STAGE s2",
+	}, recorded())
+}
@@ -11,10 +11,10 @@ var (
 )
 
 type databendStmt struct {
-	dc           *DatabendConn
-	query        string
-	placeholders []int
-	closed       bool
+	dc     *DatabendConn
+	query  string
+	params []placeholder
+	closed bool
 }
 
 func (stmt *databendStmt) Close() error {
@@ -23,41 +23,41 @@ func (stmt *databendStmt) Close() error {
 }
 
 func (stmt *databendStmt) NumInput() int {
-	return len(stmt.placeholders)
+	return numInput(stmt.params)
 }
 
 func (stmt *databendStmt) Exec(args []driver.Value) (driver.Result, error) {
 	if stmt.closed {
 		return nil, errStmtClosed
 	}
-	return stmt.dc.exec(context.Background(), stmt.query, &stmt.placeholders, args)
+	return stmt.dc.exec(context.Background(), stmt.query, stmt.params, namedValues(args))
 }
 
 func (stmt *databendStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
 	if stmt.closed {
 		return nil, errStmtClosed
 	}
-	values := make([]driver.Value, len(args))
-	for i, arg := range args {
-		values[i] = arg.Value
-	}
-	return stmt.dc.exec(ctx, stmt.query, &stmt.placeholders, values)
+	return stmt.dc.exec(ctx, stmt.query, stmt.params, args)
 }
 
 func (stmt *databendStmt) Query(args []driver.Value) (driver.Rows, error) {
 	if stmt.closed {
 		return nil, errStmtClosed
 	}
-	return stmt.dc.query(context.Background(), stmt.query, &stmt.placeholders, args)
+	return stmt.dc.query(context.Background(), stmt.query, stmt.params, namedValues(args))
 }
 
 func (stmt *databendStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
 	if stmt.closed {
 		return nil, errStmtClosed
 	}
-	values := make([]driver.Value, len(args))
+	return stmt.dc.query(ctx, stmt.query, stmt.params, args)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	values := make([]driver.NamedValue, len(args))
 	for i, arg := range args {
-		values[i] = arg.Value
+		values[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
 	}
-	return stmt.dc.query(ctx, stmt.query, &stmt.placeholders, values)
+	return values
 }
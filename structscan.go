@@ -0,0 +1,325 @@
+package godatabend
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// StructMode controls what ScanStruct, QueryStructs and QueryOne do with
+// result columns that have no matching struct field.
+type StructMode uint8
+
+const (
+	// StructLenient discards columns without a matching field.
+	StructLenient StructMode = iota
+	// StructStrict reports columns without a matching field as an error.
+	StructStrict
+)
+
+type structModeKey struct{}
+
+// WithStructMode returns a context that makes QueryStructs and QueryOne use mode.
+func WithStructMode(ctx context.Context, mode StructMode) context.Context {
+	return context.WithValue(ctx, structModeKey{}, mode)
+}
+
+func structModeFrom(ctx context.Context) StructMode {
+	mode, _ := ctx.Value(structModeKey{}).(StructMode)
+	return mode
+}
+
+// Queryer is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// ScanStruct scans the current row into the struct pointed to by dst.
+//
+// Columns are matched to fields by their `db:"name"` tag, or by the field name
+// when there is no tag, falling back to a case-insensitive match. Fields of
+// embedded structs are matched as if they were fields of dst, and fields tagged
+// `db:"-"` are skipped. Columns without a matching field are discarded.
+func ScanStruct(rows *sql.Rows, dst any) error {
+	return ScanStructMode(rows, dst, StructLenient)
+}
+
+// ScanStructMode is ScanStruct with a choice of what to do with columns that
+// have no matching field.
+func ScanStructMode(rows *sql.Rows, dst any, mode StructMode) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ScanStruct destination must be a non-nil pointer to a struct, got %T", dst)
+	}
+	plan, err := cachedStructPlan(rows, v.Elem().Type(), mode)
+	if err != nil {
+		return err
+	}
+	return plan.scan(rows, v.Elem())
+}
+
+// structPlanKey identifies a plan by the struct type and the names and types
+// of the result columns, which are all a plan depends on.
+type structPlanKey struct {
+	typ     reflect.Type
+	mode    StructMode
+	columns string
+}
+
+var structPlanCache sync.Map // map[structPlanKey]*structPlan
+
+// cachedStructPlan returns the plan for scanning rows into t, so that a loop
+// calling ScanStructMode for every row builds it only once.
+func cachedStructPlan(rows *sql.Rows, t reflect.Type, mode StructMode) (*structPlan, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	var sb strings.Builder
+	for _, ct := range types {
+		sb.WriteString(ct.Name())
+		sb.WriteByte(0)
+		sb.WriteString(ct.DatabaseTypeName())
+		sb.WriteByte(0)
+	}
+	key := structPlanKey{typ: t, mode: mode, columns: sb.String()}
+	if plan, ok := structPlanCache.Load(key); ok {
+		return plan.(*structPlan), nil
+	}
+	plan, err := newStructPlan(types, t, mode)
+	if err != nil {
+		return nil, err
+	}
+	cached, _ := structPlanCache.LoadOrStore(key, plan)
+	return cached.(*structPlan), nil
+}
+
+// QueryStructs runs a query and scans every row into a T, which must be a
+// struct, with the same rules as ScanStruct. Use WithStructMode to report
+// columns without a matching field.
+func QueryStructs[T any](ctx context.Context, db Queryer, query string, args ...any) ([]T, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	plan, err := newStructPlan(types, reflect.TypeFor[T](), structModeFrom(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var out []T
+	for rows.Next() {
+		var item T
+		if err := plan.scan(rows, reflect.ValueOf(&item).Elem()); err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// QueryOne runs a query and scans its first row into a T like QueryStructs.
+// It returns sql.ErrNoRows if there are no rows.
+func QueryOne[T any](ctx context.Context, db Queryer, query string, args ...any) (T, error) {
+	var item T
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return item, err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return item, err
+	}
+	plan, err := newStructPlan(types, reflect.TypeFor[T](), structModeFrom(ctx))
+	if err != nil {
+		return item, err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return item, err
+		}
+		return item, sql.ErrNoRows
+	}
+	if err := plan.scan(rows, reflect.ValueOf(&item).Elem()); err != nil {
+		return item, err
+	}
+	return item, rows.Close()
+}
+
+// structField is a field that columns can be scanned into, with the index path
+// through embedded structs.
+type structField struct {
+	name  string
+	index []int
+}
+
+// structFields holds the fields of a struct type by name, and by lower case
+// name for the case-insensitive fallback.
+type structFields struct {
+	byName  map[string]*structField
+	byLower map[string]*structField
+}
+
+var structFieldsCache sync.Map // map[reflect.Type]*structFields
+
+func cachedStructFields(t reflect.Type) *structFields {
+	if f, ok := structFieldsCache.Load(t); ok {
+		return f.(*structFields)
+	}
+	fields := &structFields{byName: map[string]*structField{}, byLower: map[string]*structField{}}
+	depths := map[string]int{}
+	collectStructFields(t, nil, 0, fields, depths)
+	f, _ := structFieldsCache.LoadOrStore(t, fields)
+	return f.(*structFields)
+}
+
+// collectStructFields adds the fields of t, where fields of outer structs hide
+// those of embedded structs with the same name, like Go's selector rules.
+func collectStructFields(t reflect.Type, index []int, depth int, fields *structFields, depths map[string]int) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag, hasTag := f.Tag.Lookup("db")
+		if tag == "-" {
+			continue
+		}
+		path := append(append([]int(nil), index...), i)
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && !hasTag && ft.Kind() == reflect.Struct && !isScannerType(ft) {
+			if !f.IsExported() && f.Type.Kind() == reflect.Pointer {
+				// cannot be allocated through reflection
+				continue
+			}
+			collectStructFields(ft, path, depth+1, fields, depths)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if hasTag && tag != "" {
+			name = tag
+		}
+		if d, ok := depths[name]; ok && d <= depth {
+			continue
+		}
+		depths[name] = depth
+		field := &structField{name: name, index: path}
+		fields.byName[name] = field
+		lower := strings.ToLower(name)
+		if existing, ok := fields.byLower[lower]; !ok || existing.name == name || depths[existing.name] > depth {
+			fields.byLower[lower] = field
+		}
+	}
+}
+
+func isScannerType(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(reflect.TypeFor[sql.Scanner]())
+}
+
+// structPlan is the field and scan method of each column of a result.
+type structPlan struct {
+	columns []structColumn
+}
+
+type structColumn struct {
+	field *structField
+	// direct is set when database/sql can scan into the field itself;
+	// otherwise the value is scanned into an any and converted by assignNested
+	direct bool
+}
+
+func newStructPlan(types []*sql.ColumnType, t reflect.Type, mode StructMode) (*structPlan, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot scan rows into %s, expected a struct", t)
+	}
+	fields := cachedStructFields(t)
+	plan := &structPlan{columns: make([]structColumn, len(types))}
+	for i, ct := range types {
+		field, ok := fields.byName[ct.Name()]
+		if !ok {
+			field, ok = fields.byLower[strings.ToLower(ct.Name())]
+		}
+		if !ok {
+			if mode == StructStrict {
+				return nil, fmt.Errorf("column %q has no matching field in %s", ct.Name(), t)
+			}
+			continue
+		}
+		plan.columns[i] = structColumn{field: field, direct: scansDirectly(ct.ScanType(), t.FieldByIndex(field.index).Type)}
+	}
+	return plan, nil
+}
+
+// scansDirectly reports whether database/sql can convert values of the column's
+// ScanType into a field of type ft. Nested values, such as an Array(Int32)
+// scanned into []int64, need assignNested instead.
+func scansDirectly(scanType, ft reflect.Type) bool {
+	if scanType == nil || scanType.AssignableTo(ft) || isScannerType(ft) || ft.Kind() == reflect.Interface {
+		return true
+	}
+	if ft.Kind() == reflect.Pointer {
+		ft = ft.Elem()
+	}
+	if scanType.AssignableTo(ft) || isScannerType(ft) {
+		return true
+	}
+	switch ft.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.Struct:
+		return ft == reflectTypeBytes || ft == reflectTypeTime
+	}
+	return true
+}
+
+func (p *structPlan) scan(rows *sql.Rows, dst reflect.Value) error {
+	dests := make([]any, len(p.columns))
+	for i, col := range p.columns {
+		if col.field == nil || !col.direct {
+			dests[i] = new(any)
+			continue
+		}
+		dests[i] = fieldByIndexAlloc(dst, col.field.index).Addr().Interface()
+	}
+	if err := rows.Scan(dests...); err != nil {
+		return err
+	}
+	for i, col := range p.columns {
+		if col.field == nil || col.direct {
+			continue
+		}
+		if err := assignNested(fieldByIndexAlloc(dst, col.field.index), *dests[i].(*any)); err != nil {
+			return fmt.Errorf("cannot scan column %d into field %s: %w", i, col.field.name, err)
+		}
+	}
+	return nil
+}
+
+// fieldByIndexAlloc returns the field at index, allocating nil embedded struct
+// pointers on the way.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
@@ -0,0 +1,125 @@
+package godatabend
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type structScanBase struct {
+	ID      int64 `db:"id"`
+	Created time.Time
+}
+
+type structScanUser struct {
+	structScanBase
+	Name    string   `db:"name"`
+	Email   *string  `db:"email"`
+	Tags    []string `db:"tags"`
+	Scores  Array[float64]
+	Ignored string `db:"-"`
+}
+
+func structScanDB() *sql.DB {
+	return sql.OpenDB(&fakeConnector{
+		resp: &QueryResponse{
+			Schema: &[]DataField{
+				{Name: "id", Type: "Int32"},
+				{Name: "CREATED", Type: "Timestamp"},
+				{Name: "name", Type: "String"},
+				{Name: "email", Type: "Nullable(String)"},
+				{Name: "tags", Type: "Array(String)"},
+				{Name: "scores", Type: "Array(Int16)"},
+				{Name: "extra", Type: "String"},
+			},
+			Data: [][]*string{
+				{strPtr("1"), strPtr("2024-01-02 03:04:05.000000"), strPtr("ann"), strPtr("a@x"), strPtr("['a','b']"), strPtr("[1,2]"), strPtr("x")},
+				{strPtr("2"), strPtr("2024-01-03 00:00:00.000000"), strPtr("bob"), nil, strPtr("[]"), strPtr("[]"), strPtr("y")},
+			},
+		},
+	})
+}
+
+func TestScanStruct(t *testing.T) {
+	rows, err := structScanDB().Query("x")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	require.True(t, rows.Next())
+	u := structScanUser{Ignored: "keep"}
+	require.NoError(t, ScanStruct(rows, &u))
+	assert.Equal(t, int64(1), u.ID)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), u.Created)
+	assert.Equal(t, "ann", u.Name)
+	assert.Equal(t, strPtr("a@x"), u.Email)
+	assert.Equal(t, []string{"a", "b"}, u.Tags)
+	assert.Equal(t, Array[float64]{1, 2}, u.Scores)
+	assert.Equal(t, "keep", u.Ignored)
+
+	require.True(t, rows.Next())
+	assert.ErrorContains(t, ScanStructMode(rows, &u, StructStrict), `column "extra" has no matching field`)
+	assert.Error(t, ScanStruct(rows, u))
+}
+
+func TestScanStructReusesPlan(t *testing.T) {
+	rows, err := structScanDB().Query("x")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var u structScanUser
+	require.True(t, rows.Next())
+	require.NoError(t, ScanStruct(rows, &u))
+	plan, err := cachedStructPlan(rows, reflect.TypeFor[structScanUser](), StructLenient)
+	require.NoError(t, err)
+	require.True(t, rows.Next())
+	require.NoError(t, ScanStruct(rows, &u))
+	assert.Equal(t, "bob", u.Name)
+
+	// the plan is shared by every result with the same columns
+	other, err := structScanDB().Query("x")
+	require.NoError(t, err)
+	defer other.Close()
+	same, err := cachedStructPlan(other, reflect.TypeFor[structScanUser](), StructLenient)
+	require.NoError(t, err)
+	assert.Same(t, plan, same)
+}
+
+func TestQueryStructs(t *testing.T) {
+	ctx := context.Background()
+	users, err := QueryStructs[structScanUser](ctx, structScanDB(), "x")
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "bob", users[1].Name)
+	assert.Nil(t, users[1].Email)
+	assert.Equal(t, []string{}, users[1].Tags)
+
+	_, err = QueryStructs[structScanUser](WithStructMode(ctx, StructStrict), structScanDB(), "x")
+	assert.Error(t, err)
+
+	type Audit struct {
+		ID      int64 `db:"id"`
+		Created time.Time
+	}
+	type embedded struct {
+		*Audit
+		ID string `db:"id"`
+	}
+	one, err := QueryOne[embedded](ctx, structScanDB(), "x")
+	require.NoError(t, err)
+	assert.Equal(t, "1", one.ID)
+	require.NotNil(t, one.Audit)
+	assert.Equal(t, int64(0), one.Audit.ID)
+	assert.Equal(t, 2024, one.Created.Year())
+
+	empty := sql.OpenDB(&fakeConnector{resp: &QueryResponse{Schema: &[]DataField{{Name: "id", Type: "Int32"}}}})
+	_, err = QueryOne[structScanBase](ctx, empty, "x")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = QueryStructs[int](ctx, structScanDB(), "x")
+	assert.Error(t, err)
+}
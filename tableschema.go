@@ -0,0 +1,194 @@
+package godatabend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// tableColumn is a column of a table as reported by DESC.
+type tableColumn struct {
+	name string
+	desc *TypeDesc
+}
+
+// describeInsertTable returns the columns of the table an INSERT or REPLACE
+// statement writes to.
+func (dc *DatabendConn) describeInsertTable(ctx context.Context, query string) ([]tableColumn, error) {
+	desc, err := generateDescTable(query)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := dc.rest.querySyncWithTransport(ctx, desc, queryTransportJSON)
+	if err != nil {
+		return nil, errors.Wrap(err, "describe table failed")
+	}
+	return parseDescTableRows(resp.Data)
+}
+
+// parseDescTableRows reads the Field, Type and Null columns of the result of DESC.
+func parseDescTableRows(data [][]*string) ([]tableColumn, error) {
+	columns := make([]tableColumn, 0, len(data))
+	for _, row := range data {
+		if len(row) < 3 || row[0] == nil || row[1] == nil {
+			return nil, errors.New("unexpected result of DESC")
+		}
+		desc, err := parseSQLTypeDesc(*row[1])
+		if err != nil {
+			return nil, &columnTypeError{column: *row[0], err: err}
+		}
+		if row[2] != nil && strings.EqualFold(*row[2], "YES") {
+			desc.Nullable = true
+		}
+		columns = append(columns, tableColumn{name: *row[0], desc: desc})
+	}
+	return columns, nil
+}
+
+// columnTypeError is returned for a column whose type DESC spells in a way
+// parseSQLTypeDesc does not understand.
+type columnTypeError struct {
+	column string
+	err    error
+}
+
+func (e *columnTypeError) Error() string {
+	return fmt.Sprintf("column %s: %v", e.column, e.err)
+}
+
+func (e *columnTypeError) Unwrap() error {
+	return e.err
+}
+
+var unsignedSQLTypes = strings.NewReplacer(
+	"TINYINT UNSIGNED", "UInt8",
+	"SMALLINT UNSIGNED", "UInt16",
+	"BIGINT UNSIGNED", "UInt64",
+	"INT UNSIGNED", "UInt32",
+)
+
+// sqlTypeNames maps the SQL names DESC uses, such as VARCHAR or BIGINT, and the
+// upper case spelling of the type names of query results, to the latter.
+var sqlTypeNames = func() map[string]string {
+	names := map[string]string{
+		"TINYINT":   "Int8",
+		"SMALLINT":  "Int16",
+		"INT":       "Int32",
+		"INTEGER":   "Int32",
+		"BIGINT":    "Int64",
+		"FLOAT":     "Float32",
+		"REAL":      "Float32",
+		"DOUBLE":    "Float64",
+		"VARCHAR":   "String",
+		"TEXT":      "String",
+		"BOOL":      "Boolean",
+		"DATETIME":  "Timestamp",
+		"VARBINARY": "Binary",
+		"BLOB":      "Binary",
+		"JSON":      "Variant",
+	}
+	for _, name := range []string{
+		"Int8", "Int16", "Int32", "Int64", "UInt8", "UInt16", "UInt32", "UInt64",
+		"Int128", "Int256", "UInt128", "UInt256", "Float32", "Float64", "Decimal",
+		"String", "Boolean", "Binary", "Date", "Timestamp", "Timestamp_Tz", "Interval",
+		"Array", "Map", "Tuple", "Nullable", "Variant", "Bitmap", "Geometry",
+		"Geography", "Vector", "UUID", "IPv4", "IPv6", "Enum", "Enum8", "Enum16",
+	} {
+		names[strings.ToUpper(name)] = name
+	}
+	return names
+}()
+
+// parseSQLTypeDesc parses a type as DESC prints it, e.g. `ARRAY(INT32 NULL)`,
+// into a TypeDesc spelled like the types of query results.
+func parseSQLTypeDesc(s string) (*TypeDesc, error) {
+	desc, err := ParseTypeDesc(unsignedSQLTypes.Replace(strings.TrimSpace(s)))
+	if err != nil {
+		return nil, err
+	}
+	desc = desc.Normalize()
+	renameSQLType(desc)
+	return desc, nil
+}
+
+func renameSQLType(desc *TypeDesc) {
+	if name, ok := sqlTypeNames[strings.ToUpper(desc.Name)]; ok {
+		desc.Name = name
+	}
+	if isNestedTypeName(desc.Name) {
+		for i, arg := range desc.Args {
+			desc.Args[i] = arg.Normalize()
+			renameSQLType(desc.Args[i])
+		}
+	}
+}
+
+// insertColumns returns the table columns written by an INSERT or REPLACE
+// statement, in the order of its column list if it has one.
+func insertColumns(query string, columns []tableColumn) ([]tableColumn, error) {
+	names := parseInsertColumnNames(query)
+	if len(names) == 0 {
+		return columns, nil
+	}
+	selected := make([]tableColumn, 0, len(names))
+	for _, name := range names {
+		col, ok := findTableColumn(columns, name)
+		if !ok {
+			return nil, fmt.Errorf("column %s not found in table", name)
+		}
+		selected = append(selected, col)
+	}
+	return selected, nil
+}
+
+func findTableColumn(columns []tableColumn, name string) (tableColumn, bool) {
+	for _, col := range columns {
+		if col.name == name {
+			return col, true
+		}
+	}
+	// unquoted identifiers are case-insensitive
+	for _, col := range columns {
+		if strings.EqualFold(col.name, name) {
+			return col, true
+		}
+	}
+	return tableColumn{}, false
+}
+
+// parseInsertColumnNames returns the unquoted names of the column list of an
+// INSERT or REPLACE statement, or nil if it has none.
+func parseInsertColumnNames(query string) []string {
+	stmt := parseSQLStatement(query)
+	_, i, ok := stmt.insertTarget()
+	if !ok || !stmt.op(i, "(") {
+		return nil
+	}
+	var names []string
+	for i++; i < len(stmt.tokens) && !stmt.op(i, ")"); i++ {
+		if stmt.op(i, ",") {
+			continue
+		}
+		names = append(names, identName(stmt.tokens[i].text(stmt.query)))
+	}
+	return names
+}
+
+// identName returns the name an identifier written as s refers to: quoted
+// identifiers are unquoted, and unquoted ones lowered.
+func identName(s string) string {
+	if len(s) > 0 && (s[0] == '`' || s[0] == '"') {
+		return unquoteIdent(s)
+	}
+	return strings.ToLower(s)
+}
+
+func unquoteIdent(s string) string {
+	if len(s) >= 2 && (s[0] == '`' || s[0] == '"') && s[len(s)-1] == s[0] {
+		q := s[:1]
+		return strings.ReplaceAll(s[1:len(s)-1], q+q, q)
+	}
+	return s
+}
@@ -0,0 +1,75 @@
+package godatabend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSQLTypeDesc(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"INT", "Int32"},
+		{"BIGINT UNSIGNED", "UInt64"},
+		{"TINYINT UNSIGNED", "UInt8"},
+		{"VARCHAR", "String"},
+		{"DOUBLE", "Float64"},
+		{"TIMESTAMP", "Timestamp"},
+		{"DECIMAL(10, 2)", "Decimal(10, 2)"},
+		{"ARRAY(INT32 NULL)", "Array(Int32 NULL)"},
+		{"MAP(STRING, ARRAY(BIGINT))", "Map(String, Array(Int64))"},
+		{"TUPLE(INT, VARCHAR)", "Tuple(Int32, String)"},
+		{"TUPLE(a INT32, b VARCHAR)", "Tuple(a Int32, b String)"},
+		{"TUPLE(a Nullable(INT), `b c` ARRAY(STRING) NULL)", "Tuple(a Int32 NULL, `b c` Array(String) NULL)"},
+		{"Nullable(Int64)", "Int64 NULL"},
+		{"Vector(3)", "Vector(3)"},
+	}
+	for _, tc := range testCases {
+		desc, err := parseSQLTypeDesc(tc.input)
+		require.NoError(t, err, tc.input)
+		assert.Equal(t, tc.expected, desc.String(), tc.input)
+	}
+}
+
+func TestParseDescTableRows(t *testing.T) {
+	columns, err := parseDescTableRows([][]*string{
+		{strPtr("id"), strPtr("BIGINT"), strPtr("NO"), strPtr("0"), strPtr("")},
+		{strPtr("name"), strPtr("VARCHAR"), strPtr("YES"), strPtr("NULL"), strPtr("")},
+	})
+	require.NoError(t, err)
+	require.Len(t, columns, 2)
+	assert.Equal(t, "id", columns[0].name)
+	assert.Equal(t, "Int64", columns[0].desc.String())
+	assert.Equal(t, "name", columns[1].name)
+	assert.Equal(t, "String NULL", columns[1].desc.String())
+
+	_, err = parseDescTableRows([][]*string{{strPtr("id")}})
+	assert.Error(t, err)
+
+	_, err = parseDescTableRows([][]*string{{strPtr("g"), strPtr("UNKNOWN(a b c)"), strPtr("NO")}})
+	var typeErr *columnTypeError
+	require.ErrorAs(t, err, &typeErr)
+	assert.Equal(t, "g", typeErr.column)
+}
+
+func TestInsertColumns(t *testing.T) {
+	columns := []tableColumn{
+		{name: "id", desc: &TypeDesc{Name: "Int64"}},
+		{name: "Name", desc: &TypeDesc{Name: "String"}},
+		{name: "v", desc: &TypeDesc{Name: "Float64"}},
+	}
+
+	selected, err := insertColumns("INSERT INTO t VALUES", columns)
+	require.NoError(t, err)
+	assert.Equal(t, columns, selected)
+
+	selected, err = insertColumns("INSERT INTO t (v, `Name`, ID) VALUES", columns)
+	require.NoError(t, err)
+	assert.Equal(t, []tableColumn{columns[2], columns[1], columns[0]}, selected)
+
+	_, err = insertColumns(`INSERT INTO t ("missing") VALUES`, columns)
+	assert.Error(t, err)
+}
@@ -5,6 +5,10 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"fmt"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
 	databend "github.com/datafuselabs/databend-go"
 	"golang.org/x/mod/semver"
 	"time"
@@ -67,7 +71,7 @@ func (s *DatabendTestSuite) TestBatchInsert() {
 			"1",
 			"1.2",
 			"s1",
-			"[1,2,3]",
+			[]uint8{1, 2, 3},
 			today,
 			todayLA,
 		},
@@ -75,7 +79,7 @@ func (s *DatabendTestSuite) TestBatchInsert() {
 			"2",
 			"2.2",
 			"s1",
-			"[1,2,3]",
+			[]uint8{1, 2, 3},
 			today,
 			today.In(locLA),
 		},
@@ -83,7 +87,7 @@ func (s *DatabendTestSuite) TestBatchInsert() {
 			"2",
 			"2.2",
 			"s1",
-			"[1,2,3]",
+			[]uint8{1, 2, 3},
 			today,
 			today.In(locLA),
 		},
@@ -91,7 +95,7 @@ func (s *DatabendTestSuite) TestBatchInsert() {
 			"2",
 			"2.2",
 			"s1",
-			"[1,2,3]",
+			[]uint8{1, 2, 3},
 			yesterday,
 			today.In(locLA),
 		},
@@ -102,3 +106,61 @@ func (s *DatabendTestSuite) TestBatchInsert() {
 	}
 	_ = rows.Close()
 }
+
+func (s *DatabendTestSuite) TestInsertArrow() {
+	if semver.Compare(serverVersion, "1.2.836") < 0 {
+		return
+	}
+
+	db := sql.OpenDB(s.cfg)
+	defer db.Close()
+
+	tableName := "test_insert_arrow"
+	_, err := db.Exec(fmt.Sprintf("CREATE OR REPLACE TABLE %s (id Int64, name String NULL, note String DEFAULT 'x')", tableName))
+	s.r.NoError(err)
+
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+	}, nil)
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+	b.Field(0).(*array.Int64Builder).AppendValues([]int64{1, 2}, nil)
+	b.Field(1).(*array.StringBuilder).AppendValues([]string{"a", ""}, []bool{true, false})
+	record := b.NewRecord()
+	defer record.Release()
+	reader, err := array.NewRecordReader(schema, []arrow.Record{record})
+	s.r.NoError(err)
+	defer reader.Release()
+
+	conn, err := db.Conn(context.Background())
+	s.r.NoError(err)
+	defer conn.Close()
+	r, err := databend.InsertArrow(context.Background(), conn, tableName, reader)
+	s.r.NoError(err)
+	n, err := r.RowsAffected()
+	s.r.NoError(err)
+	s.r.Equal(int64(2), n)
+
+	rows, err := db.Query(fmt.Sprintf("SELECT id, name, note FROM %s ORDER BY id", tableName))
+	s.r.NoError(err)
+	defer rows.Close()
+	var (
+		ids   []int64
+		names []sql.NullString
+	)
+	for rows.Next() {
+		var (
+			id   int64
+			name sql.NullString
+			note string
+		)
+		s.r.NoError(rows.Scan(&id, &name, &note))
+		s.r.Equal("x", note)
+		ids = append(ids, id)
+		names = append(names, name)
+	}
+	s.r.NoError(rows.Err())
+	s.r.Equal([]int64{1, 2}, ids)
+	s.r.Equal([]sql.NullString{{String: "a", Valid: true}, {}}, names)
+}
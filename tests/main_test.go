@@ -303,7 +303,7 @@ func (s *DatabendTestSuite) TestExec() {
 		{
 			fmt.Sprintf("INSERT INTO %s (i64, a16, a8) VALUES (?, ?, ?)", s.table),
 			"",
-			[]interface{}{int64(3), dc.Array([]int16{1, 2}), dc.Array([]uint8{10, 20})},
+			[]interface{}{int64(3), dc.Array[int16]{1, 2}, dc.Array[uint8]{10, 20}},
 		},
 		{
 			fmt.Sprintf("INSERT INTO %s (d, t) VALUES (?, ?)", s.table),
@@ -242,7 +242,7 @@ func (s *DatabendTestSuite) TestDecimal() {
 	s.r.NoError(err)
 	s.r.Len(columnTypes, 1)
 	s.r.Equal("Decimal(18, 4) NULL", columnTypes[0].DatabaseTypeName())
-	s.r.Equal(reflect.TypeOf(""), columnTypes[0].ScanType())
+	s.r.Equal(reflect.TypeOf(databend.Decimal{}), columnTypes[0].ScanType())
 	nullable, ok := columnTypes[0].Nullable()
 	s.r.True(ok)
 	s.r.True(nullable)
@@ -260,6 +260,12 @@ func (s *DatabendTestSuite) TestDecimal() {
 	s.r.Equal("12345.6789", output)
 
 	s.r.NoError(rows.Close())
+
+	var exact, roundTrip databend.Decimal
+	s.r.NoError(db.QueryRow(fmt.Sprintf("select d from %s", tableName)).Scan(&exact))
+	s.r.Equal("12345.6789", exact.String())
+	s.r.NoError(db.QueryRow(fmt.Sprintf("select d from %s where d = ?", tableName), exact).Scan(&roundTrip))
+	s.r.Equal(0, exact.Cmp(roundTrip))
 }
 
 func (s *DatabendTestSuite) TestScalarMappings() {
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strings"
+	"unicode/utf8"
 )
 
 const (
@@ -55,3 +57,265 @@ func readRaw(s io.RuneScanner) *bytes.Buffer {
 
 	return &data
 }
+
+type sqlTokenKind uint8
+
+const (
+	sqlTokenWhitespace sqlTokenKind = iota
+	sqlTokenComment
+	// sqlTokenWord is a keyword or an unquoted identifier.
+	sqlTokenWord
+	// sqlTokenQuotedIdent is a "double-quoted" or `backtick-quoted` identifier.
+	sqlTokenQuotedIdent
+	// sqlTokenString is a 'single-quoted' string literal.
+	sqlTokenString
+	// sqlTokenDollarString is a $$-delimited body, as used by UDFs and scripts.
+	sqlTokenDollarString
+	sqlTokenNumber
+	sqlTokenPlaceholder
+	sqlTokenOperator
+)
+
+type sqlToken struct {
+	kind  sqlTokenKind
+	start int
+	end   int
+}
+
+func (t sqlToken) text(query string) string {
+	return query[t.start:t.end]
+}
+
+// significant reports whether the token carries meaning, i.e. is neither
+// whitespace nor a comment.
+func (t sqlToken) significant() bool {
+	return t.kind != sqlTokenWhitespace && t.kind != sqlTokenComment
+}
+
+// tokenizeSQL splits a Databend SQL statement into tokens. It only knows as much
+// of the dialect as is needed to find parameters and classify statements:
+// literals, quoted identifiers, comments and $$ bodies are kept opaque, and
+// unterminated ones extend to the end of the query.
+func tokenizeSQL(query string) []sqlToken {
+	var tokens []sqlToken
+	for i := 0; i < len(query); {
+		start := i
+		kind := sqlTokenOperator
+		c := query[i]
+		switch {
+		case isSpace(c):
+			kind = sqlTokenWhitespace
+			for i < len(query) && isSpace(query[i]) {
+				i++
+			}
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			kind = sqlTokenComment
+			if n := strings.IndexByte(query[i:], '\n'); n >= 0 {
+				i += n + 1
+			} else {
+				i = len(query)
+			}
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			kind = sqlTokenComment
+			if n := strings.Index(query[i+2:], "*/"); n >= 0 {
+				i += n + 4
+			} else {
+				i = len(query)
+			}
+		case c == '\'':
+			kind = sqlTokenString
+			i = scanQuoted(query, i, '\'', true)
+		case c == '"' || c == '`':
+			kind = sqlTokenQuotedIdent
+			i = scanQuoted(query, i, c, false)
+		case c == '$' && strings.HasPrefix(query[i:], "$$"):
+			kind = sqlTokenDollarString
+			if n := strings.Index(query[i+2:], "$$"); n >= 0 {
+				i += n + 4
+			} else {
+				i = len(query)
+			}
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			kind = sqlTokenPlaceholder
+			i++
+			for i < len(query) && isDigit(query[i]) {
+				i++
+			}
+		case c == '?':
+			i++
+			// `?|` and `?&` are JSON key-existence operators
+			if i < len(query) && (query[i] == '|' || query[i] == '&') {
+				i++
+			} else {
+				kind = sqlTokenPlaceholder
+			}
+		case c == ':' && strings.HasPrefix(query[i:], "::"):
+			i += 2
+		case (c == ':' || c == '@') && i+1 < len(query) && isIdentStart(query[i+1]):
+			kind = sqlTokenPlaceholder
+			i++
+			for i < len(query) && isIdentPart(query[i]) {
+				i++
+			}
+		case isIdentStart(c) || c >= utf8.RuneSelf:
+			kind = sqlTokenWord
+			for i < len(query) && (isIdentPart(query[i]) || query[i] == '$' || query[i] >= utf8.RuneSelf) {
+				i++
+			}
+		case isDigit(c):
+			kind = sqlTokenNumber
+			for i < len(query) && (isIdentPart(query[i]) || query[i] == '.') {
+				i++
+			}
+		default:
+			i++
+		}
+		tokens = append(tokens, sqlToken{kind: kind, start: start, end: i})
+	}
+	return tokens
+}
+
+// scanQuoted returns the offset just past the closing quote of the quoted
+// token starting at start. A doubled quote is an escaped quote; backslash
+// escapes are only recognized in string literals.
+func scanQuoted(query string, start int, quote byte, backslash bool) int {
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if backslash {
+				i++
+			}
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+// sqlStatement is a tokenized statement with whitespace and comments removed,
+// with small helpers for matching its grammar.
+type sqlStatement struct {
+	query  string
+	tokens []sqlToken
+}
+
+func parseSQLStatement(query string) *sqlStatement {
+	stmt := &sqlStatement{query: query}
+	for _, tok := range tokenizeSQL(query) {
+		if tok.significant() {
+			stmt.tokens = append(stmt.tokens, tok)
+		}
+	}
+	return stmt
+}
+
+// word reports whether the i-th token is one of the keywords, case-insensitively.
+func (s *sqlStatement) word(i int, keywords ...string) bool {
+	if i >= len(s.tokens) || s.tokens[i].kind != sqlTokenWord {
+		return false
+	}
+	for _, kw := range keywords {
+		if strings.EqualFold(s.tokens[i].text(s.query), kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// op reports whether the i-th token is the operator op.
+func (s *sqlStatement) op(i int, op string) bool {
+	return i < len(s.tokens) && s.tokens[i].kind == sqlTokenOperator && s.tokens[i].text(s.query) == op
+}
+
+// skipParens returns the index after the balanced parenthesized group at i.
+func (s *sqlStatement) skipParens(i int) int {
+	depth := 0
+	for ; i < len(s.tokens); i++ {
+		switch {
+		case s.op(i, "("):
+			depth++
+		case s.op(i, ")"):
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return i
+}
+
+// qualifiedName reads a dotted, possibly quoted name starting at i and returns
+// it as written, together with the index of the following token.
+func (s *sqlStatement) qualifiedName(i int) (string, int, bool) {
+	var name strings.Builder
+	for {
+		if i >= len(s.tokens) || (s.tokens[i].kind != sqlTokenWord && s.tokens[i].kind != sqlTokenQuotedIdent) {
+			return "", i, false
+		}
+		name.WriteString(s.tokens[i].text(s.query))
+		i++
+		if !s.op(i, ".") {
+			return name.String(), i, true
+		}
+		name.WriteByte('.')
+		i++
+	}
+}
+
+// splitQualifiedName returns the parts of a dotted name written as in SQL, such
+// as `db`.t, unquoted. Unquoted parts are lowered, as Databend matches them
+// case-insensitively.
+func splitQualifiedName(name string) ([]string, bool) {
+	s := parseSQLStatement(name)
+	var parts []string
+	for i := 0; ; i++ {
+		if i >= len(s.tokens) || (s.tokens[i].kind != sqlTokenWord && s.tokens[i].kind != sqlTokenQuotedIdent) {
+			return nil, false
+		}
+		parts = append(parts, identName(s.tokens[i].text(s.query)))
+		i++
+		if i == len(s.tokens) {
+			return parts, true
+		}
+		if !s.op(i, ".") {
+			return nil, false
+		}
+	}
+}
+
+// insertTarget matches `INSERT [OVERWRITE] INTO <table>` or `REPLACE INTO <table>`
+// and returns the table as written and the index of the following token.
+func (s *sqlStatement) insertTarget() (string, int, bool) {
+	i := 0
+	if !s.word(i, "INSERT", "REPLACE") {
+		return "", i, false
+	}
+	if s.word(i, "INSERT") && s.word(i+1, "OVERWRITE") {
+		i++
+	}
+	i++
+	if !s.word(i, "INTO") {
+		return "", i, false
+	}
+	return s.qualifiedName(i + 1)
+}
@@ -0,0 +1,123 @@
+package godatabend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenizeSQL(t *testing.T) {
+	type tok struct {
+		kind sqlTokenKind
+		text string
+	}
+	testCases := []struct {
+		name  string
+		query string
+		want  []tok
+	}{
+		{
+			name:  "words and operators",
+			query: "SELECT a,b",
+			want:  []tok{{sqlTokenWord, "SELECT"}, {sqlTokenWhitespace, " "}, {sqlTokenWord, "a"}, {sqlTokenOperator, ","}, {sqlTokenWord, "b"}},
+		},
+		{
+			name:  "string with escapes",
+			query: `'a\'b''c'`,
+			want:  []tok{{sqlTokenString, `'a\'b''c'`}},
+		},
+		{
+			name:  "double quoted identifier",
+			query: `"a?""b"`,
+			want:  []tok{{sqlTokenQuotedIdent, `"a?""b"`}},
+		},
+		{
+			name:  "backtick identifier",
+			query: "`a?b`",
+			want:  []tok{{sqlTokenQuotedIdent, "`a?b`"}},
+		},
+		{
+			name:  "backslash is not an escape in identifiers",
+			query: `"a\" ?`,
+			want:  []tok{{sqlTokenQuotedIdent, `"a\"`}, {sqlTokenWhitespace, " "}, {sqlTokenPlaceholder, "?"}},
+		},
+		{
+			name:  "line comment",
+			query: "-- why?\n?",
+			want:  []tok{{sqlTokenComment, "-- why?\n"}, {sqlTokenPlaceholder, "?"}},
+		},
+		{
+			name:  "block comment",
+			query: "/* ? */?",
+			want:  []tok{{sqlTokenComment, "/* ? */"}, {sqlTokenPlaceholder, "?"}},
+		},
+		{
+			name:  "unterminated block comment",
+			query: "/* ?",
+			want:  []tok{{sqlTokenComment, "/* ?"}},
+		},
+		{
+			name:  "dollar quoted body",
+			query: "$$ select ? $$ $1",
+			want:  []tok{{sqlTokenDollarString, "$$ select ? $$"}, {sqlTokenWhitespace, " "}, {sqlTokenPlaceholder, "$1"}},
+		},
+		{
+			name:  "json operators",
+			query: "v?|a ?&b ?",
+			want: []tok{
+				{sqlTokenWord, "v"}, {sqlTokenOperator, "?|"}, {sqlTokenWord, "a"}, {sqlTokenWhitespace, " "},
+				{sqlTokenOperator, "?&"}, {sqlTokenWord, "b"}, {sqlTokenWhitespace, " "}, {sqlTokenPlaceholder, "?"},
+			},
+		},
+		{
+			name:  "cast and named parameter",
+			query: "a::Int32=:x",
+			want:  []tok{{sqlTokenWord, "a"}, {sqlTokenOperator, "::"}, {sqlTokenWord, "Int32"}, {sqlTokenOperator, "="}, {sqlTokenPlaceholder, ":x"}},
+		},
+		{
+			name:  "stage reference",
+			query: "@s1/p",
+			want:  []tok{{sqlTokenPlaceholder, "@s1"}, {sqlTokenOperator, "/"}, {sqlTokenWord, "p"}},
+		},
+		{
+			name:  "numbers",
+			query: "1.5e3",
+			want:  []tok{{sqlTokenNumber, "1.5e3"}},
+		},
+		{
+			name:  "unicode identifier",
+			query: "数据 ?",
+			want:  []tok{{sqlTokenWord, "数据"}, {sqlTokenWhitespace, " "}, {sqlTokenPlaceholder, "?"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []tok
+			for _, token := range tokenizeSQL(tc.query) {
+				got = append(got, tok{token.kind, token.text(tc.query)})
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestPlaceholdersSkipOpaqueTokens(t *testing.T) {
+	testCases := []struct {
+		query string
+		want  int
+	}{
+		{`SELECT "a?" FROM t WHERE x = ?`, 1},
+		{"SELECT `a?` FROM t WHERE x = ?", 1},
+		{"SELECT 1 -- ?\nWHERE x = ?", 1},
+		{"SELECT /* ? */ ?", 1},
+		{"CREATE FUNCTION f() RETURNS INT LANGUAGE python AS $$ ? $$", 0},
+		{"SELECT v ?| ['a'], v ?& ['b'] FROM t WHERE x = ?", 1},
+		{`SELECT 'it''s ?', ?`, 1},
+		{`SELECT 'a\'?', ?, ?`, 2},
+	}
+
+	for _, tc := range testCases {
+		assert.Len(t, placeholders(tc.query), tc.want, tc.query)
+	}
+}
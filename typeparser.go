@@ -1,12 +1,19 @@
 package godatabend
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // TypeDesc describes a (possibly nested) data type returned by Databend.
 type TypeDesc struct {
 	Name     string
 	Nullable bool
 	Args     []*TypeDesc
+
+	// field is the field name of a named Tuple element, e.g. `a` in
+	// `Tuple(a Int32, b String)`.
+	field string
 }
 
 func ParseTypeDesc(s string) (*TypeDesc, error) {
@@ -16,10 +23,26 @@ func ParseTypeDesc(s string) (*TypeDesc, error) {
 		depth    = 0
 		start    = 0
 		nullable = false
+		inQuote  = false
+		escaped  = false
 	)
 
 	for i, c := range s {
+		if inQuote {
+			switch c {
+			case '\\':
+				escaped = !escaped
+			case '\'':
+				inQuote = escaped
+				escaped = false
+			default:
+				escaped = false
+			}
+			continue
+		}
 		switch c {
+		case '\'':
+			inQuote = true
 		case '(':
 			if depth == 0 {
 				name = s[start:i]
@@ -31,7 +54,7 @@ func ParseTypeDesc(s string) (*TypeDesc, error) {
 			if depth == 0 {
 				s := s[start:i]
 				if s != "" {
-					desc, err := ParseTypeDesc(s)
+					desc, err := parseTypeArg(s)
 					if err != nil {
 						return nil, err
 					}
@@ -43,7 +66,7 @@ func ParseTypeDesc(s string) (*TypeDesc, error) {
 			if depth == 1 {
 				s := s[start:i]
 				if s != "" {
-					desc, err := ParseTypeDesc(s)
+					desc, err := parseTypeArg(s)
 					if err != nil {
 						return nil, err
 					}
@@ -61,7 +84,7 @@ func ParseTypeDesc(s string) (*TypeDesc, error) {
 			}
 		}
 	}
-	if depth != 0 {
+	if depth != 0 || inQuote {
 		return nil, fmt.Errorf("invalid type desc: %s", s)
 	}
 	if start < len(s) {
@@ -79,11 +102,94 @@ func ParseTypeDesc(s string) (*TypeDesc, error) {
 	return &TypeDesc{Name: name, Nullable: nullable, Args: args}, nil
 }
 
+func parseTypeArg(s string) (*TypeDesc, error) {
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "'") {
+		return parseEnumValue(trimmed)
+	}
+	if field, rest, ok := cutTupleField(trimmed); ok {
+		desc, err := ParseTypeDesc(rest)
+		if err != nil {
+			return nil, err
+		}
+		desc.field = field
+		return desc, nil
+	}
+	return ParseTypeDesc(s)
+}
+
+// cutTupleField splits a named Tuple element such as `a Int32` or
+// "`a b` String NULL" into its field name and type.
+func cutTupleField(s string) (field, rest string, ok bool) {
+	var name string
+	if len(s) > 0 && (s[0] == '`' || s[0] == '"') {
+		end := 1
+		for end < len(s) {
+			if s[end] == s[0] {
+				if end+1 < len(s) && s[end+1] == s[0] {
+					end += 2
+					continue
+				}
+				break
+			}
+			end++
+		}
+		if end == len(s) {
+			return "", "", false
+		}
+		name = unquoteIdent(s[:end+1])
+		rest = s[end+1:]
+	} else {
+		i := strings.IndexByte(s, ' ')
+		if i < 0 || !isPlainIdentifier(s[:i]) {
+			return "", "", false
+		}
+		name, rest = s[:i], s[i:]
+	}
+	rest = strings.TrimSpace(rest)
+	if rest == "" || strings.EqualFold(rest, "NULL") || strings.EqualFold(rest, "NOT NULL") {
+		return "", "", false
+	}
+	return name, rest, true
+}
+
+// parseEnumValue parses an Enum value such as `'a' = 1` into a TypeDesc named
+// after the value, with the number as its argument if there is one.
+func parseEnumValue(s string) (*TypeDesc, error) {
+	var sb strings.Builder
+	i := 1
+	for ; i < len(s) && s[i] != '\''; i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	if i == len(s) {
+		return nil, fmt.Errorf("invalid enum value: %s", s)
+	}
+	desc := &TypeDesc{Name: sb.String(), Args: []*TypeDesc{}}
+	rest := strings.TrimSpace(s[i+1:])
+	if rest == "" {
+		return desc, nil
+	}
+	code, ok := strings.CutPrefix(rest, "=")
+	if !ok {
+		return nil, fmt.Errorf("invalid enum value: %s", s)
+	}
+	desc.Args = append(desc.Args, &TypeDesc{Name: strings.TrimSpace(code), Args: []*TypeDesc{}})
+	return desc, nil
+}
+
+func (desc *TypeDesc) isEnum() bool {
+	return strings.HasPrefix(desc.Name, "Enum")
+}
+
 func (desc *TypeDesc) Normalize() *TypeDesc {
 	switch desc.Name {
 	case "Nullable":
 		sub := desc.Args[0].Normalize()
 		sub.Nullable = true
+		sub.field = desc.field
 		return sub
 	case "DateTime":
 		desc.Name = "Timestamp"
@@ -92,3 +198,37 @@ func (desc *TypeDesc) Normalize() *TypeDesc {
 		return desc
 	}
 }
+
+// String formats the type the way Databend spells it, e.g. `Array(Int32) NULL`.
+func (desc *TypeDesc) String() string {
+	var sb strings.Builder
+	if desc.field != "" {
+		if isPlainIdentifier(desc.field) {
+			sb.WriteString(desc.field + " ")
+		} else {
+			sb.WriteString(QuoteIdentifier(desc.field) + " ")
+		}
+	}
+	sb.WriteString(desc.Name)
+	if len(desc.Args) > 0 {
+		sb.WriteString("(")
+		for i, arg := range desc.Args {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			if desc.isEnum() {
+				sb.WriteString(quote(escape(arg.Name)))
+				if len(arg.Args) > 0 {
+					sb.WriteString(" = " + arg.Args[0].Name)
+				}
+				continue
+			}
+			sb.WriteString(arg.String())
+		}
+		sb.WriteString(")")
+	}
+	if desc.Nullable {
+		sb.WriteString(" NULL")
+	}
+	return sb.String()
+}
@@ -177,6 +177,29 @@ func TestParseTypeDesc(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc:  "enum type",
+			input: `Enum8('a' = 1, 'b, \'c\')' = -2)`,
+			output: &TypeDesc{
+				Name:     "Enum8",
+				Nullable: false,
+				Args: []*TypeDesc{
+					{
+						Name: "a",
+						Args: []*TypeDesc{{Name: "1", Args: []*TypeDesc{}}},
+					},
+					{
+						Name: "b, 'c')",
+						Args: []*TypeDesc{{Name: "-2", Args: []*TypeDesc{}}},
+					},
+				},
+			},
+		},
+		{
+			desc:  "unterminated enum value",
+			input: "Enum8('a = 1)",
+			fail:  true,
+		},
 	}
 
 	for _, tc := range testCases {
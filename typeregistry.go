@@ -0,0 +1,117 @@
+package godatabend
+
+import (
+	"database/sql/driver"
+	"sync"
+
+	"github.com/apache/arrow-go/v18/arrow"
+)
+
+// ColumnTypeFactory creates the ColumnType of a column, whose Parse method
+// decodes the values of JSON results.
+type ColumnTypeFactory func(desc *TypeDesc, opts *ColumnTypeOptions) (ColumnType, error)
+
+// ArrowValueFunc returns the driver value at row rowIdx of an Arrow result
+// column. It is not called for NULL values.
+type ArrowValueFunc func(desc *TypeDesc, column arrow.Array, rowIdx int, opts *ColumnTypeOptions) (driver.Value, error)
+
+// TypeRegistry overrides how result columns of a Databend type, such as
+// "Timestamp" or "Decimal", are decoded. Names are matched against the type
+// without Nullable, which is reported by desc.Nullable instead.
+//
+// If a type has a ColumnTypeFactory but no ArrowValueFunc, the values of Arrow
+// results are converted to text and decoded by the ColumnType's Parse method,
+// so that both result formats produce the same values.
+type TypeRegistry struct {
+	mu          sync.RWMutex
+	columnTypes map[string]ColumnTypeFactory
+	arrowValues map[string]ArrowValueFunc
+}
+
+// NewTypeRegistry returns an empty registry, to be set as Config.Types. The
+// zero value is an empty registry too.
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{
+		columnTypes: map[string]ColumnTypeFactory{},
+		arrowValues: map[string]ArrowValueFunc{},
+	}
+}
+
+var defaultTypeRegistry = NewTypeRegistry()
+
+// RegisterColumnType overrides how columns of the type are decoded for every
+// connection. Types registered in Config.Types take precedence.
+func RegisterColumnType(name string, factory ColumnTypeFactory) {
+	defaultTypeRegistry.RegisterColumnType(name, factory)
+}
+
+// RegisterArrowType overrides how columns of the type are read from Arrow
+// results for every connection. Types registered in Config.Types take
+// precedence.
+func RegisterArrowType(name string, fn ArrowValueFunc) {
+	defaultTypeRegistry.RegisterArrowType(name, fn)
+}
+
+// RegisterColumnType sets the factory of the type, or removes it if factory
+// is nil.
+func (r *TypeRegistry) RegisterColumnType(name string, factory ColumnTypeFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if factory == nil {
+		delete(r.columnTypes, name)
+		return
+	}
+	if r.columnTypes == nil {
+		r.columnTypes = map[string]ColumnTypeFactory{}
+	}
+	r.columnTypes[name] = factory
+}
+
+// RegisterArrowType sets the Arrow reader of the type, or removes it if fn is
+// nil.
+func (r *TypeRegistry) RegisterArrowType(name string, fn ArrowValueFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if fn == nil {
+		delete(r.arrowValues, name)
+		return
+	}
+	if r.arrowValues == nil {
+		r.arrowValues = map[string]ArrowValueFunc{}
+	}
+	r.arrowValues[name] = fn
+}
+
+// columnType returns the factory of the type from r, or from the default
+// registry. r may be nil.
+func (r *TypeRegistry) columnType(name string) ColumnTypeFactory {
+	for _, reg := range []*TypeRegistry{r, defaultTypeRegistry} {
+		if reg == nil {
+			continue
+		}
+		reg.mu.RLock()
+		factory := reg.columnTypes[name]
+		reg.mu.RUnlock()
+		if factory != nil {
+			return factory
+		}
+	}
+	return nil
+}
+
+// arrowValue returns the Arrow reader of the type from r, or from the default
+// registry. r may be nil.
+func (r *TypeRegistry) arrowValue(name string) ArrowValueFunc {
+	for _, reg := range []*TypeRegistry{r, defaultTypeRegistry} {
+		if reg == nil {
+			continue
+		}
+		reg.mu.RLock()
+		fn := reg.arrowValues[name]
+		reg.mu.RUnlock()
+		if fn != nil {
+			return fn
+		}
+	}
+	return nil
+}
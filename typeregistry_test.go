@@ -0,0 +1,145 @@
+package godatabend
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	arrowarray "github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type civilDate struct {
+	Year, Month, Day int
+}
+
+type civilDateColumnType struct {
+	columnTypeDefault
+	isNullable
+}
+
+func (c civilDateColumnType) Parse(s string) (driver.Value, error) {
+	var d civilDate
+	if _, err := fmt.Sscanf(s, "%d-%d-%d", &d.Year, &d.Month, &d.Day); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (civilDateColumnType) ScanType() reflect.Type {
+	return reflect.TypeOf(civilDate{})
+}
+
+func (c civilDateColumnType) DatabaseTypeName() string {
+	return c.wrapName("Date")
+}
+
+func (c civilDateColumnType) Desc() *TypeDesc {
+	return &TypeDesc{Name: "Date", Nullable: bool(c.isNullable)}
+}
+
+func newCivilDateColumnType(desc *TypeDesc, opts *ColumnTypeOptions) (ColumnType, error) {
+	return civilDateColumnType{isNullable: isNullable(desc.Nullable)}, nil
+}
+
+func TestTypeRegistryColumnType(t *testing.T) {
+	types := NewTypeRegistry()
+	types.RegisterColumnType("Date", newCivilDateColumnType)
+
+	opts := defaultColumnTypeOptions()
+	opts.SetTypeRegistry(types)
+	colType, err := NewColumnType("Nullable(Date)", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Date NULL", colType.DatabaseTypeName())
+	v, err := colType.Parse("2024-01-02")
+	require.NoError(t, err)
+	assert.Equal(t, civilDate{2024, 1, 2}, v)
+
+	// other configs are not affected
+	colType, err = NewColumnType("Date", nil)
+	require.NoError(t, err)
+	assert.Equal(t, reflectTypeTime, colType.ScanType())
+
+	types.RegisterColumnType("Date", nil)
+	colType, err = NewColumnType("Date", opts)
+	require.NoError(t, err)
+	assert.Equal(t, reflectTypeTime, colType.ScanType())
+}
+
+func TestTypeRegistryZeroValue(t *testing.T) {
+	types := &TypeRegistry{}
+	types.RegisterColumnType("Date", newCivilDateColumnType)
+	types.RegisterArrowType("Date", nil)
+
+	opts := defaultColumnTypeOptions()
+	opts.SetTypeRegistry(types)
+	colType, err := NewColumnType("Date", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Date", colType.DatabaseTypeName())
+	v, err := colType.Parse("2024-01-02")
+	require.NoError(t, err)
+	assert.Equal(t, civilDate{2024, 1, 2}, v)
+}
+
+func TestRegisterColumnTypeGlobal(t *testing.T) {
+	RegisterColumnType("Date", newCivilDateColumnType)
+	defer RegisterColumnType("Date", nil)
+
+	colType, err := NewColumnType("Date", nil)
+	require.NoError(t, err)
+	assert.Equal(t, reflect.TypeOf(civilDate{}), colType.ScanType())
+
+	// a Config's registry takes precedence
+	types := NewTypeRegistry()
+	types.RegisterColumnType("Date", func(desc *TypeDesc, opts *ColumnTypeOptions) (ColumnType, error) {
+		return &simpleColumnType{dbType: "Date", scanType: reflectTypeString}, nil
+	})
+	opts := defaultColumnTypeOptions()
+	opts.SetTypeRegistry(types)
+	colType, err = NewColumnType("Date", opts)
+	require.NoError(t, err)
+	assert.Equal(t, reflectTypeString, colType.ScanType())
+}
+
+func TestTypeRegistryArrow(t *testing.T) {
+	resp := QueryResponse{
+		ID:     "query-registry",
+		Schema: &[]DataField{{Name: "d", Type: "Nullable(Date)"}, {Name: "i", Type: "Int32"}},
+	}
+	payload := buildArrowPayload(t, resp, []arrow.Field{
+		{Name: "d", Type: arrow.FixedWidthTypes.Date32, Nullable: true},
+		{Name: "i", Type: arrow.PrimitiveTypes.Int32},
+	}, func(builder *arrowarray.RecordBuilder) {
+		builder.Field(0).(*arrowarray.Date32Builder).AppendValues([]arrow.Date32{19724, 0}, []bool{true, false})
+		builder.Field(1).(*arrowarray.Int32Builder).AppendValues([]int32{7, 8}, nil)
+	})
+	raw := &rawHTTPResponse{
+		headers: http.Header{contentType: []string{arrowStreamContentType}},
+		body:    payload,
+	}
+
+	// without an Arrow reader, the text form is decoded by the ColumnType
+	types := NewTypeRegistry()
+	types.RegisterColumnType("Date", newCivilDateColumnType)
+	decoded, err := decodeQueryResponse(types, raw)
+	require.NoError(t, err)
+	require.Len(t, decoded.typedRows, 2)
+	assert.Equal(t, civilDate{2024, 1, 2}, decoded.typedRows[0][0])
+	assert.Nil(t, decoded.typedRows[1][0])
+	assert.Equal(t, "7", decoded.typedRows[0][1])
+
+	types.RegisterArrowType("Int32", func(desc *TypeDesc, column arrow.Array, rowIdx int, opts *ColumnTypeOptions) (driver.Value, error) {
+		return int64(column.(*arrowarray.Int32).Value(rowIdx)) * 10, nil
+	})
+	decoded, err = decodeQueryResponse(types, raw)
+	require.NoError(t, err)
+	assert.Equal(t, int64(70), decoded.typedRows[0][1])
+
+	decoded, err = decodeQueryResponse(nil, raw)
+	require.NoError(t, err)
+	assert.Equal(t, "7", decoded.typedRows[0][1])
+}
@@ -8,30 +8,13 @@ import (
 	"time"
 )
 
-func Map(v interface{}) driver.Valuer {
-	return tmap{v: v}
-}
-
-type tmap struct {
-	v interface{}
-}
-
-func (a tmap) Value() (driver.Value, error) {
-	return textEncode.Encode(a)
-}
-
-// Array wraps slice or array into driver.Valuer interface to allow pass through it from database/sql
-func Array(v interface{}) driver.Valuer {
-	return array{v: v}
-}
-
-type array struct {
-	v interface{}
-}
+// Raw marks a string as a SQL fragment that is interpolated into the query
+// verbatim, without quoting or escaping. Never wrap untrusted input with it.
+type Raw string
 
 // Value implements driver.Valuer
-func (a array) Value() (driver.Value, error) {
-	return textEncode.Encode(a)
+func (r Raw) Value() (driver.Value, error) {
+	return []byte(r), nil
 }
 
 // Date returns date for t
@@ -99,26 +82,7 @@ func (i ip) Value() (driver.Value, error) {
 	return net.IP(i).String(), nil
 }
 
-// Tuple converts a struct into a tuple
-// struct{A string, B int}{"a", 1} -> ("a", 1)
-func Tuple(v interface{}) driver.Valuer {
-	return tuple{v: v}
-}
-
-type tuple struct {
-	v interface{}
-}
-
-func (t tuple) Value() (driver.Value, error) {
-	return textEncode.Encode(t)
-}
-
+// Interval returns the interval of t, truncated to microseconds, as a parameter.
 func Interval(t time.Duration) driver.Valuer {
-	return interval(t)
-}
-
-type interval time.Duration
-
-func (d interval) Value() (driver.Value, error) {
-	return []byte(quote(strconv.FormatInt(time.Duration(d).Microseconds(), 10))), nil
+	return NewIntervalFromDuration(t)
 }
@@ -11,20 +11,21 @@ import (
 
 func TestArray(t *testing.T) {
 	testCases := []struct {
-		value    interface{}
+		value    driver.Valuer
 		expected driver.Value
 	}{
-		{[]int16{1, 2}, []byte("[1,2]")},
-		{[]int32{1, 2}, []byte("[1,2]")},
-		{[]int64{1, 2}, []byte("[1,2]")},
-		{[]uint16{1, 2}, []byte("[1,2]")},
-		{[]uint32{1, 2}, []byte("[1,2]")},
-		{[]uint64{1, 2}, []byte("[1,2]")},
-		{[]uint64{}, []byte("[]")},
+		{Array[int16]{1, 2}, []byte("[1,2]")},
+		{Array[int32]{1, 2}, []byte("[1,2]")},
+		{Array[int64]{1, 2}, []byte("[1,2]")},
+		{Array[uint16]{1, 2}, []byte("[1,2]")},
+		{Array[uint32]{1, 2}, []byte("[1,2]")},
+		{Array[uint64]{1, 2}, []byte("[1,2]")},
+		{Array[uint64]{}, []byte("[]")},
+		{Array[*string]{nil}, []byte("[NULL]")},
 	}
 
 	for _, tc := range testCases {
-		dv, err := Array(tc.value).Value()
+		dv, err := tc.value.Value()
 		if assert.NoError(t, err) {
 			assert.Equal(t, tc.expected, dv)
 		}
@@ -0,0 +1,146 @@
+package godatabend
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+var reflectTypeVariant = reflect.TypeOf(Variant(nil))
+
+// Variant is the value of a Variant column: a JSON document. A nil Variant is
+// SQL NULL, so it can be scanned from nullable columns directly.
+//
+// As a parameter, a Variant is bound as a Variant value rather than as a string.
+type Variant json.RawMessage
+
+// NewVariant marshals v, such as a map or a struct, to JSON.
+func NewVariant(v any) (Variant, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return Variant(data), nil
+}
+
+// RawMessage returns the JSON document.
+func (v Variant) RawMessage() json.RawMessage {
+	return json.RawMessage(v)
+}
+
+// IsNull reports whether v is SQL NULL or JSON null.
+func (v Variant) IsNull() bool {
+	return v == nil || string(bytes.TrimSpace(v)) == "null"
+}
+
+// Unmarshal decodes the document into dest, with the rules of json.Unmarshal.
+func (v Variant) Unmarshal(dest any) error {
+	if v == nil {
+		return fmt.Errorf("cannot unmarshal NULL variant")
+	}
+	return json.Unmarshal(v, dest)
+}
+
+// Get returns the element at path, where a string selects an object key and an
+// int an array index, e.g. Get("a", 0, "b") for `v['a'][0]['b']`. The result is
+// false if the path does not exist.
+func (v Variant) Get(path ...any) (Variant, bool) {
+	cur := json.RawMessage(v)
+	for _, p := range path {
+		switch key := p.(type) {
+		case string:
+			var obj map[string]json.RawMessage
+			if err := json.Unmarshal(cur, &obj); err != nil || obj == nil {
+				return nil, false
+			}
+			next, ok := obj[key]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case int:
+			var arr []json.RawMessage
+			if err := json.Unmarshal(cur, &arr); err != nil || key < 0 || key >= len(arr) {
+				return nil, false
+			}
+			cur = arr[key]
+		default:
+			return nil, false
+		}
+	}
+	return Variant(cur), true
+}
+
+// String returns the JSON text, or "NULL".
+func (v Variant) String() string {
+	if v == nil {
+		return "NULL"
+	}
+	return string(v)
+}
+
+// MarshalJSON returns the document itself, so a Variant can be embedded in other JSON.
+func (v Variant) MarshalJSON() ([]byte, error) {
+	if v == nil {
+		return []byte("null"), nil
+	}
+	return v, nil
+}
+
+// UnmarshalJSON keeps a copy of the document.
+func (v *Variant) UnmarshalJSON(data []byte) error {
+	*v = append((*v)[:0], data...)
+	return nil
+}
+
+// Value implements driver.Valuer
+func (v Variant) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return string(v), nil
+}
+
+// Scan implements sql.Scanner
+func (v *Variant) Scan(src any) error {
+	switch s := src.(type) {
+	case nil:
+		*v = nil
+	case string:
+		*v = Variant(s)
+	case []byte:
+		*v = append(Variant(nil), s...)
+	case Variant:
+		*v = append(Variant(nil), s...)
+	default:
+		return fmt.Errorf("cannot scan %T into Variant", src)
+	}
+	return nil
+}
+
+type variantColumnType struct {
+	dbType string
+	columnTypeDefault
+	isNullable
+}
+
+func (c variantColumnType) Parse(s string) (driver.Value, error) {
+	if c.checkNull(s) {
+		return nil, nil
+	}
+	return s, nil
+}
+
+func (variantColumnType) ScanType() reflect.Type {
+	return reflectTypeVariant
+}
+
+func (c variantColumnType) DatabaseTypeName() string {
+	return c.wrapName(c.dbType)
+}
+
+func (c variantColumnType) Desc() *TypeDesc {
+	return &TypeDesc{Name: c.dbType, Nullable: bool(c.isNullable)}
+}
@@ -0,0 +1,154 @@
+package godatabend
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	arrowarray "github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariantGet(t *testing.T) {
+	v := Variant(`{"a":[{"b":1},{"b":"x"}],"c":null}`)
+
+	got, ok := v.Get("a", 1, "b")
+	require.True(t, ok)
+	assert.Equal(t, `"x"`, got.String())
+
+	got, ok = v.Get("c")
+	require.True(t, ok)
+	assert.True(t, got.IsNull())
+
+	for _, path := range [][]any{{"missing"}, {"a", 2}, {"a", -1}, {"a", "b"}, {"c", "d"}, {1.5}} {
+		_, ok := v.Get(path...)
+		assert.False(t, ok, path)
+	}
+
+	whole, ok := v.Get()
+	require.True(t, ok)
+	assert.Equal(t, v, whole)
+}
+
+func TestVariantUnmarshal(t *testing.T) {
+	type item struct {
+		B int `json:"b"`
+	}
+	var dest struct {
+		A []item `json:"a"`
+	}
+	require.NoError(t, Variant(`{"a":[{"b":1},{"b":2}]}`).Unmarshal(&dest))
+	assert.Equal(t, []item{{B: 1}, {B: 2}}, dest.A)
+
+	assert.Error(t, Variant(nil).Unmarshal(&dest))
+}
+
+func TestNewVariant(t *testing.T) {
+	v, err := NewVariant(map[string]any{"k": []int{1, 2}})
+	require.NoError(t, err)
+	assert.Equal(t, `{"k":[1,2]}`, string(v))
+
+	encoded, err := textEncode.Encode(Variant(`{"a":"it's"}`))
+	require.NoError(t, err)
+	assert.Equal(t, `PARSE_JSON('{"a":"it\'s"}')`, string(encoded))
+
+	encoded, err = textEncode.Encode(Variant(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "NULL", string(encoded))
+}
+
+func TestVariantScan(t *testing.T) {
+	db := sql.OpenDB(&fakeConnector{
+		resp: &QueryResponse{
+			Schema: &[]DataField{{Name: "x", Type: "Nullable(Variant)"}, {Name: "y", Type: "Variant NULL"}},
+			Data:   [][]*string{{strPtr(`{"k":1}`), nil}},
+		},
+	})
+
+	rows, err := db.Query("x")
+	require.NoError(t, err)
+	types, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	assert.Equal(t, reflectTypeVariant, types[0].ScanType())
+	assert.Equal(t, "Variant NULL", types[0].DatabaseTypeName())
+
+	require.True(t, rows.Next())
+	var x, y Variant
+	require.NoError(t, rows.Scan(&x, &y))
+	assert.Equal(t, Variant(`{"k":1}`), x)
+	assert.Nil(t, y)
+	require.NoError(t, rows.Close())
+}
+
+// jsonbValue builds a JSONB container from entries and their data.
+func jsonbValue(header uint32, entries []uint32, data ...[]byte) []byte {
+	buf := binary.BigEndian.AppendUint32(nil, header)
+	for _, e := range entries {
+		buf = binary.BigEndian.AppendUint32(buf, e)
+	}
+	for _, d := range data {
+		buf = append(buf, d...)
+	}
+	return buf
+}
+
+func TestVariantTextDecodesJSONB(t *testing.T) {
+	arr := jsonbValue(jsonbArrayContainer|2,
+		[]uint32{jsonbNumber | 2, jsonbString | 1},
+		[]byte{jsonbNumberInt, 0xff}, []byte("x"))
+	obj := jsonbValue(jsonbObjectContainer|3,
+		[]uint32{jsonbString | 1, jsonbString | 1, jsonbString | 1, jsonbContainer | uint32(len(arr)), jsonbNull, jsonbTrue},
+		[]byte("a"), []byte("b"), []byte(`"`), arr)
+	float := binary.BigEndian.AppendUint64([]byte{jsonbNumberFloat}, math.Float64bits(1.5))
+	uint64Max := append([]byte{jsonbNumberUint}, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+
+	tests := []struct {
+		input []byte
+		want  string
+	}{
+		{input: obj, want: `{"a":[-1,"x"],"b":null,"\"":true}`},
+		{input: jsonbValue(jsonbScalarContainer|1, []uint32{jsonbNumber | 9}, float), want: "1.5"},
+		{input: jsonbValue(jsonbScalarContainer|1, []uint32{jsonbNumber | 9}, uint64Max), want: "18446744073709551615"},
+		{input: jsonbValue(jsonbScalarContainer|1, []uint32{jsonbString | 2}, []byte("hi")), want: `"hi"`},
+		{input: jsonbValue(jsonbArrayContainer|0, nil), want: "[]"},
+		{input: []byte(`{"already":"json"}`), want: `{"already":"json"}`},
+	}
+	for _, tc := range tests {
+		got, err := variantText(tc.input)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got)
+	}
+
+	_, err := variantText(jsonbValue(jsonbArrayContainer|2, []uint32{jsonbString | 5}))
+	assert.Error(t, err)
+}
+
+func TestDecodeArrowResponseMaterializesVariant(t *testing.T) {
+	resp := QueryResponse{
+		ID:     "query-variant",
+		Schema: &[]DataField{{Name: "v", Type: "Variant"}},
+	}
+	doc := jsonbValue(jsonbObjectContainer|1, []uint32{jsonbString | 1, jsonbNumber | 2}, []byte("k"), []byte{jsonbNumberInt, 7})
+
+	payload := buildArrowPayload(t, resp, []arrow.Field{
+		{
+			Name:     "v",
+			Type:     arrow.BinaryTypes.LargeBinary,
+			Metadata: arrow.NewMetadata([]string{arrowExtensionKey}, []string{arrowExtensionVariant}),
+		},
+	}, func(builder *arrowarray.RecordBuilder) {
+		builder.Field(0).(*arrowarray.BinaryBuilder).Append(doc)
+	})
+
+	decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
+		headers: http.Header{contentType: []string{arrowStreamContentType}},
+		body:    payload,
+	})
+	require.NoError(t, err)
+	require.Len(t, decoded.typedRows, 1)
+	assert.Equal(t, `{"k":7}`, decoded.typedRows[0][0])
+}
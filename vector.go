@@ -0,0 +1,99 @@
+package godatabend
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	arrowarray "github.com/apache/arrow-go/v18/arrow/array"
+)
+
+var reflectTypeFloat32Slice = reflect.TypeOf([]float32(nil))
+
+// vectorDimension returns N of a Vector(N) type.
+func vectorDimension(desc *TypeDesc) (int, error) {
+	if len(desc.Args) != 1 {
+		return 0, fmt.Errorf("dimension not specified for Vector")
+	}
+	dim, err := strconv.Atoi(desc.Args[0].Name)
+	if err != nil || dim <= 0 {
+		return 0, fmt.Errorf("malformed dimension specified for Vector: %q", desc.Args[0].Name)
+	}
+	return dim, nil
+}
+
+// parseVector parses the text form of a vector, e.g. `[0.1,0.2,0.3]`.
+func parseVector(s string, dim int) ([]float32, error) {
+	text := strings.TrimSpace(s)
+	if len(text) < 2 || text[0] != '[' || text[len(text)-1] != ']' {
+		return nil, fmt.Errorf("invalid vector %q", s)
+	}
+	text = text[1 : len(text)-1]
+	values := make([]float32, 0, dim)
+	if strings.TrimSpace(text) != "" {
+		for _, field := range strings.Split(text, ",") {
+			v, err := strconv.ParseFloat(strings.TrimSpace(field), 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid vector %q", s)
+			}
+			values = append(values, float32(v))
+		}
+	}
+	if len(values) != dim {
+		return nil, fmt.Errorf("vector has %d dimensions, expected %d", len(values), dim)
+	}
+	return values, nil
+}
+
+func materializeArrowVectorDriverValue(desc *TypeDesc, column arrow.Array, rowIdx int) (driver.Value, error) {
+	dim, err := vectorDimension(desc)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := column.(*arrowarray.FixedSizeList)
+	if !ok {
+		return nil, fmt.Errorf("unsupported arrow vector column %T", column)
+	}
+	elems, ok := list.ListValues().(*arrowarray.Float32)
+	if !ok {
+		return nil, fmt.Errorf("unsupported arrow vector element column %T", list.ListValues())
+	}
+	start, end := list.ValueOffsets(rowIdx)
+	if int(end-start) != dim {
+		return nil, fmt.Errorf("vector has %d dimensions, expected %d", end-start, dim)
+	}
+	return append([]float32(nil), elems.Float32Values()[start:end]...), nil
+}
+
+type vectorColumnType struct {
+	dim int
+	columnTypeDefault
+	isNullable
+}
+
+func (c vectorColumnType) Parse(s string) (driver.Value, error) {
+	if c.checkNull(s) {
+		return nil, nil
+	}
+	return parseVector(s, c.dim)
+}
+
+func (vectorColumnType) ScanType() reflect.Type {
+	return reflectTypeFloat32Slice
+}
+
+func (c vectorColumnType) DatabaseTypeName() string {
+	return c.wrapName(fmt.Sprintf("Vector(%d)", c.dim))
+}
+
+func (c vectorColumnType) Desc() *TypeDesc {
+	return &TypeDesc{Name: "Vector", Nullable: bool(c.isNullable), Args: []*TypeDesc{{Name: strconv.Itoa(c.dim)}}}
+}
+
+// Length returns the dimension of the vectors.
+func (c vectorColumnType) Length() (int64, bool) {
+	return int64(c.dim), true
+}
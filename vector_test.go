@@ -0,0 +1,108 @@
+package godatabend
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	arrowarray "github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVector(t *testing.T) {
+	v, err := parseVector("[0.1, -2,3e-3]", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []float32{0.1, -2, 0.003}, v)
+
+	for _, input := range []string{"", "[]", "[1,2]", "[1,2,3,4]", "1,2,3", "[1,x,3]"} {
+		_, err := parseVector(input, 3)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestVectorColumnType(t *testing.T) {
+	db := sql.OpenDB(&fakeConnector{
+		resp: &QueryResponse{
+			Schema: &[]DataField{{Name: "x", Type: "Nullable(Vector(3))"}},
+			Data:   [][]*string{{strPtr("[1,2.5,-3]")}, {nil}},
+		},
+	})
+
+	rows, err := db.Query("x")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	require.NoError(t, err)
+	assert.Equal(t, reflectTypeFloat32Slice, types[0].ScanType())
+	assert.Equal(t, "Vector(3) NULL", types[0].DatabaseTypeName())
+	length, ok := types[0].Length()
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), length)
+
+	require.True(t, rows.Next())
+	var v []float32
+	require.NoError(t, rows.Scan(&v))
+	assert.Equal(t, []float32{1, 2.5, -3}, v)
+
+	require.True(t, rows.Next())
+	var null *[]float32
+	require.NoError(t, rows.Scan(&null))
+	assert.Nil(t, null)
+
+	db = sql.OpenDB(&fakeConnector{
+		resp: &QueryResponse{
+			Schema: &[]DataField{{Name: "x", Type: "Vector(3)"}},
+			Data:   [][]*string{{strPtr("[1,2]")}},
+		},
+	})
+	_, err = db.Query("x")
+	assert.ErrorContains(t, err, "vector has 2 dimensions, expected 3")
+}
+
+func TestDecodeArrowResponseMaterializesVector(t *testing.T) {
+	resp := QueryResponse{
+		ID:     "query-vector",
+		Schema: &[]DataField{{Name: "v", Type: "Vector(2)"}},
+	}
+
+	payload := buildArrowPayload(t, resp, []arrow.Field{
+		{
+			Name:     "v",
+			Type:     arrow.FixedSizeListOf(2, arrow.PrimitiveTypes.Float32),
+			Metadata: arrow.NewMetadata([]string{arrowExtensionKey}, []string{arrowExtensionVector}),
+		},
+	}, func(builder *arrowarray.RecordBuilder) {
+		list := builder.Field(0).(*arrowarray.FixedSizeListBuilder)
+		values := list.ValueBuilder().(*arrowarray.Float32Builder)
+		list.Append(true)
+		values.AppendValues([]float32{0.5, 1}, nil)
+		list.Append(true)
+		values.AppendValues([]float32{-1, 2}, nil)
+	})
+
+	decoded, err := decodeQueryResponse(nil, &rawHTTPResponse{
+		headers: http.Header{contentType: []string{arrowStreamContentType}},
+		body:    payload,
+	})
+	require.NoError(t, err)
+	require.Len(t, decoded.typedRows, 2)
+	assert.Equal(t, []float32{0.5, 1}, decoded.typedRows[0][0])
+	assert.Equal(t, []float32{-1, 2}, decoded.typedRows[1][0])
+
+	resp.Schema = &[]DataField{{Name: "v", Type: "Vector(3)"}}
+	payload = buildArrowPayload(t, resp, []arrow.Field{
+		{Name: "v", Type: arrow.FixedSizeListOf(2, arrow.PrimitiveTypes.Float32)},
+	}, func(builder *arrowarray.RecordBuilder) {
+		list := builder.Field(0).(*arrowarray.FixedSizeListBuilder)
+		list.Append(true)
+		list.ValueBuilder().(*arrowarray.Float32Builder).AppendValues([]float32{0.5, 1}, nil)
+	})
+	_, err = decodeQueryResponse(nil, &rawHTTPResponse{
+		headers: http.Header{contentType: []string{arrowStreamContentType}},
+		body:    payload,
+	})
+	assert.ErrorContains(t, err, "vector has 2 dimensions, expected 3")
+}